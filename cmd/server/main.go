@@ -51,6 +51,11 @@ func init() {
 // It parses command-line flags, loads configuration, and starts the appropriate
 // service based on the provided flags (login, codex-login, or server mode).
 func main() {
+	// Operator subcommands talk to a running proxy and keep stdout clean for scripting.
+	if len(os.Args) > 1 && cmd.IsOperatorCommand(os.Args[1]) {
+		os.Exit(cmd.RunOperatorCommand(os.Args[1:]))
+	}
+
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
 	// Command-line flags to control the application's behavior.
@@ -65,7 +70,12 @@ func main() {
 	var antigravityLogin bool
 	var projectID string
 	var vertexImport string
+	var nativeImport string
+	var nativeImportFile string
+	var nativeImportEmail string
 	var configPath string
+	var validateConfig bool
+	var configSchemaPath string
 	var password string
 
 	// Define command-line flags for different operation modes.
@@ -81,6 +91,11 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.StringVar(&nativeImport, "import", "", "Import credentials from a native CLI: claude, codex, gemini, qwen or all")
+	flag.StringVar(&nativeImportFile, "import-file", "", "Credential file for -import (defaults to the CLI's own location)")
+	flag.StringVar(&nativeImportEmail, "import-email", "", "Account email for -import when the credential file lacks one")
+	flag.BoolVar(&validateConfig, "validate-config", false, "Strictly validate the config file, report problems and exit")
+	flag.StringVar(&configSchemaPath, "config-schema", "", "Write the config JSON Schema to the given file and exit")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -113,6 +128,17 @@ func main() {
 	// Parse the command-line flags.
 	flag.Parse()
 
+	if configSchemaPath != "" {
+		os.Exit(cmd.DoWriteConfigSchema(configSchemaPath))
+	}
+	if validateConfig {
+		target := configPath
+		if target == "" {
+			target = "config.yaml"
+		}
+		os.Exit(cmd.DoValidateConfig(target))
+	}
+
 	// Core application variables.
 	var err error
 	var cfg *config.Config
@@ -450,6 +476,9 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
+	} else if nativeImport != "" {
+		// Handle native CLI credential import
+		cmd.DoNativeImport(cfg, nativeImport, nativeImportFile, nativeImportEmail, projectID)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
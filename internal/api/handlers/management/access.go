@@ -0,0 +1,188 @@
+package management
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// managementRoutePrefix is the route group every management endpoint is registered under.
+const managementRoutePrefix = "/v0/management"
+
+// fullAccess is granted to the secret-key, MANAGEMENT_PASSWORD and the local password.
+var fullAccess = []string{config.ManagementScopeAdmin}
+
+// readScopes satisfy routes that only read non-secret state.
+var readScopes = []string{config.ManagementScopeReadOnly, config.ManagementScopeConfigWrite, config.ManagementScopeCredentialsWrite}
+
+// credentialRoutes expose or modify upstream keys, auth files or OAuth logins. Every method on
+// them requires credentials:write except the listings in credentialReadRoutes.
+var credentialRoutes = []string{
+	"/api-keys",
+	"/gemini-api-key",
+	"/claude-api-key",
+	"/codex-api-key",
+	"/vertex-api-key",
+	"/openai-compatibility",
+	"/ampcode",
+	"/auth-files",
+	"/vertex/import",
+	"/native/import",
+	"/api-call",
+	"/oauth-callback",
+	"/get-auth-status",
+}
+
+// credentialReadRoutes list auth files and their models without returning token material.
+var credentialReadRoutes = map[string]bool{
+	"/auth-files":        true,
+	"/auth-files/models": true,
+}
+
+// readOnlyPostRoutes accept a body but never change state.
+var readOnlyPostRoutes = map[string]bool{
+	"/config/validate": true,
+	"/config/diff":     true,
+	"/explain":         true,
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// requiredScopes returns the scopes that allow method on route (a path relative to the
+// management group); holding any one of them is sufficient. admin satisfies everything.
+func requiredScopes(method, route string) []string {
+	read := isReadMethod(method)
+	switch {
+	case route == "/usage" || route == "/usage/export" || route == "/usage/spend" || route == "/codex-usage":
+		if read {
+			return append([]string{config.ManagementScopeUsage}, readScopes...)
+		}
+		return []string{config.ManagementScopeUsage}
+	case route == "/usage/import":
+		return []string{config.ManagementScopeUsage}
+	case isCredentialRoute(route):
+		if read && credentialReadRoutes[route] {
+			return readScopes
+		}
+		return []string{config.ManagementScopeCredentialsWrite}
+	case route == "/config" || route == "/config.yaml":
+		// The raw config carries every upstream key.
+		return []string{config.ManagementScopeConfigWrite}
+	case read || readOnlyPostRoutes[route]:
+		return readScopes
+	default:
+		return []string{config.ManagementScopeConfigWrite}
+	}
+}
+
+func isCredentialRoute(route string) bool {
+	if strings.HasSuffix(route, "-auth-url") {
+		return true
+	}
+	for _, prefix := range credentialRoutes {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// scopesAllow reports whether granted contains admin or one of required.
+func scopesAllow(granted, required []string) bool {
+	for _, scope := range granted {
+		if scope == config.ManagementScopeAdmin {
+			return true
+		}
+		for _, want := range required {
+			if scope == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasAdminScope reports whether the request was authenticated with full access.
+func hasAdminScope(c *gin.Context) bool {
+	granted, _ := c.Value(managementScopesKey).([]string)
+	return slices.Contains(granted, config.ManagementScopeAdmin)
+}
+
+// managementRoute returns the matched route template relative to the management group.
+func managementRoute(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return strings.TrimPrefix(route, managementRoutePrefix)
+}
+
+// matchManagementKey returns the named key matching provided, or nil. Successful bcrypt
+// comparisons are cached by digest so scoped keys don't pay the bcrypt cost on every request.
+func (h *Handler) matchManagementKey(keys []config.ManagementKey, provided string) *config.ManagementKey {
+	if len(keys) == 0 || provided == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(provided))
+	digest := hex.EncodeToString(sum[:])
+	for i := range keys {
+		key := &keys[i]
+		if key.Key == "" {
+			continue
+		}
+		if !isBcryptHash(key.Key) {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(key.Key)) == 1 {
+				return key
+			}
+			continue
+		}
+		cacheKey := digest + "\x00" + key.Key
+		if _, ok := h.keyMatches.Load(cacheKey); ok {
+			return key
+		}
+		if bcrypt.CompareHashAndPassword([]byte(key.Key), []byte(provided)) == nil {
+			h.keyMatches.Store(cacheKey, struct{}{})
+			return key
+		}
+	}
+	return nil
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+// authorize checks the scopes of an authenticated principal against the route, then runs the
+// handler and writes the audit entry for mutating or credential-sensitive calls.
+func (h *Handler) authorize(c *gin.Context, identity string, scopes []string) {
+	c.Set(managementIdentityKey, identity)
+	c.Set(managementScopesKey, scopes)
+	route := managementRoute(c)
+	required := requiredScopes(c.Request.Method, route)
+	// Everything beyond read-only is audited: mutations and reads that expose secrets.
+	audited := !slices.Contains(required, config.ManagementScopeReadOnly)
+	var payload any
+	if audited {
+		payload = auditPayload(c, isCredentialRoute(route))
+	}
+	if !scopesAllow(scopes, required) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope", "required": required})
+		if audited {
+			h.writeAudit(c, identity, route, payload)
+		}
+		return
+	}
+	c.Next()
+	if audited {
+		h.writeAudit(c, identity, route, payload)
+	}
+}
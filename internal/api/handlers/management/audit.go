@@ -0,0 +1,300 @@
+package management
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// auditLogFileName is the append-only JSON lines file holding management audit entries.
+	auditLogFileName = "management-audit.log"
+	// maxAuditBody caps how much of a request body is read for the audit payload.
+	maxAuditBody = 1 << 20
+	// maxAuditPayload caps the encoded payload stored per entry.
+	maxAuditPayload   = 8 << 10
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	redactedValue     = "<redacted>"
+)
+
+// sensitiveFieldPattern matches payload field names whose values are never logged.
+var sensitiveFieldPattern = regexp.MustCompile(`(?i)(key|secret|token|password|authorization|cookie|credential)`)
+
+// auditEntry is one line of the management audit log.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Key     string    `json:"key"`
+	IP      string    `json:"ip"`
+	Method  string    `json:"method"`
+	Route   string    `json:"route"`
+	Path    string    `json:"path"`
+	Status  int       `json:"status"`
+	Payload any       `json:"payload,omitempty"`
+}
+
+// auditLogPath places the audit log next to the request logs, or next to config.yaml.
+func (h *Handler) auditLogPath() string {
+	dir := h.logDir
+	if dir == "" && h.configFilePath != "" {
+		dir = filepath.Dir(h.configFilePath)
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, auditLogFileName)
+}
+
+// writeAudit appends an entry for the current request. Failures are logged and never affect
+// the response.
+func (h *Handler) writeAudit(c *gin.Context, identity, route string, payload any) {
+	path := h.auditLogPath()
+	if path == "" {
+		return
+	}
+	entry := auditEntry{
+		Time:    time.Now().UTC(),
+		Key:     identity,
+		IP:      c.ClientIP(),
+		Method:  c.Request.Method,
+		Route:   route,
+		Path:    c.Request.URL.Path,
+		Status:  c.Writer.Status(),
+		Payload: payload,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Warnf("management audit: encode entry failed: %v", err)
+		return
+	}
+	h.auditMu.Lock()
+	defer h.auditMu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		log.Warnf("management audit: create directory failed: %v", err)
+		return
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Warnf("management audit: open log failed: %v", err)
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.Warnf("management audit: close log failed: %v", errClose)
+		}
+	}()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		log.Warnf("management audit: write entry failed: %v", err)
+	}
+}
+
+// auditPayload reads the request body, restores it for the handler and returns a redacted
+// form suitable for the audit log. With redactAll every string value is hidden, which is used
+// for credential routes whose bodies are bare key lists.
+func auditPayload(c *gin.Context, redactAll bool) any {
+	var payload map[string]any
+	if query := c.Request.URL.Query(); len(query) > 0 {
+		params := make(map[string]any, len(query))
+		for name, values := range query {
+			if redactAll || sensitiveFieldPattern.MatchString(name) {
+				params[name] = redactedValue
+			} else {
+				params[name] = redactURLCredentials(strings.Join(values, ","))
+			}
+		}
+		payload = map[string]any{"query": params}
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nilIfEmpty(payload)
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody+1))
+	if err != nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		return nilIfEmpty(payload)
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nilIfEmpty(payload)
+	}
+	if payload == nil {
+		payload = make(map[string]any)
+	}
+	payload["body"] = summarizeBody(c.GetHeader("Content-Type"), body, redactAll)
+	return payload
+}
+
+func summarizeBody(contentType string, body []byte, redactAll bool) any {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	summary := map[string]any{"bytes": len(body)}
+	if mediaType != "" {
+		summary["content_type"] = mediaType
+	}
+	if len(body) > maxAuditBody {
+		summary["truncated"] = true
+		return summary
+	}
+	var decoded any
+	switch {
+	case mediaType == "application/json" || (mediaType == "" && json.Valid(body)):
+		if json.Unmarshal(body, &decoded) != nil {
+			return summary
+		}
+	case strings.Contains(mediaType, "yaml"):
+		if yaml.Unmarshal(body, &decoded) != nil {
+			return summary
+		}
+	default:
+		return summary
+	}
+	redacted := redactValue(decoded, redactAll)
+	encoded, err := json.Marshal(redacted)
+	if err != nil || len(encoded) > maxAuditPayload {
+		summary["truncated"] = true
+		return summary
+	}
+	return redacted
+}
+
+// redactValue replaces values stored under sensitive field names, or every string when all
+// is set. Passwords embedded in URLs, such as proxy URLs, are masked wherever they appear.
+func redactValue(value any, all bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for name, item := range v {
+			if sensitiveFieldPattern.MatchString(name) {
+				out[name] = redactedValue
+				continue
+			}
+			out[name] = redactValue(item, all)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item, all)
+		}
+		return out
+	case string:
+		if all {
+			return redactedValue
+		}
+		return redactURLCredentials(v)
+	default:
+		return v
+	}
+}
+
+// redactURLCredentials masks the userinfo secret of a URL, the password or a bare token user,
+// and returns any other string unchanged.
+func redactURLCredentials(value string) string {
+	if !strings.Contains(value, "@") || !strings.Contains(value, "://") {
+		return value
+	}
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	if _, hasPassword := parsed.User.Password(); !hasPassword {
+		parsed.User = url.User("xxxxx")
+	}
+	return parsed.Redacted()
+}
+
+func nilIfEmpty(payload map[string]any) any {
+	if len(payload) == 0 {
+		return nil
+	}
+	return payload
+}
+
+// GetAuditLog returns management audit entries, newest first. Query parameters: limit
+// (default 100, max 1000), key (key name or identity), route (prefix) and since (RFC 3339).
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit := defaultAuditLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(parsed, maxAuditLimit)
+	}
+	var since time.Time
+	if raw := strings.TrimSpace(c.Query("since")); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since, expected RFC 3339"})
+			return
+		}
+		since = parsed
+	}
+	keyFilter := strings.TrimSpace(c.Query("key"))
+	routeFilter := strings.TrimSpace(c.Query("route"))
+
+	entries := []auditEntry{}
+	path := h.auditLogPath()
+	if path == "" {
+		c.JSON(http.StatusOK, gin.H{"entries": entries})
+		return
+	}
+	h.auditMu.Lock()
+	file, err := os.Open(path)
+	if err != nil {
+		h.auditMu.Unlock()
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusOK, gin.H{"entries": entries})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read audit log", "message": err.Error()})
+		return
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for scanner.Scan() {
+		var entry auditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if keyFilter != "" && entry.Key != keyFilter && entry.Key != "key:"+keyFilter {
+			continue
+		}
+		if routeFilter != "" && !strings.HasPrefix(entry.Route, routeFilter) {
+			continue
+		}
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	errScan := scanner.Err()
+	_ = file.Close()
+	h.auditMu.Unlock()
+	if errScan != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read audit log", "message": errScan.Error()})
+		return
+	}
+
+	// Entries are appended chronologically; return the newest first.
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
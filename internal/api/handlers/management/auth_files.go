@@ -430,6 +430,26 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if claims := extractCodexIDTokenClaims(auth); claims != nil {
 		entry["id_token"] = claims
 	}
+	if quarantined, reason, since := coreauth.QuarantineInfo(auth); quarantined {
+		entry["quarantined"] = true
+		entry["quarantine_reason"] = reason
+		if !since.IsZero() {
+			entry["quarantined_at"] = since
+		}
+	}
+	if streak := coreauth.FailureStreak(auth); streak > 0 {
+		entry["failure_streak"] = streak
+	}
+	if state := coreauth.AuthScheduleState(auth, time.Now()); state != nil {
+		entry["schedule"] = state.Schedule
+		entry["schedule_open"] = state.Open
+		if state.NextChange != nil {
+			entry["next_schedule_change"] = state.NextChange
+		}
+		if state.Error != "" {
+			entry["schedule_error"] = state.Error
+		}
+	}
 	return entry
 }
 
@@ -665,6 +685,102 @@ func (h *Handler) DeleteAuthFile(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// PatchAuthFileStatus enables or disables a single credential by name or ID.
+// The flag is stored in the auth metadata so it survives reloads and restarts. Enabling a
+// quarantined credential also clears its quarantine and failure streak.
+func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var body struct {
+		Name     string `json:"name"`
+		Disabled *bool  `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Disabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	auth := h.findAuthByName(body.Name)
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	disabled := *body.Disabled
+	auth.Disabled = disabled
+	if disabled {
+		auth.Status = coreauth.StatusDisabled
+		auth.StatusMessage = "disabled via management API"
+	} else {
+		auth.Status = coreauth.StatusActive
+		auth.StatusMessage = ""
+		auth.Unavailable = false
+		auth.NextRetryAfter = time.Time{}
+		auth.NextRefreshAfter = time.Time{}
+		// Re-enabling is the explicit release of a quarantined credential.
+		coreauth.ClearQuarantine(auth)
+	}
+	if auth.Metadata == nil && disabled && authAttribute(auth, "path") != "" {
+		auth.Metadata = make(map[string]any)
+	}
+	if auth.Metadata != nil {
+		if disabled {
+			auth.Metadata["disabled"] = true
+		} else {
+			delete(auth.Metadata, "disabled")
+		}
+	}
+	auth.UpdatedAt = time.Now()
+	if _, err := h.authManager.Update(c.Request.Context(), auth); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "id": auth.ID, "disabled": disabled})
+}
+
+// TestAuthFile sends a minimal request through one credential and reports the outcome.
+func (h *Handler) TestAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var body struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	auth := h.findAuthByName(body.Name)
+	if auth == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+	result, err := h.authManager.ProbeAuth(ctx, auth.ID, body.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// findAuthByName resolves an auth by file name or ID.
+func (h *Handler) findAuthByName(name string) *coreauth.Auth {
+	name = strings.TrimSpace(name)
+	if name == "" || h.authManager == nil {
+		return nil
+	}
+	for _, auth := range h.authManager.List() {
+		if auth != nil && (auth.FileName == name || auth.ID == name) {
+			return auth
+		}
+	}
+	return nil
+}
+
 func (h *Handler) authIDForPath(path string) string {
 	path = strings.TrimSpace(path)
 	if path == "" {
@@ -808,6 +924,11 @@ func (h *Handler) saveTokenRecord(ctx context.Context, record *coreauth.Auth) (s
 
 func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "anthropic")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	fmt.Println("Initializing Claude authentication...")
 
@@ -984,10 +1105,10 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 			Storage:  tokenStorage,
 			Metadata: map[string]any{"email": tokenStorage.Email},
 		}
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
 			log.Errorf("Failed to save authentication tokens: %v", errSave)
-			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save authentication tokens"))
 			return
 		}
 
@@ -1005,11 +1126,19 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 
 func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "gemini")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 	proxyHTTPClient := util.SetProxy(&h.cfg.SDKConfig, &http.Client{})
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, proxyHTTPClient)
 
 	// Optional project ID from query
 	projectID := c.Query("project_id")
+	if projectID == "" && reauth != nil {
+		projectID, _ = reauth.Metadata["project_id"].(string)
+	}
 
 	fmt.Println("Initializing Google authentication...")
 
@@ -1230,10 +1359,10 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 			Storage:  &ts,
 			Metadata: recordMetadata,
 		}
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
 			log.Errorf("Failed to save token to file: %v", errSave)
-			SetOAuthSessionError(state, "Failed to save token to file")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save token to file"))
 			return
 		}
 
@@ -1247,6 +1376,11 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 
 func (h *Handler) RequestCodexToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "codex")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	fmt.Println("Initializing Codex authentication...")
 
@@ -1412,9 +1546,9 @@ func (h *Handler) RequestCodexToken(c *gin.Context) {
 				"account_id": tokenStorage.AccountID,
 			},
 		}
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
-			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save authentication tokens"))
 			log.Errorf("Failed to save authentication tokens: %v", errSave)
 			return
 		}
@@ -1445,6 +1579,11 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 	}
 
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "antigravity")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	fmt.Println("Initializing Antigravity authentication...")
 
@@ -1652,10 +1791,10 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 			Label:    label,
 			Metadata: metadata,
 		}
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
 			log.Errorf("Failed to save token to file: %v", errSave)
-			SetOAuthSessionError(state, "Failed to save token to file")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save token to file"))
 			return
 		}
 
@@ -1673,6 +1812,11 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 
 func (h *Handler) RequestQwenToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "qwen")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	fmt.Println("Initializing Qwen authentication...")
 
@@ -1704,6 +1848,12 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 		tokenStorage := qwenAuth.CreateTokenStorage(tokenData)
 
 		tokenStorage.Email = fmt.Sprintf("qwen-%d", time.Now().UnixMilli())
+		if reauth != nil {
+			// Qwen emails are synthetic; keep the one the credential already has.
+			if email, _ := reauth.Metadata["email"].(string); email != "" {
+				tokenStorage.Email = email
+			}
+		}
 		record := &coreauth.Auth{
 			ID:       fmt.Sprintf("qwen-%s.json", tokenStorage.Email),
 			Provider: "qwen",
@@ -1711,10 +1861,10 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 			Storage:  tokenStorage,
 			Metadata: map[string]any{"email": tokenStorage.Email},
 		}
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
 			log.Errorf("Failed to save authentication tokens: %v", errSave)
-			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save authentication tokens"))
 			return
 		}
 
@@ -1728,6 +1878,11 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 
 func (h *Handler) RequestIFlowToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "iflow")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	fmt.Println("Initializing iFlow authentication...")
 
@@ -1820,9 +1975,9 @@ func (h *Handler) RequestIFlowToken(c *gin.Context) {
 			Attributes: map[string]string{"api_key": tokenStorage.APIKey},
 		}
 
-		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
 		if errSave != nil {
-			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			SetOAuthSessionError(state, loginSaveMessage(errSave, "Failed to save authentication tokens"))
 			log.Errorf("Failed to save authentication tokens: %v", errSave)
 			return
 		}
@@ -1841,6 +1996,11 @@ func (h *Handler) RequestIFlowToken(c *gin.Context) {
 
 func (h *Handler) RequestIFlowCookieToken(c *gin.Context) {
 	ctx := context.Background()
+	reauth, ok := h.reauthTarget(c, "iflow")
+	if !ok {
+		return
+	}
+	allowAccountChange := reauthAllowsAccountChange(c)
 
 	var payload struct {
 		Cookie string `json:"cookie"`
@@ -1868,7 +2028,8 @@ func (h *Handler) RequestIFlowCookieToken(c *gin.Context) {
 	if existingFile, err := iflowauth.CheckDuplicateBXAuth(h.cfg.AuthDir, bxAuth); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "failed to check duplicate"})
 		return
-	} else if existingFile != "" {
+	} else if existingFile != "" && (reauth == nil || filepath.Base(existingFile) != filepath.Base(authAttribute(reauth, "path"))) {
+		// Re-authenticating a credential with its own cookie is not a duplicate.
 		existingFileName := filepath.Base(existingFile)
 		c.JSON(http.StatusConflict, gin.H{"status": "error", "error": "duplicate BXAuth found", "existing_file": existingFileName})
 		return
@@ -1916,7 +2077,11 @@ func (h *Handler) RequestIFlowCookieToken(c *gin.Context) {
 		},
 	}
 
-	savedPath, errSave := h.saveTokenRecord(ctx, record)
+	savedPath, errSave := h.saveLoginRecord(ctx, reauth, record, allowAccountChange)
+	if errors.Is(errSave, errReauthAccountMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "error": errSave.Error()})
+		return
+	}
 	if errSave != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "failed to save authentication tokens"})
 		return
@@ -0,0 +1,43 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestPatchAuthFileStatusPersistsDisabledWithoutMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{
+		ID:         "gemini-a.json",
+		FileName:   "gemini-a.json",
+		Provider:   "gemini",
+		Attributes: map[string]string{"path": "/auths/gemini-a.json"},
+	}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{authManager: manager}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/auth-files/status", strings.NewReader(`{"name":"gemini-a.json","disabled":true}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.PatchAuthFileStatus(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	store.mu.Lock()
+	saved := store.items["gemini-a.json"]
+	store.mu.Unlock()
+	if saved == nil || saved.Metadata["disabled"] != true {
+		t.Fatalf("disabled flag not persisted: %+v", saved)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
@@ -115,29 +116,37 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": "cannot read request body"})
 		return
 	}
+	if h.applyConfigYAML(c, body, "update", "") {
+		c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
+	}
+}
+
+// applyConfigYAML validates body, replaces the config file with it, reloads the in-memory
+// config and records a history version. It writes an error response and returns false on failure.
+func (h *Handler) applyConfigYAML(c *gin.Context, body []byte, action, rollbackOf string) bool {
 	var cfg config.Config
-	if err = yaml.Unmarshal(body, &cfg); err != nil {
+	if err := yaml.Unmarshal(body, &cfg); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": err.Error()})
-		return
+		return false
 	}
 	// Validate config using LoadConfigOptional with optional=false to enforce parsing
 	tmpDir := filepath.Dir(h.configFilePath)
 	tmpFile, err := os.CreateTemp(tmpDir, "config-validate-*.yaml")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": err.Error()})
-		return
+		return false
 	}
 	tempFile := tmpFile.Name()
 	if _, errWrite := tmpFile.Write(body); errWrite != nil {
 		_ = tmpFile.Close()
 		_ = os.Remove(tempFile)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": errWrite.Error()})
-		return
+		return false
 	}
 	if errClose := tmpFile.Close(); errClose != nil {
 		_ = os.Remove(tempFile)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": errClose.Error()})
-		return
+		return false
 	}
 	defer func() {
 		_ = os.Remove(tempFile)
@@ -145,22 +154,39 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	_, err = config.LoadConfigOptional(tempFile, false)
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
-		return
+		return false
 	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	previous, _ := os.ReadFile(h.configFilePath)
+	// Management keys live in remote-management, so only admins may change that section.
+	if !hasAdminScope(c) && remoteManagementChanged(previous, &cfg) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient scope", "required": fullAccess, "message": "changing remote-management requires the admin scope"})
+		return false
+	}
 	if WriteConfig(h.configFilePath, body) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": "failed to write config"})
-		return
+		return false
 	}
 	// Reload into handler to keep memory in sync
 	newCfg, err := config.LoadConfig(h.configFilePath)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "reload_failed", "message": err.Error()})
-		return
+		return false
 	}
 	h.cfg = newCfg
-	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
+	h.recordConfigVersion(c, previous, action, rollbackOf)
+	return true
+}
+
+// remoteManagementChanged reports whether next differs from the config file contents previous
+// in its remote-management section. Unparseable previous contents count as a change.
+func remoteManagementChanged(previous []byte, next *config.Config) bool {
+	var current config.Config
+	if err := yaml.Unmarshal(previous, &current); err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(current.RemoteManagement, next.RemoteManagement)
 }
 
 // GetConfigYAML returns the raw config.yaml file bytes without re-encoding.
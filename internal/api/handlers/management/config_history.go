@@ -0,0 +1,183 @@
+package management
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// configHistory returns where config versions are kept: the token store when it versions
+// configs itself (git, PostgreSQL, object storage), otherwise a directory next to config.yaml.
+func (h *Handler) configHistory() store.ConfigHistory {
+	h.historyOnce.Do(func() {
+		if history, ok := h.tokenStore.(store.ConfigHistory); ok {
+			h.history = history
+			return
+		}
+		if h.configFilePath != "" {
+			h.history = store.NewFileConfigHistory(filepath.Join(filepath.Dir(h.configFilePath), "config-history"))
+		}
+	})
+	return h.history
+}
+
+// configChanges returns the redacted change list between two config documents.
+func configChanges(previous, current []byte) []string {
+	var oldCfg, newCfg config.Config
+	if err := yaml.Unmarshal(previous, &oldCfg); err != nil {
+		return nil
+	}
+	if err := yaml.Unmarshal(current, &newCfg); err != nil {
+		return nil
+	}
+	return diff.BuildConfigChangeDetails(&oldCfg, &newCfg)
+}
+
+// recordConfigVersion stores the config file as a new history version after a management
+// write. The first recorded change also stores the previous content as a baseline so the
+// original file can be restored. Failures are logged; the write itself has already succeeded.
+// Callers hold h.mu.
+func (h *Handler) recordConfigVersion(c *gin.Context, previous []byte, action, rollbackOf string) {
+	limit := 0
+	if h.cfg != nil {
+		limit = h.cfg.RemoteManagement.ConfigHistoryLimit
+	}
+	if limit < 0 {
+		return
+	}
+	history := h.configHistory()
+	if history == nil {
+		return
+	}
+	current, err := os.ReadFile(h.configFilePath)
+	if err != nil {
+		log.Warnf("config history: read config failed: %v", err)
+		return
+	}
+	if bytes.Equal(previous, current) {
+		return
+	}
+	ctx := c.Request.Context()
+	if versions, errList := history.ListConfigVersions(ctx); errList == nil && len(versions) == 0 && len(bytes.TrimSpace(previous)) > 0 {
+		baseline := &store.ConfigVersion{Action: "baseline"}
+		if errRecord := history.RecordConfigVersion(ctx, baseline, previous, limit); errRecord != nil {
+			log.Warnf("config history: record baseline failed: %v", errRecord)
+		}
+	}
+	version := &store.ConfigVersion{
+		Author:     managementIdentity(c),
+		ClientIP:   c.ClientIP(),
+		Action:     action,
+		RollbackOf: rollbackOf,
+		Changes:    configChanges(previous, current),
+	}
+	if errRecord := history.RecordConfigVersion(ctx, version, current, limit); errRecord != nil {
+		log.Warnf("config history: record version failed: %v", errRecord)
+		return
+	}
+	log.Infof("config version %s recorded (%s by %s)", version.ID, action, version.Author)
+}
+
+// PreviewConfigDiff returns the redacted changes and validation issues that applying the
+// YAML request body would produce, without writing anything.
+func (h *Handler) PreviewConfigDiff(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": "cannot read request body"})
+		return
+	}
+	var probe config.Config
+	if err = yaml.Unmarshal(body, &probe); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": err.Error()})
+		return
+	}
+	current, err := os.ReadFile(h.configFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+		return
+	}
+	report := config.ValidateConfigYAML(body)
+	c.JSON(http.StatusOK, gin.H{
+		"changes": nonNilChanges(configChanges(current, body)),
+		"valid":   !report.HasErrors(),
+		"issues":  report.Issues,
+	})
+}
+
+// ListConfigHistory returns the recorded config versions, newest first.
+func (h *Handler) ListConfigHistory(c *gin.Context) {
+	history := h.configHistory()
+	if history == nil {
+		c.JSON(http.StatusOK, gin.H{"versions": []store.ConfigVersion{}})
+		return
+	}
+	versions, err := history.ListConfigVersions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "history_failed", "message": err.Error()})
+		return
+	}
+	if versions == nil {
+		versions = []store.ConfigVersion{}
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// GetConfigHistoryVersion returns a recorded version together with the redacted changes
+// that rolling back to it would apply to the current config.
+func (h *Handler) GetConfigHistoryVersion(c *gin.Context) {
+	version, content, ok := h.loadConfigVersion(c)
+	if !ok {
+		return
+	}
+	current, _ := os.ReadFile(h.configFilePath)
+	c.JSON(http.StatusOK, gin.H{
+		"version":          version,
+		"rollback_changes": nonNilChanges(configChanges(current, content)),
+	})
+}
+
+// RollbackConfig restores a recorded config version and records the rollback as a new version.
+func (h *Handler) RollbackConfig(c *gin.Context) {
+	version, content, ok := h.loadConfigVersion(c)
+	if !ok {
+		return
+	}
+	if h.applyConfigYAML(c, content, "rollback", version.ID) {
+		c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}, "restored": version.ID})
+	}
+}
+
+func (h *Handler) loadConfigVersion(c *gin.Context) (*store.ConfigVersion, []byte, bool) {
+	history := h.configHistory()
+	if history == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": "config history unavailable"})
+		return nil, nil, false
+	}
+	version, content, err := history.GetConfigVersion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrConfigVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found", "message": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "history_failed", "message": err.Error()})
+		}
+		return nil, nil, false
+	}
+	return version, content, true
+}
+
+func nonNilChanges(changes []string) []string {
+	if changes == nil {
+		return []string{}
+	}
+	return changes
+}
@@ -0,0 +1,155 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
+)
+
+func TestConfigHistoryRecordsAndRollsBack(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	original := "port: 8317\ndebug: false\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	h := &Handler{cfg: cfg, configFilePath: configPath, tokenStore: &memoryAuthStore{}}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(managementIdentityKey, "test-key") })
+	router.PUT("/config.yaml", h.PutConfigYAML)
+	router.POST("/config/diff", h.PreviewConfigDiff)
+	router.GET("/config/history", h.ListConfigHistory)
+	router.POST("/config/history/:id/rollback", h.RollbackConfig)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	updated := "port: 9000\ndebug: true\n"
+	rec := do(http.MethodPost, "/config/diff", updated)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "port: 8317 -\\u003e 9000") {
+		t.Fatalf("diff preview = %d %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("diff preview modified config: %q", data)
+	}
+
+	if rec = do(http.MethodPut, "/config.yaml", updated); rec.Code != http.StatusOK {
+		t.Fatalf("put config = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/config/history", "")
+	var listed struct {
+		Versions []store.ConfigVersion `json:"versions"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if len(listed.Versions) != 2 {
+		t.Fatalf("versions = %d, want baseline and update: %+v", len(listed.Versions), listed.Versions)
+	}
+	latest, baseline := listed.Versions[0], listed.Versions[1]
+	if latest.Action != "update" || latest.Author != "test-key" || len(latest.Changes) == 0 {
+		t.Fatalf("unexpected latest version: %+v", latest)
+	}
+	if baseline.Action != "baseline" {
+		t.Fatalf("unexpected baseline version: %+v", baseline)
+	}
+
+	if rec = do(http.MethodPost, "/config/history/"+baseline.ID+"/rollback", ""); rec.Code != http.StatusOK {
+		t.Fatalf("rollback = %d %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("config after rollback = %q, want %q", data, original)
+	}
+	if h.cfg.Port != 8317 {
+		t.Fatalf("in-memory port = %d after rollback", h.cfg.Port)
+	}
+	if rec = do(http.MethodPost, "/config/history/../../etc/rollback", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("invalid id = %d, want 404", rec.Code)
+	}
+}
+
+func TestConfigWriteCannotChangeRemoteManagement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	original := "port: 8317\nremote-management:\n  secret-key: admin\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	// Loading hashes the plaintext secret-key in place.
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	original = string(data)
+	h := &Handler{cfg: cfg, configFilePath: configPath, tokenStore: &memoryAuthStore{}}
+
+	scopes := []string{config.ManagementScopeConfigWrite}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(managementIdentityKey, "test-key")
+		c.Set(managementScopesKey, scopes)
+	})
+	router.PUT("/config.yaml", h.PutConfigYAML)
+	router.GET("/config/history", h.ListConfigHistory)
+	router.POST("/config/history/:id/rollback", h.RollbackConfig)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	escalated := original + "  keys:\n    - name: me\n      key: mine\n      scopes: [admin]\n"
+	if rec := do(http.MethodPut, "/config.yaml", escalated); rec.Code != http.StatusForbidden {
+		t.Fatalf("config:write remote-management change = %d %s, want 403", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("rejected change was written: %q", data)
+	}
+	if rec := do(http.MethodPut, "/config.yaml", strings.Replace(original, "8317", "9000", 1)); rec.Code != http.StatusOK {
+		t.Fatalf("config:write unrelated change = %d %s", rec.Code, rec.Body.String())
+	}
+
+	scopes = []string{config.ManagementScopeAdmin}
+	if rec := do(http.MethodPut, "/config.yaml", escalated); rec.Code != http.StatusOK {
+		t.Fatalf("admin remote-management change = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := do(http.MethodGet, "/config/history", "")
+	var listed struct {
+		Versions []store.ConfigVersion `json:"versions"`
+	}
+	if err = json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	baseline := listed.Versions[len(listed.Versions)-1]
+	scopes = []string{config.ManagementScopeConfigWrite}
+	if rec = do(http.MethodPost, "/config/history/"+baseline.ID+"/rollback", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("config:write rollback across remote-management = %d %s, want 403", rec.Code, rec.Body.String())
+	}
+}
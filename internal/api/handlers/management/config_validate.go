@@ -0,0 +1,48 @@
+package management
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// ValidateConfig strictly validates a config document and reports unknown keys and semantic
+// problems with line numbers. The request body is the YAML to check; an empty body validates
+// the current config file. Nothing is written.
+func (h *Handler) ValidateConfig(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_yaml", "message": "cannot read request body"})
+		return
+	}
+	source := "body"
+	if len(bytes.TrimSpace(body)) == 0 {
+		source = "file"
+		body, err = os.ReadFile(h.configFilePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "read_failed", "message": err.Error()})
+			return
+		}
+	}
+	report := config.ValidateConfigYAML(body)
+	c.JSON(http.StatusOK, gin.H{
+		"valid":  !report.HasErrors(),
+		"source": source,
+		"issues": report.Issues,
+	})
+}
+
+// GetConfigSchema returns the JSON Schema describing config.yaml.
+func (h *Handler) GetConfigSchema(c *gin.Context) {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "schema_failed", "message": err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/schema+json; charset=utf-8", schema)
+}
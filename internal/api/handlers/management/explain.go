@@ -0,0 +1,213 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type explainRequest struct {
+	// Format is the client-side API format ("openai", "openai-response", "claude", "gemini", "gemini-cli").
+	Format string `json:"format"`
+	// Model overrides the model read from the request body (required for Gemini-style requests).
+	Model string `json:"model"`
+	// Stream overrides the stream flag read from the request body.
+	Stream *bool `json:"stream"`
+	// Amp evaluates ampcode model-mappings as the Amp fallback handler would.
+	Amp bool `json:"amp"`
+	// Request is the client request body.
+	Request json.RawMessage `json:"request"`
+}
+
+type explainThinking struct {
+	BaseModel string         `json:"base_model"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+type explainPrefix struct {
+	ForceModelPrefix bool   `json:"force_model_prefix"`
+	Prefix           string `json:"prefix,omitempty"`
+	Model            string `json:"model,omitempty"`
+}
+
+type explainAmpMapping struct {
+	Mode        string `json:"mode"`
+	Applied     bool   `json:"applied"`
+	MappedModel string `json:"mapped_model,omitempty"`
+}
+
+type explainRoute struct {
+	coreauth.RouteExplanation
+	Target         executor.PayloadTarget      `json:"target"`
+	UpstreamModel  string                      `json:"upstream_model"`
+	PayloadRules   []executor.PayloadRuleMatch `json:"payload_rules"`
+	TranslatedBody json.RawMessage             `json:"translated_body,omitempty"`
+}
+
+type explainResponse struct {
+	Format         string             `json:"format"`
+	RequestedModel string             `json:"requested_model"`
+	Stream         bool               `json:"stream"`
+	AutoResolved   string             `json:"auto_resolved,omitempty"`
+	Thinking       explainThinking    `json:"thinking"`
+	Prefix         explainPrefix      `json:"prefix"`
+	AmpMapping     *explainAmpMapping `json:"amp_mapping,omitempty"`
+	ResolvedModel  string             `json:"resolved_model"`
+	Providers      []string           `json:"providers"`
+	Routes         []explainRoute     `json:"routes"`
+	Error          string             `json:"error,omitempty"`
+}
+
+// ExplainRoute resolves a client request the same way the API handlers and auth manager
+// would and returns the full resolution trace without contacting any upstream.
+func (h *Handler) ExplainRoute(c *gin.Context) {
+	var body explainRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(body.Format))
+	if format == "" {
+		format = "openai"
+	}
+	rawRequest := []byte(body.Request)
+	if len(rawRequest) > 0 && !json.Valid(rawRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request must be a JSON object"})
+		return
+	}
+	modelName := strings.TrimSpace(body.Model)
+	if modelName == "" {
+		modelName = strings.TrimSpace(gjson.GetBytes(rawRequest, "model").String())
+	}
+	if modelName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	stream := gjson.GetBytes(rawRequest, "stream").Bool()
+	if body.Stream != nil {
+		stream = *body.Stream
+	}
+
+	resp := explainResponse{
+		Format:         format,
+		RequestedModel: modelName,
+		Stream:         stream,
+		Providers:      []string{},
+		Routes:         []explainRoute{},
+	}
+	if h.cfg != nil {
+		resp.Prefix.ForceModelPrefix = h.cfg.ForceModelPrefix
+	}
+
+	// Amp fallback handlers apply model-mappings before the regular handler sees the request.
+	if body.Amp && h.cfg != nil {
+		resp.AmpMapping = h.explainAmpMapping(modelName)
+		if resp.AmpMapping.Applied {
+			modelName = resp.AmpMapping.MappedModel
+		}
+	}
+
+	resolvedName := util.ResolveAutoModel(modelName)
+	if resolvedName != modelName {
+		resp.AutoResolved = resolvedName
+	}
+	normalizedModel, metadata := util.NormalizeThinkingModel(resolvedName)
+	resp.Thinking = explainThinking{BaseModel: normalizedModel, Metadata: metadata}
+	if idx := strings.Index(normalizedModel, "/"); idx > 0 {
+		resp.Prefix.Prefix = normalizedModel[:idx]
+		resp.Prefix.Model = normalizedModel[idx+1:]
+	}
+
+	providers := util.GetProviderName(normalizedModel)
+	if len(providers) == 0 && metadata != nil {
+		if original, ok := metadata[util.ThinkingOriginalModelMetadataKey].(string); ok {
+			original = strings.TrimSpace(original)
+			if original != "" && !strings.EqualFold(original, normalizedModel) {
+				if alt := util.GetProviderName(original); len(alt) > 0 {
+					providers = alt
+					normalizedModel = original
+				}
+			}
+		}
+	}
+	resp.ResolvedModel = normalizedModel
+	if len(providers) == 0 {
+		resp.Error = "unknown provider for model " + modelName
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Providers = providers
+
+	from := sdktranslator.FromString(format)
+	for _, provider := range providers {
+		route := explainRoute{
+			Target:        executor.PayloadTargetForProvider(provider),
+			UpstreamModel: normalizedModel,
+		}
+		if h.authManager != nil {
+			route.RouteExplanation = h.authManager.ExplainRoute(provider, normalizedModel)
+		} else {
+			route.RouteExplanation = coreauth.RouteExplanation{Provider: provider}
+		}
+		for _, candidate := range route.Candidates {
+			if candidate.Eligible {
+				route.UpstreamModel = candidate.UpstreamModel
+				break
+			}
+		}
+		route.PayloadRules = executor.MatchingPayloadRules(h.cfg, route.UpstreamModel, route.Target.Protocol)
+		if len(rawRequest) > 0 {
+			route.TranslatedBody = explainTranslateBody(h, from, route.Target, route.UpstreamModel, rawRequest, stream)
+		}
+		resp.Routes = append(resp.Routes, route)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) explainAmpMapping(modelName string) *explainAmpMapping {
+	out := &explainAmpMapping{Mode: "fallback"}
+	if h.cfg.AmpCode.ForceModelMappings {
+		out.Mode = "force"
+	}
+	normalized, _ := util.NormalizeThinkingModel(modelName)
+	if out.Mode == "fallback" && len(util.GetProviderName(normalized)) > 0 {
+		// Local providers win in fallback mode; mappings are not consulted.
+		return out
+	}
+	mapper := amp.NewModelMapper(h.cfg.AmpCode.ModelMappings)
+	mapped := strings.TrimSpace(mapper.MapModel(modelName))
+	if mapped == "" {
+		mapped = strings.TrimSpace(mapper.MapModel(normalized))
+	}
+	if mapped == "" {
+		return out
+	}
+	out.Applied = true
+	out.MappedModel = mapped
+	return out
+}
+
+// explainTranslateBody converts the client body into the executor's target format and
+// applies payload rules. Executor-specific adjustments made at send time (thinking
+// injection, credential-dependent fields) are not reproduced.
+func explainTranslateBody(h *Handler, from sdktranslator.Format, target executor.PayloadTarget, model string, rawRequest []byte, stream bool) json.RawMessage {
+	to := sdktranslator.FromString(target.Format)
+	translated := sdktranslator.TranslateRequest(from, to, model, append([]byte(nil), rawRequest...), stream)
+	if target.Root == "" && gjson.GetBytes(translated, "model").Exists() {
+		translated, _ = sjson.SetBytes(translated, "model", model)
+	}
+	translated = executor.ApplyPayloadConfig(h.cfg, model, target.Protocol, target.Root, translated, translated)
+	if !json.Valid(translated) {
+		return nil
+	}
+	return translated
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -27,6 +28,12 @@ type attemptInfo struct {
 	lastActivity time.Time // track last activity for cleanup
 }
 
+// managementIdentityKey stores the identity of the management credential on the gin context.
+const managementIdentityKey = "managementIdentity"
+
+// managementScopesKey stores the scopes granted to the management credential on the gin context.
+const managementScopesKey = "managementScopes"
+
 // attemptCleanupInterval controls how often stale IP entries are purged
 const attemptCleanupInterval = 1 * time.Hour
 
@@ -47,6 +54,10 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	historyOnce         sync.Once
+	history             store.ConfigHistory
+	auditMu             sync.Mutex
+	keyMatches          sync.Map // cached bcrypt matches of named management keys
 }
 
 // NewHandler creates a new management handler instance.
@@ -131,6 +142,8 @@ func (h *Handler) SetLogDirectory(dir string) {
 // Middleware enforces access control for management endpoints.
 // All requests (local and remote) require a valid management key.
 // Additionally, remote access requires allow-remote-management=true.
+// Named keys from remote-management.keys are limited to their scopes; every call beyond
+// read-only is written to the audit log.
 func (h *Handler) Middleware() gin.HandlerFunc {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
@@ -146,10 +159,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		var (
 			allowRemote bool
 			secretHash  string
+			namedKeys   []config.ManagementKey
 		)
 		if cfg != nil {
 			allowRemote = cfg.RemoteManagement.AllowRemote
 			secretHash = cfg.RemoteManagement.SecretKey
+			namedKeys = cfg.RemoteManagement.Keys
 		}
 		if h.allowRemoteOverride {
 			allowRemote = true
@@ -226,31 +241,31 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
-					c.Next()
+					h.authorize(c, "local-password", fullAccess)
 					return
 				}
 			}
 		}
 
-		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
-			if !localClient {
-				h.attemptsMu.Lock()
-				if ai := h.failedAttempts[clientIP]; ai != nil {
-					ai.count = 0
-					ai.blockedUntil = time.Time{}
+		var (
+			identity string
+			scopes   []string
+		)
+		switch {
+		case envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1:
+			identity, scopes = "env:MANAGEMENT_PASSWORD", fullAccess
+		case secretHash != "" && bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) == nil:
+			identity, scopes = "remote-management.secret-key", fullAccess
+		default:
+			key := h.matchManagementKey(namedKeys, provided)
+			if key == nil {
+				if !localClient {
+					fail()
 				}
-				h.attemptsMu.Unlock()
-			}
-			c.Next()
-			return
-		}
-
-		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
-			return
+			identity, scopes = "key:"+key.Name, key.Scopes
 		}
 
 		if !localClient {
@@ -262,19 +277,29 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
-		c.Next()
+		h.authorize(c, identity, scopes)
+	}
+}
+
+// managementIdentity names the management credential that authenticated the request.
+func managementIdentity(c *gin.Context) string {
+	if c == nil {
+		return ""
 	}
+	return c.GetString(managementIdentityKey)
 }
 
 // persist saves the current in-memory config to disk.
 func (h *Handler) persist(c *gin.Context) bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	previous, _ := os.ReadFile(h.configFilePath)
 	// Preserve comments when writing
 	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
 		return false
 	}
+	h.recordConfigVersion(c, previous, "update", "")
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	return true
 }
@@ -0,0 +1,38 @@
+package management
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// ListModels returns every model currently routable through the proxy together with
+// the providers serving it and the number of available credentials.
+func (h *Handler) ListModels(c *gin.Context) {
+	reg := registry.GetGlobalRegistry()
+	available := reg.GetAvailableModels("openai")
+	models := make([]gin.H, 0, len(available))
+	for _, model := range available {
+		id, _ := model["id"].(string)
+		if id == "" {
+			continue
+		}
+		entry := gin.H{
+			"id":        id,
+			"providers": reg.GetModelProviders(id),
+			"clients":   reg.GetModelCount(id),
+		}
+		if ownedBy, ok := model["owned_by"].(string); ok && ownedBy != "" {
+			entry["owned_by"] = ownedBy
+		}
+		models = append(models, entry)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		idI, _ := models[i]["id"].(string)
+		idJ, _ := models[j]["id"].(string)
+		return idI < idJ
+	})
+	c.JSON(http.StatusOK, gin.H{"models": models})
+}
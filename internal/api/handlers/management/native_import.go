@@ -0,0 +1,121 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	geminiAuth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+)
+
+// ImportNativeCredential handles uploading a credential file written by a provider's own CLI
+// (Claude Code, Codex CLI, Gemini CLI or Qwen Code) and saving it as an auth record. An
+// existing record for the same account is replaced instead of duplicated.
+func (h *Handler) ImportNativeCredential(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	if h.cfg.AuthDir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth directory not configured"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+		return
+	}
+	data, err := readFormFile(fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to read file: %v", err)})
+		return
+	}
+	var profile []byte
+	if profileHeader, errProfile := c.FormFile("profile"); errProfile == nil {
+		if profile, err = readFormFile(profileHeader); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to read profile: %v", err)})
+			return
+		}
+	}
+
+	provider := strings.TrimSpace(c.PostForm("provider"))
+	if provider != "" && sdkAuth.NormalizeNativeProvider(provider) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported provider", "supported": sdkAuth.NativeImportProviders})
+		return
+	}
+	ctx := context.Background()
+	if reqCtx := c.Request.Context(); reqCtx != nil {
+		ctx = reqCtx
+	}
+	record, err := sdkAuth.ImportNativeCredential(provider, data, sdkAuth.NativeImportOptions{
+		Email:     c.PostForm("email"),
+		ProjectID: c.PostForm("project_id"),
+		Profile:   profile,
+		DiscoverGeminiProject: func(storage *geminiAuth.GeminiTokenStorage) error {
+			return h.discoverGeminiProject(ctx, storage)
+		},
+	})
+	if err != nil {
+		var emailErr *sdkAuth.EmailRequiredError
+		if errors.As(err, &emailErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email required", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid credential file", "message": err.Error()})
+		return
+	}
+
+	store := h.tokenStoreWithBaseDir()
+	savedPath, replaced, err := sdkAuth.SaveImportedAuth(ctx, store, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ok",
+		"auth-file": savedPath,
+		"provider":  record.Provider,
+		"email":     record.Metadata["email"],
+		"replaced":  replaced,
+	})
+}
+
+// discoverGeminiProject onboards imported Gemini CLI credentials onto a project the same way
+// the web login does when no project is requested.
+func (h *Handler) discoverGeminiProject(ctx context.Context, storage *geminiAuth.GeminiTokenStorage) error {
+	gemClient, err := geminiAuth.NewGeminiAuth().GetAuthenticatedClient(ctx, storage, h.cfg, &geminiAuth.WebLoginOptions{
+		NoBrowser: true,
+	})
+	if err != nil {
+		return fmt.Errorf("get authenticated client: %w", err)
+	}
+	if err = ensureGeminiProjectAndOnboard(ctx, gemClient, storage, ""); err != nil {
+		return err
+	}
+	isChecked, err := checkCloudAPIIsEnabled(ctx, gemClient, storage.ProjectID)
+	if err != nil {
+		return fmt.Errorf("verify Cloud AI API status: %w", err)
+	}
+	if !isChecked {
+		return fmt.Errorf("cloud AI API not enabled for project %s", storage.ProjectID)
+	}
+	storage.Checked = true
+	return nil
+}
+
+func readFormFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return io.ReadAll(file)
+}
@@ -0,0 +1,34 @@
+package management
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+)
+
+// TestNotifications sends a test event to every configured webhook, or to the webhook named
+// in the optional {"webhook": "..."} body, and reports each delivery.
+func (h *Handler) TestNotifications(c *gin.Context) {
+	var body struct {
+		Webhook string `json:"webhook"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(body.Webhook)
+	results := notify.Default().Test(c.Request.Context(), name)
+	if len(results) == 0 {
+		message := "no notification webhooks configured"
+		if name != "" {
+			message = "notification webhook not found: " + name
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
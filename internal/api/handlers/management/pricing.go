@@ -0,0 +1,156 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// GetPricing returns the configured pricing section together with the built-in default prices.
+func (h *Handler) GetPricing(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"pricing":  h.cfg.Pricing,
+		"currency": usage.CurrentPriceTable().Currency(),
+		"defaults": usage.DefaultModelPrices,
+	})
+}
+
+// PutPricing replaces the whole pricing section.
+func (h *Handler) PutPricing(c *gin.Context) {
+	var body struct {
+		Value *config.PricingConfig `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := validatePricing(*body.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.cfg.Pricing = *body.Value
+	h.persist(c)
+}
+
+// PatchPricingModels adds or replaces model prices, matching entries by model and provider.
+func (h *Handler) PatchPricingModels(c *gin.Context) {
+	var body struct {
+		Value []config.ModelPrice `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Value) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := validatePricing(config.PricingConfig{Models: body.Value}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, price := range body.Value {
+		replaced := false
+		for i, existing := range h.cfg.Pricing.Models {
+			if sameModelPrice(existing, price) {
+				h.cfg.Pricing.Models[i] = price
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			h.cfg.Pricing.Models = append(h.cfg.Pricing.Models, price)
+		}
+	}
+	h.persist(c)
+}
+
+// DeletePricingModels removes model prices by model pattern; a request without a body removes
+// every configured price so the defaults apply again.
+func (h *Handler) DeletePricingModels(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		h.cfg.Pricing.Models = nil
+		h.persist(c)
+		return
+	}
+	var body struct {
+		Value []string `json:"value"`
+	}
+	if err = json.Unmarshal(data, &body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	remove := make(map[string]bool, len(body.Value))
+	for _, model := range body.Value {
+		remove[strings.ToLower(strings.TrimSpace(model))] = true
+	}
+	kept := h.cfg.Pricing.Models[:0]
+	for _, price := range h.cfg.Pricing.Models {
+		if !remove[strings.ToLower(strings.TrimSpace(price.Model))] {
+			kept = append(kept, price)
+		}
+	}
+	h.cfg.Pricing.Models = kept
+	h.persist(c)
+}
+
+// GetUsageSpend reports spend per client key, credential and model, and the state of every
+// budget in its current period.
+func (h *Handler) GetUsageSpend(c *gin.Context) {
+	var snapshot usage.StatisticsSnapshot
+	if h != nil && h.usageStats != nil {
+		snapshot = h.usageStats.Snapshot()
+	}
+	byKey := make(map[string]float64, len(snapshot.APIs))
+	for name, api := range snapshot.APIs {
+		byKey[name] = api.TotalCost
+	}
+	currency := snapshot.Currency
+	if currency == "" {
+		currency = usage.CurrentPriceTable().Currency()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"currency":      currency,
+		"total_cost":    snapshot.TotalCost,
+		"by_api_key":    byKey,
+		"by_credential": snapshot.CostByCredential,
+		"by_model":      snapshot.CostByModel,
+		"by_day":        snapshot.CostByDay,
+		"budgets":       usage.BudgetStatuses(),
+	})
+}
+
+func sameModelPrice(a, b config.ModelPrice) bool {
+	return strings.EqualFold(strings.TrimSpace(a.Model), strings.TrimSpace(b.Model)) &&
+		strings.EqualFold(strings.TrimSpace(a.Provider), strings.TrimSpace(b.Provider))
+}
+
+func validatePricing(pricing config.PricingConfig) error {
+	for i, price := range pricing.Models {
+		if strings.TrimSpace(price.Model) == "" {
+			return fmt.Errorf("models[%d]: model is required", i)
+		}
+		if price.Input < 0 || price.Output < 0 || price.Reasoning < 0 || price.CachedInput < 0 {
+			return fmt.Errorf("models[%d]: prices must not be negative", i)
+		}
+	}
+	for i, budget := range pricing.Budgets {
+		switch strings.ToLower(strings.TrimSpace(budget.Period)) {
+		case "", config.BudgetPeriodDaily, config.BudgetPeriodWeekly, config.BudgetPeriodMonthly, config.BudgetPeriodTotal:
+		default:
+			return fmt.Errorf("budgets[%d]: unknown period %q", i, budget.Period)
+		}
+		if budget.Limit <= 0 {
+			return fmt.Errorf("budgets[%d]: limit must be positive", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestDeletePricingModels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Pricing.Models = []config.ModelPrice{{Model: "gpt-5", Input: 1}, {Model: "claude-*", Input: 2}}
+	h := &Handler{cfg: cfg, configFilePath: configPath, tokenStore: &memoryAuthStore{}}
+
+	router := gin.New()
+	router.DELETE("/pricing/models", h.DeletePricingModels)
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/pricing/models", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(`{"value": ["gpt-5"`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body = %d, want 400", rec.Code)
+	}
+	if len(cfg.Pricing.Models) != 2 {
+		t.Fatalf("malformed body removed prices: %+v", cfg.Pricing.Models)
+	}
+	if rec := do(`{"value": ["GPT-5"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("delete by model = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(cfg.Pricing.Models) != 1 || cfg.Pricing.Models[0].Model != "claude-*" {
+		t.Fatalf("unexpected prices after delete: %+v", cfg.Pricing.Models)
+	}
+	if rec := do(""); rec.Code != http.StatusOK {
+		t.Fatalf("empty body = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(cfg.Pricing.Models) != 0 {
+		t.Fatalf("empty body should clear every price: %+v", cfg.Pricing.Models)
+	}
+}
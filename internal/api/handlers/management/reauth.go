@@ -0,0 +1,182 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// reauthProviders lists the credential providers each login flow can re-authenticate.
+var reauthProviders = map[string][]string{
+	"anthropic":   {"claude"},
+	"gemini":      {"gemini", "gemini-cli"},
+	"codex":       {"codex"},
+	"antigravity": {"antigravity"},
+	"qwen":        {"qwen"},
+	"iflow":       {"iflow"},
+}
+
+// errReauthAccountMismatch rejects a re-authentication that signed in to a different account
+// than the credential it replaces.
+var errReauthAccountMismatch = errors.New("re-authentication signed in to a different account")
+
+// reauthTarget resolves the credential named by the optional "reauth" query parameter of a
+// login request. It returns nil when the login creates a new credential, and writes the error
+// response itself when the target cannot be re-authenticated by the given flow.
+func (h *Handler) reauthTarget(c *gin.Context, flow string) (*coreauth.Auth, bool) {
+	name := strings.TrimSpace(c.Query("reauth"))
+	if name == "" {
+		return nil, true
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return nil, false
+	}
+	target := h.findAuthByName(name)
+	if target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return nil, false
+	}
+	// Virtual Gemini project credentials share the tokens of their parent file.
+	if parentID := authAttribute(target, "gemini_virtual_parent"); parentID != "" {
+		if parent, ok := h.authManager.GetByID(parentID); ok && parent != nil {
+			target = parent
+		}
+	}
+	if isRuntimeOnlyAuth(target) || strings.TrimSpace(authAttribute(target, "path")) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth is not backed by a credential file"})
+		return nil, false
+	}
+	provider := strings.ToLower(strings.TrimSpace(target.Provider))
+	for _, p := range reauthProviders[flow] {
+		if p == provider {
+			return target, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("auth %s belongs to provider %s, not %s", target.ID, target.Provider, flow)})
+	return nil, false
+}
+
+// reauthAllowsAccountChange reports whether a login request passed allow_account_change=true,
+// letting a re-authentication sign in to a different account than the one it replaces.
+func reauthAllowsAccountChange(c *gin.Context) bool {
+	allow, _ := strconv.ParseBool(strings.TrimSpace(c.Query("allow_account_change")))
+	return allow
+}
+
+// saveLoginRecord persists the result of a login. Without a re-authentication target it saves
+// record as a new credential. With one, it replaces only the tokens of the target and keeps
+// its ID, file, prefix, label and other settings, so routing and stickiness survive the login.
+// A login to a different account is rejected unless allowAccountChange is set.
+func (h *Handler) saveLoginRecord(ctx context.Context, target, record *coreauth.Auth, allowAccountChange bool) (string, error) {
+	if target == nil {
+		return h.saveTokenRecord(ctx, record)
+	}
+	if record == nil {
+		return "", fmt.Errorf("token record is nil")
+	}
+	if current, ok := h.authManager.GetByID(target.ID); ok && current != nil {
+		target = current
+	}
+	tokens, err := loginTokenFields(record)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	updated := target.Clone()
+	if updated.Metadata == nil {
+		updated.Metadata = make(map[string]any, len(tokens))
+	}
+	oldEmail, _ := updated.Metadata["email"].(string)
+	if newEmail, _ := tokens["email"].(string); oldEmail != "" && newEmail != "" && !strings.EqualFold(oldEmail, newEmail) {
+		if !allowAccountChange {
+			return "", fmt.Errorf("%w: signed in as %s instead of %s", errReauthAccountMismatch, newEmail, oldEmail)
+		}
+		log.Warnf("re-authentication of %s signed in as %s instead of %s", updated.ID, newEmail, oldEmail)
+	}
+	for key, value := range tokens {
+		updated.Metadata[key] = value
+	}
+	delete(updated.Metadata, "disabled")
+	coreauth.ClearQuarantine(updated)
+	for key, value := range record.Attributes {
+		if updated.Attributes == nil {
+			updated.Attributes = make(map[string]string)
+		}
+		updated.Attributes[key] = value
+	}
+	updated.Storage = nil
+	// The primary of a multi-project Gemini credential stays disabled behind its virtual children.
+	if authAttribute(updated, "gemini_virtual_primary") != "true" {
+		updated.Disabled = false
+		updated.Status = coreauth.StatusActive
+		updated.StatusMessage = ""
+	}
+	updated.Unavailable = false
+	updated.LastError = nil
+	updated.NextRetryAfter = time.Time{}
+	updated.NextRefreshAfter = time.Time{}
+	updated.Quota = coreauth.QuotaState{}
+	updated.ModelStates = nil
+	updated.LastRefreshedAt = now
+	updated.UpdatedAt = now
+
+	savedPath, err := h.saveTokenRecord(ctx, updated)
+	if err != nil {
+		return "", err
+	}
+	if _, err = h.authManager.Update(ctx, updated); err != nil {
+		return "", err
+	}
+	log.Infof("re-authenticated %s", updated.ID)
+	return savedPath, nil
+}
+
+// loginTokenFields returns the fields a login wrote for record, as they would appear in its
+// credential file.
+func loginTokenFields(record *coreauth.Auth) (map[string]any, error) {
+	if record.Storage == nil {
+		fields := make(map[string]any, len(record.Metadata))
+		for key, value := range record.Metadata {
+			fields[key] = value
+		}
+		return fields, nil
+	}
+	data, err := json.Marshal(record.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("encode token storage failed: %w", err)
+	}
+	fields := make(map[string]any)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode token storage failed: %w", err)
+	}
+	// Storages set their type only when writing the file; the target already carries it.
+	if typ, _ := fields["type"].(string); typ == "" {
+		delete(fields, "type")
+	}
+	for key, value := range record.Metadata {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	return fields, nil
+}
+
+// loginSaveMessage returns the message reported for a login whose tokens could not be saved:
+// the account mismatch itself, which the caller can act on, or fallback.
+func loginSaveMessage(err error, fallback string) string {
+	if errors.Is(err, errReauthAccountMismatch) {
+		return err.Error()
+	}
+	return fallback
+}
@@ -0,0 +1,175 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestSaveLoginRecordReplacesTokensInPlace(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	target := &coreauth.Auth{
+		ID:         "claude-old.json",
+		FileName:   "claude-old.json",
+		Provider:   "claude",
+		Prefix:     "team-a",
+		Label:      "primary",
+		Disabled:   true,
+		Status:     coreauth.StatusQuarantined,
+		Attributes: map[string]string{"path": "/auths/claude-old.json", "priority": "5"},
+		Metadata: map[string]any{
+			"type":                       "claude",
+			"email":                      "a@example.com",
+			"access_token":               "old-access",
+			"refresh_token":              "old-refresh",
+			"prefix":                     "team-a",
+			coreauth.QuarantinedKey:      true,
+			coreauth.QuarantineReasonKey: "invalid_grant",
+			coreauth.FailureStreakKey:    3,
+		},
+	}
+	if _, err := manager.Register(ctx, target); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+
+	h := &Handler{authManager: manager, tokenStore: store}
+	record := &coreauth.Auth{
+		ID:       "claude-a@example.com.json",
+		Provider: "claude",
+		Metadata: map[string]any{"email": "a@example.com", "access_token": "new-access", "refresh_token": "new-refresh"},
+	}
+	if _, err := h.saveLoginRecord(ctx, target, record, false); err != nil {
+		t.Fatalf("saveLoginRecord: %v", err)
+	}
+
+	got, ok := manager.GetByID("claude-old.json")
+	if !ok {
+		t.Fatalf("re-authenticated auth missing")
+	}
+	if _, exists := manager.GetByID(record.ID); exists {
+		t.Fatalf("re-authentication registered a new auth")
+	}
+	if got.Disabled || got.Status != coreauth.StatusActive {
+		t.Fatalf("auth still disabled: disabled=%t status=%s", got.Disabled, got.Status)
+	}
+	if got.Prefix != "team-a" || got.Label != "primary" || got.Attributes["priority"] != "5" || got.Metadata["prefix"] != "team-a" {
+		t.Fatalf("settings not preserved: %+v", got)
+	}
+	if got.Metadata["access_token"] != "new-access" || got.Metadata["refresh_token"] != "new-refresh" {
+		t.Fatalf("tokens not replaced: %+v", got.Metadata)
+	}
+	if quarantined, _, _ := coreauth.QuarantineInfo(got); quarantined || coreauth.FailureStreak(got) != 0 {
+		t.Fatalf("quarantine not cleared")
+	}
+}
+
+func TestReauthTargetRejectsProviderMismatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{
+		ID:         "codex-a.json",
+		Provider:   "codex",
+		Attributes: map[string]string{"path": "/auths/codex-a.json"},
+	}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{authManager: manager}
+
+	for _, tc := range []struct {
+		flow, name string
+		ok         bool
+		status     int
+	}{
+		{flow: "anthropic", name: "", ok: true},
+		{flow: "codex", name: "codex-a.json", ok: true},
+		{flow: "anthropic", name: "codex-a.json", status: http.StatusBadRequest},
+		{flow: "codex", name: "missing.json", status: http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/?reauth="+tc.name, nil)
+		target, ok := h.reauthTarget(c, tc.flow)
+		if ok != tc.ok {
+			t.Fatalf("%s %q: ok = %t, want %t", tc.flow, tc.name, ok, tc.ok)
+		}
+		if ok && (target != nil) != (tc.name != "") {
+			t.Fatalf("%s %q: unexpected target %v", tc.flow, tc.name, target)
+		}
+		if !ok && rec.Code != tc.status {
+			t.Fatalf("%s %q: status = %d, want %d", tc.flow, tc.name, rec.Code, tc.status)
+		}
+	}
+}
+
+func registerReauthTarget(t *testing.T, manager *coreauth.Manager) *coreauth.Auth {
+	t.Helper()
+	target := &coreauth.Auth{
+		ID:         "claude-a.json",
+		FileName:   "claude-a.json",
+		Provider:   "claude",
+		Attributes: map[string]string{"path": "/auths/claude-a.json"},
+		Metadata:   map[string]any{"type": "claude", "email": "a@example.com", "access_token": "old-access"},
+	}
+	if _, err := manager.Register(context.Background(), target); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	return target
+}
+
+func TestSaveLoginRecordRejectsAccountChange(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryAuthStore{}
+	manager := coreauth.NewManager(store, nil, nil)
+	target := registerReauthTarget(t, manager)
+	h := &Handler{authManager: manager, tokenStore: store}
+	record := &coreauth.Auth{
+		ID:       "claude-b@example.com.json",
+		Provider: "claude",
+		Metadata: map[string]any{"email": "b@example.com", "access_token": "new-access"},
+	}
+
+	if _, err := h.saveLoginRecord(ctx, target, record, false); !errors.Is(err, errReauthAccountMismatch) {
+		t.Fatalf("saveLoginRecord error = %v, want account mismatch", err)
+	}
+	if got, _ := manager.GetByID(target.ID); got.Metadata["access_token"] != "old-access" || got.Metadata["email"] != "a@example.com" {
+		t.Fatalf("rejected login changed the auth: %+v", got.Metadata)
+	}
+
+	if _, err := h.saveLoginRecord(ctx, target, record, true); err != nil {
+		t.Fatalf("saveLoginRecord with override: %v", err)
+	}
+	if got, _ := manager.GetByID(target.ID); got.Metadata["access_token"] != "new-access" || got.Metadata["email"] != "b@example.com" {
+		t.Fatalf("override did not replace the account: %+v", got.Metadata)
+	}
+}
+
+func TestLoginTokenFieldsFromStorage(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	record := &coreauth.Auth{
+		Storage:  &claude.ClaudeTokenStorage{AccessToken: "new-access", RefreshToken: "new-refresh", Email: "a@example.com"},
+		Metadata: map[string]any{"email": "ignored@example.com", "label": "extra"},
+	}
+	fields, err := loginTokenFields(record)
+	if err != nil {
+		t.Fatalf("loginTokenFields: %v", err)
+	}
+	if fields["access_token"] != "new-access" || fields["refresh_token"] != "new-refresh" || fields["email"] != "a@example.com" || fields["label"] != "extra" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+	if _, ok := fields["type"]; ok {
+		t.Fatalf("empty storage type would overwrite the target's type: %+v", fields)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Fatalf("tokens written to disk: %v", entries)
+	}
+}
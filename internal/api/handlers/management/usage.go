@@ -1,12 +1,16 @@
 package management
 
 import (
+	"encoding/json"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 type usageExportPayload struct {
@@ -32,6 +36,70 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	})
 }
 
+// GetStickyRoutingStats reports request and token totals (including cached prompt tokens)
+// grouped by provider, sticky routing strategy and outcome (hit, miss, skip).
+func (h *Handler) GetStickyRoutingStats(c *gin.Context) {
+	var routing config.StickyRoutingConfig
+	if h != nil && h.cfg != nil {
+		routing = h.cfg.StickyRouting
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sticky-routing": routing,
+		"stats":          coreauth.StickyRoutingStats(),
+	})
+}
+
+// GetConcurrencyStats reports in-flight requests per credential together with the
+// concurrency wait queue depth and wait times per provider.
+func (h *Handler) GetConcurrencyStats(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	stats := h.authManager.ConcurrencyStats()
+	c.JSON(http.StatusOK, gin.H{
+		"concurrency": h.cfg.Concurrency,
+		"providers":   stats.Providers,
+		"auths":       stats.Auths,
+	})
+}
+
+// GetRoutingSchedules lists every credential with an availability schedule, whether it is
+// inside its window now and when that next changes.
+func (h *Handler) GetRoutingSchedules(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	now := time.Now()
+	entries := make([]gin.H, 0)
+	for _, auth := range h.authManager.List() {
+		state := coreauth.AuthScheduleState(auth, now)
+		if state == nil {
+			continue
+		}
+		auth.EnsureIndex()
+		name := strings.TrimSpace(auth.FileName)
+		if name == "" {
+			name = strings.TrimSpace(auth.Attributes["source"])
+		}
+		entries = append(entries, gin.H{
+			"id":          auth.ID,
+			"auth_index":  auth.Index,
+			"name":        name,
+			"provider":    strings.TrimSpace(auth.Provider),
+			"label":       auth.Label,
+			"disabled":    auth.Disabled,
+			"schedule":    state.Schedule,
+			"open":        state.Open,
+			"next_change": state.NextChange,
+			"error":       state.Error,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["id"].(string) < entries[j]["id"].(string) })
+	c.JSON(http.StatusOK, gin.H{"now": now, "schedules": entries})
+}
+
 // GetCodexUsage requires explicit auth_id to fetch Codex plan and rate limits.
 // Query parameters:
 // - auth_id: required specific auth ID (auth file name, with or without .json)
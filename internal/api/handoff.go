@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// listenFDEnv names the inherited listening socket descriptor during a hand-off.
+	listenFDEnv = "CLIPROXY_LISTEN_FD"
+	// readyFDEnv names the pipe the new process writes to once it is accepting connections.
+	readyFDEnv = "CLIPROXY_READY_FD"
+
+	// systemdListenFDStart is SD_LISTEN_FDS_START, the first descriptor passed by socket activation.
+	systemdListenFDStart = 3
+)
+
+// inheritedListener returns a listening socket passed in by systemd socket activation or by a
+// parent process handing over its port. It returns nil when the process was started normally.
+func inheritedListener() (net.Listener, error) {
+	fd := 0
+	if raw := os.Getenv(listenFDEnv); raw != "" {
+		_ = os.Unsetenv(listenFDEnv)
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s value %q", listenFDEnv, raw)
+		}
+		fd = parsed
+	} else if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		if count, _ := strconv.Atoi(os.Getenv("LISTEN_FDS")); count >= 1 {
+			fd = systemdListenFDStart
+		}
+		// Child processes must not mistake the activation variables for their own.
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}
+	if fd == 0 {
+		return nil, nil
+	}
+	file := os.NewFile(uintptr(fd), "inherited-listener")
+	if file == nil {
+		return nil, fmt.Errorf("inherited listener descriptor %d is invalid", fd)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use inherited listener: %w", err)
+	}
+	return ln, nil
+}
+
+// notifyHandoffReady tells a parent performing a hand-off that this process is accepting connections.
+func notifyHandoffReady() {
+	raw := os.Getenv(readyFDEnv)
+	if raw == "" {
+		return
+	}
+	_ = os.Unsetenv(readyFDEnv)
+	fd, err := strconv.Atoi(raw)
+	if err != nil || fd <= 0 {
+		return
+	}
+	file := os.NewFile(uintptr(fd), "handoff-ready")
+	if file == nil {
+		return
+	}
+	_, _ = file.Write([]byte{1})
+	_ = file.Close()
+}
+
+// Handoff starts a new instance of the running binary that inherits the listening socket and
+// waits until it reports that it is serving. Connections arriving meanwhile queue on the shared
+// socket, so none are refused. On success the caller should drain and stop this server.
+func (s *Server) Handoff(ctx context.Context, timeout time.Duration) error {
+	s.listenerMu.Lock()
+	ln := s.listener
+	s.listenerMu.Unlock()
+	if ln == nil {
+		return errors.New("handoff: server is not listening")
+	}
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.New("handoff: listener does not expose a file descriptor")
+	}
+	lnFile, err := filer.File()
+	if err != nil {
+		return fmt.Errorf("handoff: %w", err)
+	}
+	defer func() {
+		_ = lnFile.Close()
+	}()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("handoff: %w", err)
+	}
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("handoff: %w", err)
+	}
+	defer func() {
+		_ = readyRead.Close()
+	}()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles start at descriptor 3.
+	cmd.ExtraFiles = []*os.File{lnFile, readyWrite}
+	cmd.Env = append(os.Environ(), listenFDEnv+"=3", readyFDEnv+"=4")
+	errStart := cmd.Start()
+	_ = readyWrite.Close()
+	if errStart != nil {
+		return fmt.Errorf("handoff: failed to start new process: %w", errStart)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		// EOF means the new process exited before it started serving.
+		_, errRead := readyRead.Read(buf)
+		ready <- errRead
+	}()
+
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case errReady := <-ready:
+		if errReady != nil {
+			return fmt.Errorf("handoff: new process exited before serving: %w", errReady)
+		}
+		log.Infof("handoff: process %d is now serving; draining this process", cmd.Process.Pid)
+		return nil
+	case <-timer.C:
+		_ = cmd.Process.Kill()
+		return errors.New("handoff: timed out waiting for the new process")
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		return ctx.Err()
+	}
+}
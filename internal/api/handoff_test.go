@@ -0,0 +1,349 @@
+//go:build !windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	gin "github.com/gin-gonic/gin"
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// handoffHelperEnv switches the test binary into a stand-in for the process started by Handoff.
+const handoffHelperEnv = "CLIPROXY_HANDOFF_HELPER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(handoffHelperEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "serve":
+		os.Exit(runHandoffHelper())
+	default:
+		// Exit before reporting readiness, like a process that fails to start.
+		os.Exit(1)
+	}
+}
+
+// runHandoffHelper serves a single request on the inherited listener and exits.
+func runHandoffHelper() int {
+	ln, err := inheritedListener()
+	if err != nil || ln == nil {
+		return 2
+	}
+	served := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "successor")
+		close(served)
+	})}
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	notifyHandoffReady()
+	select {
+	case <-served:
+	case <-time.After(10 * time.Second):
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+	return 0
+}
+
+// dupFD returns a duplicate descriptor of f that the code under test may take ownership of.
+func dupFD(t *testing.T, f *os.File) int {
+	t.Helper()
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("dup: %v", err)
+	}
+	return fd
+}
+
+func TestDrainTimeout(t *testing.T) {
+	if got := DrainTimeout(nil); got != 30*time.Second {
+		t.Fatalf("DrainTimeout(nil) = %s, want 30s", got)
+	}
+	cfg := &proxyconfig.Config{}
+	if got := DrainTimeout(cfg); got != 30*time.Second {
+		t.Fatalf("DrainTimeout(unset) = %s, want 30s", got)
+	}
+	cfg.Shutdown.DrainTimeoutSeconds = 5
+	if got := DrainTimeout(cfg); got != 5*time.Second {
+		t.Fatalf("DrainTimeout(5) = %s, want 5s", got)
+	}
+}
+
+// startTestServer serves s in the background and returns its address and the Start result.
+func startTestServer(t *testing.T, s *Server) (string, <-chan error) {
+	t.Helper()
+	s.server.Addr = "127.0.0.1:0"
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Start()
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.listenerMu.Lock()
+		ln := s.listener
+		s.listenerMu.Unlock()
+		if ln != nil {
+			return ln.Addr().String(), done
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("Start() returned early: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("server did not start listening")
+	return "", nil
+}
+
+// addSlowRoute registers a handler that reports when it starts and finishes once release is closed.
+func addSlowRoute(s *Server, started chan<- struct{}, release <-chan struct{}) {
+	s.engine.GET("/slow", func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+}
+
+func TestStopDrainsInFlightRequests(t *testing.T) {
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	s := newTestServer(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	addSlowRoute(s, started, release)
+	addr, served := startTestServer(t, s)
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopped <- s.Stop(ctx)
+	}()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop() returned before the in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		t.Fatal("server still accepts connections while draining")
+	}
+
+	close(release)
+	if got := <-response; got.err != nil || got.body != "done" {
+		t.Fatalf("in-flight response = %q, %v; want done", got.body, got.err)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+}
+
+func TestStopClosesRequestsAfterDrainDeadline(t *testing.T) {
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	s := newTestServer(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	addSlowRoute(s, started, release)
+	addr, _ := startTestServer(t, s)
+
+	requestErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+		requestErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Fatalf("Stop() took %s, want it bounded by the drain deadline", elapsed)
+	}
+	select {
+	case err := <-requestErr:
+		if err == nil {
+			t.Fatal("request cut off by the deadline completed successfully")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("connection still open after the drain deadline")
+	}
+}
+
+func TestInheritedListener(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		t.Setenv(listenFDEnv, "")
+		t.Setenv("LISTEN_PID", "")
+		ln, err := inheritedListener()
+		if err != nil || ln != nil {
+			t.Fatalf("inheritedListener() = %v, %v; want nil, nil", ln, err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv(listenFDEnv, "abc")
+		if _, err := inheritedListener(); err == nil {
+			t.Fatal("expected an error for a malformed descriptor")
+		}
+	})
+
+	t.Run("handoff", func(t *testing.T) {
+		orig, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		defer func() { _ = orig.Close() }()
+		file, err := orig.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatalf("listener file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+
+		t.Setenv(listenFDEnv, strconv.Itoa(dupFD(t, file)))
+		ln, err := inheritedListener()
+		if err != nil || ln == nil {
+			t.Fatalf("inheritedListener() = %v, %v", ln, err)
+		}
+		defer func() { _ = ln.Close() }()
+		if ln.Addr().String() != orig.Addr().String() {
+			t.Fatalf("inherited address = %s, want %s", ln.Addr(), orig.Addr())
+		}
+		if _, ok := os.LookupEnv(listenFDEnv); ok {
+			t.Fatalf("%s must be cleared so children do not inherit it", listenFDEnv)
+		}
+
+		accepted := make(chan error, 1)
+		go func() {
+			conn, errAccept := ln.Accept()
+			if errAccept == nil {
+				_ = conn.Close()
+			}
+			accepted <- errAccept
+		}()
+		conn, err := net.DialTimeout("tcp", orig.Addr().String(), time.Second)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_ = conn.Close()
+		if err = <-accepted; err != nil {
+			t.Fatalf("accept on inherited listener: %v", err)
+		}
+	})
+
+	t.Run("systemd", func(t *testing.T) {
+		t.Setenv(listenFDEnv, "")
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+		t.Setenv("LISTEN_FDS", "1")
+		ln, err := inheritedListener()
+		if err != nil || ln != nil {
+			t.Fatalf("activation variables for another process were used: %v, %v", ln, err)
+		}
+	})
+}
+
+func TestNotifyHandoffReady(t *testing.T) {
+	readEnd, writeEnd, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer func() { _ = readEnd.Close() }()
+	t.Setenv(readyFDEnv, strconv.Itoa(dupFD(t, writeEnd)))
+	_ = writeEnd.Close()
+
+	notifyHandoffReady()
+	buf := make([]byte, 2)
+	n, err := readEnd.Read(buf)
+	if err != nil || n != 1 {
+		t.Fatalf("ready signal = %d bytes, %v; want 1 byte", n, err)
+	}
+	// The descriptor is closed after the signal, so the parent sees EOF next.
+	if _, err = readEnd.Read(buf); !errors.Is(err, io.EOF) {
+		t.Fatalf("read after signal = %v, want EOF", err)
+	}
+}
+
+// listeningServer returns a server whose listener is open but not served, as Handoff expects.
+func listeningServer(t *testing.T) (*Server, net.Listener) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	s := &Server{listener: ln}
+	return s, ln
+}
+
+func TestHandoffPassesListenerToSuccessor(t *testing.T) {
+	t.Setenv(handoffHelperEnv, "serve")
+	s, ln := listeningServer(t)
+
+	if err := s.Handoff(context.Background(), 10*time.Second); err != nil {
+		t.Fatalf("Handoff() error = %v", err)
+	}
+	// This process never accepts on the socket, so the successor answers.
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%s/", ln.Addr()))
+	if err != nil {
+		t.Fatalf("request after handoff: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "successor" {
+		t.Fatalf("response = %q, want successor", body)
+	}
+}
+
+func TestHandoffFailsWhenSuccessorExits(t *testing.T) {
+	t.Setenv(handoffHelperEnv, "fail")
+	s, _ := listeningServer(t)
+
+	if err := s.Handoff(context.Background(), 10*time.Second); err == nil {
+		t.Fatal("expected Handoff to fail when the new process exits before serving")
+	}
+}
+
+func TestHandoffRequiresListener(t *testing.T) {
+	if err := (&Server{}).Handoff(context.Background(), time.Second); err == nil {
+		t.Fatal("expected Handoff to fail without a listener")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 // Context encapsulates the dependencies exposed to routing modules during
@@ -19,6 +20,7 @@ type Context struct {
 	BaseHandler    *handlers.BaseAPIHandler
 	Config         *config.Config
 	AuthMiddleware gin.HandlerFunc
+	AuthManager    *coreauth.Manager
 }
 
 // RouteModule represents a pluggable routing module that can register routes
@@ -62,6 +64,13 @@ type RouteModuleV2 interface {
 	OnConfigUpdated(cfg *config.Config) error
 }
 
+// ManagementRouteModule is implemented by modules that expose management
+// endpoints. The server mounts them under /v0/management/modules/<name> behind
+// the management authentication middleware once management is enabled.
+type ManagementRouteModule interface {
+	RegisterManagementRoutes(group *gin.RouterGroup)
+}
+
 // RegisterModule is a helper that registers a module using either the V1 or V2
 // interface. This allows gradual migration from V1 to V2 without breaking
 // existing modules.
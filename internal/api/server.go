@@ -9,6 +9,7 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -35,6 +36,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -50,6 +52,7 @@ type serverOptionConfig struct {
 	keepAliveEnabled     bool
 	keepAliveTimeout     time.Duration
 	keepAliveOnTimeout   func()
+	routeModules         []modules.RouteModuleV2
 }
 
 // ServerOption customises HTTP server construction.
@@ -110,6 +113,13 @@ func WithRequestLoggerFactory(factory func(*config.Config, string) logging.Reque
 	}
 }
 
+// WithRouteModules registers additional route modules after the built-in ones.
+func WithRouteModules(mods ...modules.RouteModuleV2) ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.routeModules = append(cfg.routeModules, mods...)
+	}
+}
+
 // Server represents the main API server.
 // It encapsulates the Gin engine, HTTP server, handlers, and configuration.
 type Server struct {
@@ -119,6 +129,10 @@ type Server struct {
 	// server is the underlying HTTP server.
 	server *http.Server
 
+	// listener is the socket the server accepts on; it may be inherited from a previous process.
+	listenerMu sync.Mutex
+	listener   net.Listener
+
 	// handlers contains the API handlers for processing requests.
 	handlers *handlers.BaseAPIHandler
 
@@ -154,6 +168,9 @@ type Server struct {
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// routeModules are the externally supplied modules registered with the engine.
+	routeModules []modules.RouteModuleV2
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -181,7 +198,8 @@ type Server struct {
 //
 // Returns:
 //   - *Server: A new server instance
-func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdkaccess.Manager, configFilePath string, opts ...ServerOption) *Server {
+//   - error: An error if a route module supplied through WithRouteModules fails to register
+func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdkaccess.Manager, configFilePath string, opts ...ServerOption) (*Server, error) {
 	optionState := &serverOptionConfig{
 		requestLoggerFactory: defaultRequestLoggerFactory,
 	}
@@ -280,10 +298,21 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		BaseHandler:    s.handlers,
 		Config:         cfg,
 		AuthMiddleware: AuthMiddleware(accessManager),
+		AuthManager:    authManager,
 	}
 	if err := modules.RegisterModule(ctx, s.ampModule); err != nil {
 		log.Errorf("Failed to register Amp module: %v", err)
 	}
+	for _, mod := range optionState.routeModules {
+		if mod == nil {
+			continue
+		}
+		if err := modules.RegisterModule(ctx, mod); err != nil {
+			usage.StopStatisticsAutosave()
+			return nil, fmt.Errorf("failed to register %s module: %w", mod.Name(), err)
+		}
+		s.routeModules = append(s.routeModules, mod)
+	}
 
 	// Apply additional router configurators from options
 	if optionState.routerConfigurator != nil {
@@ -307,7 +336,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		Handler: engine,
 	}
 
-	return s
+	return s, nil
 }
 
 // setupRoutes configures the API routes for the server.
@@ -319,6 +348,8 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiImagesHandlers := openai.NewOpenAIImagesAPIHandler(s.handlers)
+	openaiAudioHandlers := openai.NewOpenAIAudioAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
@@ -330,6 +361,10 @@ func (s *Server) setupRoutes() {
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.POST("/images/generations", openaiImagesHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiImagesHandlers.ImageEdits)
+		v1.POST("/audio/transcriptions", openaiAudioHandlers.Transcriptions)
+		v1.POST("/audio/translations", openaiAudioHandlers.Translations)
 	}
 
 	// Gemini compatible API routes
@@ -483,10 +518,19 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
+		mgmt.GET("/usage/spend", s.mgmt.GetUsageSpend)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+		mgmt.POST("/config/validate", s.mgmt.ValidateConfig)
+		mgmt.GET("/config/schema", s.mgmt.GetConfigSchema)
+		mgmt.POST("/config/diff", s.mgmt.PreviewConfigDiff)
+		mgmt.GET("/config/history", s.mgmt.ListConfigHistory)
+		mgmt.GET("/config/history/:id", s.mgmt.GetConfigHistoryVersion)
+		mgmt.POST("/config/history/:id/rollback", s.mgmt.RollbackConfig)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
+		mgmt.GET("/audit", s.mgmt.GetAuditLog)
+		mgmt.POST("/notifications/test", s.mgmt.TestNotifications)
 
 		mgmt.GET("/debug", s.mgmt.GetDebug)
 		mgmt.PUT("/debug", s.mgmt.PutDebug)
@@ -500,6 +544,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
 		mgmt.PATCH("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
 
+		mgmt.GET("/pricing", s.mgmt.GetPricing)
+		mgmt.PUT("/pricing", s.mgmt.PutPricing)
+		mgmt.PATCH("/pricing/models", s.mgmt.PatchPricingModels)
+		mgmt.DELETE("/pricing/models", s.mgmt.DeletePricingModels)
+
 		mgmt.GET("/usage-statistics-enabled", s.mgmt.GetUsageStatisticsEnabled)
 		mgmt.PUT("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
 		mgmt.PATCH("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
@@ -510,6 +559,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
 
 		mgmt.POST("/api-call", s.mgmt.APICall)
+		mgmt.POST("/explain", s.mgmt.ExplainRoute)
 
 		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
 		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
@@ -579,6 +629,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
 		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
 		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
+		mgmt.GET("/routing/sticky-stats", s.mgmt.GetStickyRoutingStats)
+		mgmt.GET("/routing/concurrency", s.mgmt.GetConcurrencyStats)
+		mgmt.GET("/routing/schedules", s.mgmt.GetRoutingSchedules)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
@@ -617,7 +670,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
+		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
+		mgmt.POST("/auth-files/test", s.mgmt.TestAuthFile)
+		mgmt.GET("/models", s.mgmt.ListModels)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
+		mgmt.POST("/native/import", s.mgmt.ImportNativeCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
@@ -628,6 +685,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.POST("/iflow-auth-url", s.mgmt.RequestIFlowCookieToken)
 		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
 		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+
+		for _, mod := range s.routeModules {
+			if routes, ok := mod.(modules.ManagementRouteModule); ok {
+				routes.RegisterManagementRoutes(mgmt.Group("/modules/" + mod.Name()))
+			}
+		}
 	}
 }
 
@@ -776,32 +839,56 @@ func (s *Server) Start() error {
 	}
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
+	cert, key := "", ""
 	if useTLS {
-		cert := strings.TrimSpace(s.cfg.TLS.Cert)
-		key := strings.TrimSpace(s.cfg.TLS.Key)
+		cert = strings.TrimSpace(s.cfg.TLS.Cert)
+		key = strings.TrimSpace(s.cfg.TLS.Key)
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
+	}
+
+	// Reuse a socket handed over by systemd or a previous process so no connection is refused.
+	ln, errInherit := inheritedListener()
+	if errInherit != nil {
+		log.Warnf("ignoring inherited listener: %v", errInherit)
+	}
+	if ln != nil {
+		log.Infof("Using inherited listener on %s", ln.Addr())
+	} else {
+		var errListen error
+		if ln, errListen = net.Listen("tcp", s.server.Addr); errListen != nil {
+			return fmt.Errorf("failed to start HTTP server: %v", errListen)
+		}
+	}
+	s.listenerMu.Lock()
+	s.listener = ln
+	s.listenerMu.Unlock()
+	notifyHandoffReady()
+
+	if useTLS {
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
-		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
+		if errServeTLS := s.server.ServeTLS(ln, cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
 		}
 		return nil
 	}
 
 	log.Debugf("Starting API server on %s", s.server.Addr)
-	if errServe := s.server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+	if errServe := s.server.Serve(ln); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start HTTP server: %v", errServe)
 	}
 
 	return nil
 }
 
-// Stop gracefully shuts down the API server without interrupting any
-// active connections.
+// Stop gracefully shuts down the API server. It stops accepting new connections and lets
+// in-flight requests, including long-running streams, finish until ctx expires; connections
+// still open at that point are closed. Streaming request logs and usage statistics are then
+// flushed to disk.
 //
 // Parameters:
-//   - ctx: The context for graceful shutdown
+//   - ctx: The context bounding the drain
 //
 // Returns:
 //   - error: An error if the server fails to stop
@@ -815,11 +902,27 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
-	// Shutdown the HTTP server.
+	// Shutdown the HTTP server, draining in-flight requests until the deadline.
+	var stopErr error
 	if err := s.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
+		log.Warnf("drain deadline reached with requests still in flight; closing them: %v", err)
+		if errClose := s.server.Close(); errClose != nil {
+			stopErr = fmt.Errorf("failed to shutdown HTTP server: %v", errClose)
+		}
+	}
+
+	// Finalize streaming logs of requests cut off by the deadline.
+	if closed := logging.CloseStreamingLogWriters(); closed > 0 {
+		log.Debugf("flushed %d streaming request logs", closed)
 	}
 
+	// Deliver queued usage records before the final snapshot is written.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	if errFlush := coreusage.FlushDefault(flushCtx); errFlush != nil {
+		log.Warnf("usage records not fully flushed: %v", errFlush)
+	}
+	cancelFlush()
+
 	// Stop autosave and write a final snapshot
 	usage.StopStatisticsAutosave()
 	shutdownLogDir := filepath.Join(s.currentPath, "logs")
@@ -829,7 +932,15 @@ func (s *Server) Stop(ctx context.Context) error {
 	_ = usage.GetRequestStatistics().Save(filepath.Join(shutdownLogDir, "usage.json"))
 
 	log.Debug("API server stopped")
-	return nil
+	return stopErr
+}
+
+// DrainTimeout returns how long Stop should let in-flight requests finish.
+func DrainTimeout(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.Shutdown.DrainTimeoutSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(cfg.Shutdown.DrainTimeoutSeconds) * time.Second
 }
 
 // corsMiddleware returns a Gin middleware handler that adds CORS headers
@@ -1002,6 +1113,11 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	} else {
 		log.Warnf("amp module is nil, skipping config update")
 	}
+	for _, mod := range s.routeModules {
+		if err := mod.OnConfigUpdated(cfg); err != nil {
+			log.Errorf("failed to update %s module config: %v", mod.Name(), err)
+		}
+	}
 
 	// Count client sources from configuration and auth store.
 	tokenStore := sdkAuth.GetTokenStore()
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"testing"
 
 	gin "github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -41,7 +43,11 @@ func newTestServer(t *testing.T) *Server {
 	accessManager := sdkaccess.NewManager()
 
 	configPath := filepath.Join(tmpDir, "config.yaml")
-	return NewServer(cfg, authManager, accessManager, configPath)
+	server, err := NewServer(cfg, authManager, accessManager, configPath)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	return server
 }
 
 func TestAmpProviderModelRoutes(t *testing.T) {
@@ -109,3 +115,87 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+type testRouteModule struct {
+	registered bool
+	updates    int
+}
+
+func (m *testRouteModule) Name() string { return "probe" }
+
+func (m *testRouteModule) Register(ctx modules.Context) error {
+	m.registered = true
+	ctx.Engine.GET("/probe", ctx.AuthMiddleware, func(c *gin.Context) { c.String(http.StatusOK, "probe") })
+	return nil
+}
+
+func (m *testRouteModule) OnConfigUpdated(*proxyconfig.Config) error {
+	m.updates++
+	return nil
+}
+
+func (m *testRouteModule) RegisterManagementRoutes(group *gin.RouterGroup) {
+	group.GET("/state", func(c *gin.Context) { c.String(http.StatusOK, "state") })
+}
+
+func TestRouteModuleManagementRoutesMounted(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "secret")
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	cfg := &proxyconfig.Config{
+		SDKConfig: sdkconfig.SDKConfig{APIKeys: []string{"test-key"}},
+		AuthDir:   tmpDir,
+	}
+	mod := &testRouteModule{}
+	server, err := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(tmpDir, "config.yaml"), WithRouteModules(mod))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if !mod.registered {
+		t.Fatal("module was not registered")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("module route status = %d, want 200", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v0/management/modules/probe/state", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "state" {
+		t.Fatalf("module management route = %d %q, want 200 \"state\"", rr.Code, rr.Body.String())
+	}
+
+	server.UpdateClients(cfg)
+	if mod.updates != 1 {
+		t.Fatalf("OnConfigUpdated called %d times, want 1", mod.updates)
+	}
+}
+
+type failingRouteModule struct{}
+
+func (failingRouteModule) Name() string { return "broken" }
+
+func (failingRouteModule) Register(modules.Context) error { return errors.New("boom") }
+
+func (failingRouteModule) OnConfigUpdated(*proxyconfig.Config) error { return nil }
+
+func TestNewServerFailsWhenRouteModuleFailsToRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmpDir := t.TempDir()
+	cfg := &proxyconfig.Config{AuthDir: tmpDir}
+	server, err := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(tmpDir, "config.yaml"), WithRouteModules(failingRouteModule{}))
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("NewServer() error = %v, want the broken module failure", err)
+	}
+	if server != nil {
+		t.Fatal("NewServer() returned a server despite the module failure")
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchHandoff re-executes the binary on SIGUSR2. Once the new process is serving on the
+// inherited socket, stop cancels the run context so this process drains and exits.
+func watchHandoff(ctx context.Context, service *cliproxy.Service, stop context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				log.Info("SIGUSR2 received; handing the listener over to a new process")
+				if err := service.Handoff(ctx); err != nil {
+					log.Errorf("handoff failed, continuing to serve: %v", err)
+					continue
+				}
+				stop()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchHandoff is unavailable on Windows, which has neither SIGUSR2 nor descriptor inheritance.
+func watchHandoff(_ context.Context, _ *cliproxy.Service, _ context.CancelFunc) {
+	log.Warn("shutdown.handoff is not supported on Windows; ignoring")
+}
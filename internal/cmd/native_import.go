@@ -0,0 +1,134 @@
+// Package cmd contains CLI helpers. This file implements importing credentials written by
+// the providers' own CLI tools (Claude Code, Codex CLI, Gemini CLI, Qwen Code).
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoNativeImport imports the credential file of a provider's native CLI and saves it through
+// the configured token store. provider may be "all" to import every credential found in the
+// default locations; filePath overrides the default location for a single provider. email is
+// used only for credentials that do not name their account.
+func DoNativeImport(cfg *config.Config, provider, filePath, email, projectID string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+
+	providers := []string{sdkAuth.NormalizeNativeProvider(provider)}
+	if strings.EqualFold(strings.TrimSpace(provider), "all") {
+		if filePath != "" {
+			log.Errorf("import: -import-file requires a single provider")
+			return
+		}
+		providers = sdkAuth.NativeImportProviders
+	} else if providers[0] == "" {
+		log.Errorf("import: unknown provider %q (supported: %s, all)", provider, strings.Join(sdkAuth.NativeImportProviders, ", "))
+		return
+	}
+
+	home, errHome := os.UserHomeDir()
+	if errHome != nil && filePath == "" {
+		log.Errorf("import: cannot locate home directory: %v", errHome)
+		return
+	}
+
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+
+	imported := 0
+	for _, name := range providers {
+		credPath, profilePath := sdkAuth.NativeCredentialPaths(name, home)
+		if filePath != "" {
+			credPath, profilePath = filePath, ""
+		}
+		data, errRead := os.ReadFile(credPath)
+		if errRead != nil {
+			if errors.Is(errRead, os.ErrNotExist) && len(providers) > 1 {
+				log.Debugf("import: no %s credentials at %s", name, credPath)
+				continue
+			}
+			log.Errorf("import: read %s credentials failed: %v", name, errRead)
+			continue
+		}
+		var profile []byte
+		if profilePath != "" {
+			profile, _ = os.ReadFile(profilePath)
+		}
+		record, errImport := sdkAuth.ImportNativeCredential(name, data, sdkAuth.NativeImportOptions{
+			Email:     email,
+			ProjectID: projectID,
+			Profile:   profile,
+			DiscoverGeminiProject: func(storage *gemini.GeminiTokenStorage) error {
+				return discoverGeminiProject(context.Background(), cfg, storage)
+			},
+		})
+		if errImport != nil {
+			log.Errorf("import: %s: %v", name, errImport)
+			continue
+		}
+		path, replaced, errSave := sdkAuth.SaveImportedAuth(context.Background(), store, record)
+		if errSave != nil {
+			log.Errorf("import: save %s credential failed: %v", name, errSave)
+			continue
+		}
+		action := "imported"
+		if replaced {
+			action = "updated"
+		}
+		fmt.Printf("%s credentials %s: %s\n", name, action, path)
+		imported++
+	}
+	if imported == 0 && len(providers) > 1 {
+		fmt.Println("No native CLI credentials found to import")
+	}
+}
+
+// discoverGeminiProject onboards imported Gemini CLI credentials onto the account's first
+// Google Cloud project, as the login flow does when no project is given.
+func discoverGeminiProject(ctx context.Context, cfg *config.Config, storage *gemini.GeminiTokenStorage) error {
+	httpClient, errClient := gemini.NewGeminiAuth().GetAuthenticatedClient(ctx, storage, cfg, &gemini.WebLoginOptions{NoBrowser: true})
+	if errClient != nil {
+		return fmt.Errorf("get authenticated client: %w", errClient)
+	}
+	projects, errProjects := fetchGCPProjects(ctx, httpClient)
+	if errProjects != nil {
+		return fmt.Errorf("fetch project list: %w", errProjects)
+	}
+	if len(projects) == 0 || strings.TrimSpace(projects[0].ProjectID) == "" {
+		return fmt.Errorf("no Google Cloud projects available for this account")
+	}
+	candidateID := strings.TrimSpace(projects[0].ProjectID)
+	log.Infof("import: activating project %s", candidateID)
+	if errSetup := performGeminiCLISetup(ctx, httpClient, storage, candidateID); errSetup != nil {
+		return errSetup
+	}
+	if strings.TrimSpace(storage.ProjectID) == "" {
+		storage.ProjectID = candidateID
+	}
+	storage.Auto = true
+	isChecked, errCheck := checkCloudAPIIsEnabled(ctx, httpClient, storage.ProjectID)
+	if errCheck != nil {
+		return fmt.Errorf("check Cloud AI API status: %w", errCheck)
+	}
+	if !isChecked {
+		return fmt.Errorf("cloud AI API is not enabled for project %s", storage.ProjectID)
+	}
+	storage.Checked = true
+	return nil
+}
@@ -0,0 +1,548 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	operatorManagementPrefix = "/v0/management"
+	operatorKeyEnv           = "CLIPROXY_MANAGEMENT_KEY"
+	operatorURLEnv           = "CLIPROXY_URL"
+)
+
+// operatorCommands lists the subcommands handled by RunOperatorCommand.
+var operatorCommands = map[string]func(*operatorClient, []string) error{
+	"auth":   runOperatorAuth,
+	"usage":  runOperatorUsage,
+	"config": runOperatorConfig,
+	"logs":   runOperatorLogs,
+	"models": runOperatorModels,
+}
+
+// IsOperatorCommand reports whether name is an operator subcommand (e.g., "auth", "usage").
+func IsOperatorCommand(name string) bool {
+	_, ok := operatorCommands[name]
+	return ok
+}
+
+// RunOperatorCommand executes an operator subcommand against a running proxy through the
+// management API and returns the process exit code.
+//
+// Parameters:
+//   - args: The subcommand and its arguments (e.g., ["auth", "list", "-o", "json"])
+//
+// Returns:
+//   - int: 0 on success, 1 on failure, 2 on usage errors
+func RunOperatorCommand(args []string) int {
+	if len(args) == 0 {
+		operatorUsage(os.Stderr)
+		return 2
+	}
+	run, ok := operatorCommands[args[0]]
+	if !ok {
+		operatorUsage(os.Stderr)
+		return 2
+	}
+	client, rest, err := newOperatorClient(args[0], args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err = run(client, rest); err != nil {
+		var usageErr operatorUsageError
+		if errors.As(err, &usageErr) {
+			_, _ = fmt.Fprintln(os.Stderr, usageErr.Error())
+			operatorUsage(os.Stderr)
+			return 2
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+type operatorUsageError string
+
+func (e operatorUsageError) Error() string { return string(e) }
+
+func operatorUsage(w io.Writer) {
+	_, _ = fmt.Fprint(w, `Operator commands (talk to a running proxy via the management API):
+  auth list
+  auth enable <name>
+  auth disable <name>
+  auth delete <name>
+  auth test <name> [-model <model>]
+  usage report [-since 24h|2006-01-02|RFC3339]
+  config get [key]
+  config set <key> <value>
+  logs tail [-lines 100] [-follow] [-interval 2s]
+  models ls
+
+Common flags:
+  -url      management base URL (env CLIPROXY_URL, default derived from -config)
+  -key      management key (env CLIPROXY_MANAGEMENT_KEY)
+  -config   config file used to derive the default URL (default config.yaml)
+  -o        output format: table or json (default table)
+`)
+}
+
+// operatorClient is a thin HTTP client for the management API.
+type operatorClient struct {
+	baseURL    string
+	key        string
+	output     string
+	httpClient *http.Client
+
+	// Subcommand options, parsed together with the common flags.
+	model    string
+	since    string
+	lines    int
+	follow   bool
+	interval time.Duration
+}
+
+func newOperatorClient(command string, args []string) (*operatorClient, []string, error) {
+	c := &operatorClient{httpClient: &http.Client{Timeout: 90 * time.Second}}
+	var baseURL, configPath string
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&baseURL, "url", os.Getenv(operatorURLEnv), "")
+	fs.StringVar(&c.key, "key", os.Getenv(operatorKeyEnv), "")
+	fs.StringVar(&configPath, "config", "config.yaml", "")
+	fs.StringVar(&c.output, "o", "table", "")
+	fs.StringVar(&c.model, "model", "", "")
+	fs.StringVar(&c.since, "since", "", "")
+	fs.IntVar(&c.lines, "lines", 100, "")
+	fs.BoolVar(&c.follow, "follow", false, "")
+	fs.BoolVar(&c.follow, "f", false, "")
+	fs.DurationVar(&c.interval, "interval", 2*time.Second, "")
+
+	rest, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			operatorUsage(os.Stdout)
+		}
+		return nil, nil, err
+	}
+	c.output = strings.ToLower(strings.TrimSpace(c.output))
+	if c.output != "table" && c.output != "json" {
+		return nil, nil, fmt.Errorf("invalid output format %q (use table or json)", c.output)
+	}
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = operatorURLFromConfig(configPath)
+	}
+	c.baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	return c, rest, nil
+}
+
+// parseInterspersed parses flags that may appear before, between or after positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func operatorURLFromConfig(path string) string {
+	host, port, scheme := "127.0.0.1", 8317, "http"
+	if cfg, err := config.LoadConfigOptional(path, true); err == nil && cfg != nil {
+		if cfg.Port > 0 {
+			port = cfg.Port
+		}
+		if h := strings.TrimSpace(cfg.Host); h != "" && h != "0.0.0.0" && h != "::" {
+			host = h
+		}
+		if cfg.TLS.Enable {
+			scheme = "https"
+		}
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		host = "[" + host + "]"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, host, port)
+}
+
+// do sends a management API request and returns the raw JSON response body.
+func (c *operatorClient) do(method, path string, query url.Values, body any) ([]byte, error) {
+	endpoint := c.baseURL + operatorManagementPrefix + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		if msg := gjson.GetBytes(data, "error").String(); msg != "" {
+			return nil, fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, msg)
+		}
+		return nil, fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// printJSON writes data as indented JSON.
+func (c *operatorClient) printJSON(data []byte) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := os.Stdout.Write(out.Bytes())
+	return err
+}
+
+// printTable writes rows as an aligned table with a header line.
+func printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func runOperatorAuth(c *operatorClient, args []string) error {
+	if len(args) == 0 {
+		return operatorUsageError("auth: missing action")
+	}
+	action := args[0]
+	if action == "list" || action == "ls" {
+		data, err := c.do(http.MethodGet, "/auth-files", nil, nil)
+		if err != nil {
+			return err
+		}
+		if c.output == "json" {
+			return c.printJSON(data)
+		}
+		rows := make([][]string, 0)
+		for _, file := range gjson.GetBytes(data, "files").Array() {
+			account := file.Get("email").String()
+			if account == "" {
+				account = file.Get("account").String()
+			}
+			status := file.Get("status").String()
+			if file.Get("disabled").Bool() {
+				status = "disabled"
+			}
+			rows = append(rows, []string{
+				file.Get("name").String(),
+				file.Get("provider").String(),
+				account,
+				status,
+				file.Get("status_message").String(),
+			})
+		}
+		return printTable([]string{"NAME", "PROVIDER", "ACCOUNT", "STATUS", "MESSAGE"}, rows)
+	}
+	if len(args) < 2 {
+		return operatorUsageError("auth " + action + ": missing credential name")
+	}
+	name := args[1]
+	var (
+		data []byte
+		err  error
+	)
+	switch action {
+	case "enable", "disable":
+		data, err = c.do(http.MethodPatch, "/auth-files/status", nil, map[string]any{"name": name, "disabled": action == "disable"})
+	case "delete", "rm":
+		data, err = c.do(http.MethodDelete, "/auth-files", url.Values{"name": {name}}, nil)
+	case "test":
+		data, err = c.do(http.MethodPost, "/auth-files/test", nil, map[string]any{"name": name, "model": c.model})
+		if err == nil && c.output != "json" {
+			result := gjson.ParseBytes(data)
+			status := "ok"
+			if !result.Get("success").Bool() {
+				status = "failed"
+			}
+			if errTable := printTable([]string{"NAME", "PROVIDER", "MODEL", "RESULT", "ERROR"}, [][]string{{
+				name, result.Get("provider").String(), result.Get("model").String(), status, result.Get("error").String(),
+			}}); errTable != nil {
+				return errTable
+			}
+			if status != "ok" {
+				return fmt.Errorf("credential test failed")
+			}
+			return nil
+		}
+	default:
+		return operatorUsageError("auth: unknown action " + action)
+	}
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(data)
+	}
+	_, err = fmt.Printf("%s: %s\n", name, action+" ok")
+	return err
+}
+
+func runOperatorUsage(c *operatorClient, args []string) error {
+	if len(args) == 0 || args[0] != "report" {
+		return operatorUsageError("usage: expected \"usage report\"")
+	}
+	since, err := parseOperatorSince(c.since, time.Now())
+	if err != nil {
+		return err
+	}
+	data, err := c.do(http.MethodGet, "/usage", nil, nil)
+	if err != nil {
+		return err
+	}
+	type usageRow struct {
+		Model        string `json:"model"`
+		Requests     int64  `json:"requests"`
+		Failures     int64  `json:"failures"`
+		InputTokens  int64  `json:"input_tokens"`
+		OutputTokens int64  `json:"output_tokens"`
+		TotalTokens  int64  `json:"total_tokens"`
+	}
+	byModel := make(map[string]*usageRow)
+	gjson.GetBytes(data, "usage.apis").ForEach(func(_, api gjson.Result) bool {
+		api.Get("models").ForEach(func(model, stats gjson.Result) bool {
+			for _, detail := range stats.Get("details").Array() {
+				if !since.IsZero() {
+					ts, errTS := time.Parse(time.RFC3339Nano, detail.Get("timestamp").String())
+					if errTS != nil || ts.Before(since) {
+						continue
+					}
+				}
+				row := byModel[model.String()]
+				if row == nil {
+					row = &usageRow{Model: model.String()}
+					byModel[model.String()] = row
+				}
+				row.Requests++
+				if detail.Get("failed").Bool() {
+					row.Failures++
+				}
+				row.InputTokens += detail.Get("tokens.input_tokens").Int()
+				row.OutputTokens += detail.Get("tokens.output_tokens").Int()
+				row.TotalTokens += detail.Get("tokens.total_tokens").Int()
+			}
+			return true
+		})
+		return true
+	})
+	rows := make([]*usageRow, 0, len(byModel))
+	for _, row := range byModel {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Model < rows[j].Model })
+	if c.output == "json" {
+		report := map[string]any{"models": rows}
+		if !since.IsZero() {
+			report["since"] = since.Format(time.RFC3339)
+		}
+		raw, errMarshal := json.Marshal(report)
+		if errMarshal != nil {
+			return errMarshal
+		}
+		return c.printJSON(raw)
+	}
+	table := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		table = append(table, []string{
+			row.Model,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Failures, 10),
+			strconv.FormatInt(row.InputTokens, 10),
+			strconv.FormatInt(row.OutputTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+		})
+	}
+	return printTable([]string{"MODEL", "REQUESTS", "FAILED", "INPUT", "OUTPUT", "TOTAL"}, table)
+}
+
+// parseOperatorSince accepts a duration ("24h"), a date ("2006-01-02") or an RFC3339 timestamp.
+func parseOperatorSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -since value %q", value)
+}
+
+func runOperatorConfig(c *operatorClient, args []string) error {
+	if len(args) == 0 {
+		return operatorUsageError("config: missing action")
+	}
+	switch args[0] {
+	case "get":
+		data, err := c.do(http.MethodGet, "/config", nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return c.printJSON(data)
+		}
+		value := gjson.GetBytes(data, gjson.Escape(args[1]))
+		if !value.Exists() {
+			return fmt.Errorf("config key %q not found", args[1])
+		}
+		if value.Type == gjson.String && c.output != "json" {
+			_, err = fmt.Println(value.String())
+			return err
+		}
+		return c.printJSON([]byte(value.Raw))
+	case "set":
+		if len(args) < 3 {
+			return operatorUsageError("config set: expected <key> <value>")
+		}
+		key := strings.Trim(strings.TrimSpace(args[1]), "/")
+		var value any = args[2]
+		if json.Valid([]byte(args[2])) {
+			value = json.RawMessage(args[2])
+		}
+		data, err := c.do(http.MethodPut, "/"+key, nil, map[string]any{"value": value})
+		if err != nil {
+			return err
+		}
+		if c.output == "json" {
+			return c.printJSON(data)
+		}
+		_, err = fmt.Printf("%s updated\n", key)
+		return err
+	default:
+		return operatorUsageError("config: unknown action " + args[0])
+	}
+}
+
+func runOperatorLogs(c *operatorClient, args []string) error {
+	if len(args) == 0 || args[0] != "tail" {
+		return operatorUsageError("logs: expected \"logs tail\"")
+	}
+	query := url.Values{}
+	if c.lines > 0 {
+		query.Set("limit", strconv.Itoa(c.lines))
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	interval := c.interval
+	if interval < 500*time.Millisecond {
+		interval = 500 * time.Millisecond
+	}
+	for {
+		data, err := c.do(http.MethodGet, "/logs", query, nil)
+		if err != nil {
+			return err
+		}
+		if c.output == "json" {
+			if err = c.printJSON(data); err != nil {
+				return err
+			}
+		} else {
+			for _, line := range gjson.GetBytes(data, "lines").Array() {
+				_, _ = fmt.Println(line.String())
+			}
+		}
+		if !c.follow {
+			return nil
+		}
+		if latest := gjson.GetBytes(data, "latest-timestamp").Int(); latest > 0 {
+			query.Set("after", strconv.FormatInt(latest, 10))
+		}
+		query.Del("limit")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func runOperatorModels(c *operatorClient, args []string) error {
+	if len(args) == 0 || (args[0] != "ls" && args[0] != "list") {
+		return operatorUsageError("models: expected \"models ls\"")
+	}
+	data, err := c.do(http.MethodGet, "/models", nil, nil)
+	if err != nil {
+		return err
+	}
+	if c.output == "json" {
+		return c.printJSON(data)
+	}
+	rows := make([][]string, 0)
+	for _, model := range gjson.GetBytes(data, "models").Array() {
+		providers := make([]string, 0)
+		for _, p := range model.Get("providers").Array() {
+			providers = append(providers, p.String())
+		}
+		rows = append(rows, []string{
+			model.Get("id").String(),
+			strings.Join(providers, ","),
+			strconv.FormatInt(model.Get("clients").Int(), 10),
+		})
+	}
+	return printTable([]string{"MODEL", "PROVIDERS", "CREDENTIALS"}, rows)
+}
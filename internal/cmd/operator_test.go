@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureStdout runs fn and returns what it wrote to os.Stdout.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	errRun := fn()
+	os.Stdout = original
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out), errRun
+}
+
+type recordedRequest struct {
+	method, path, query, auth string
+	body                      map[string]any
+}
+
+func newOperatorTestServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var seen []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := recordedRequest{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, auth: r.Header.Get("Authorization")}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &rec.body)
+		}
+		seen = append(seen, rec)
+		body, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"auth not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func TestNewOperatorClientParsesInterspersedFlags(t *testing.T) {
+	client, rest, err := newOperatorClient("auth", []string{"test", "-url", "http://proxy:9000/", "claude-a.json", "-model", "m1", "-o", "JSON"})
+	if err != nil {
+		t.Fatalf("newOperatorClient: %v", err)
+	}
+	if strings.Join(rest, " ") != "test claude-a.json" {
+		t.Fatalf("positional args = %v", rest)
+	}
+	if client.baseURL != "http://proxy:9000" || client.model != "m1" || client.output != "json" {
+		t.Fatalf("unexpected client: %+v", client)
+	}
+	if _, _, err = newOperatorClient("auth", []string{"-o", "yaml"}); err == nil {
+		t.Fatalf("expected an error for an unknown output format")
+	}
+}
+
+func TestRunOperatorAuthActions(t *testing.T) {
+	srv, seen := newOperatorTestServer(t, map[string]string{
+		"GET /v0/management/auth-files":          `{"files":[{"name":"claude-a.json","provider":"claude","email":"a@example.com","status":"active"},{"name":"codex-b.json","provider":"codex","account":"b","disabled":true}]}`,
+		"PATCH /v0/management/auth-files/status": `{"status":"ok"}`,
+		"POST /v0/management/auth-files/test":    `{"provider":"claude","model":"m1","success":false,"error":"401"}`,
+	})
+	client := &operatorClient{baseURL: srv.URL, key: "secret", output: "table", httpClient: srv.Client(), model: "m1"}
+
+	out, err := captureStdout(t, func() error { return runOperatorAuth(client, []string{"list"}) })
+	if err != nil {
+		t.Fatalf("auth list: %v", err)
+	}
+	if !strings.Contains(out, "a@example.com") || !strings.Contains(out, "disabled") {
+		t.Fatalf("unexpected auth list output:\n%s", out)
+	}
+
+	if _, err = captureStdout(t, func() error { return runOperatorAuth(client, []string{"disable", "claude-a.json"}) }); err != nil {
+		t.Fatalf("auth disable: %v", err)
+	}
+	last := (*seen)[len(*seen)-1]
+	if last.method != http.MethodPatch || last.body["name"] != "claude-a.json" || last.body["disabled"] != true || last.auth != "Bearer secret" {
+		t.Fatalf("unexpected disable request: %+v", last)
+	}
+
+	out, err = captureStdout(t, func() error { return runOperatorAuth(client, []string{"test", "claude-a.json"}) })
+	if err == nil || !strings.Contains(out, "failed") {
+		t.Fatalf("expected a failed credential test, got err=%v output:\n%s", err, out)
+	}
+	if last = (*seen)[len(*seen)-1]; last.body["model"] != "m1" {
+		t.Fatalf("test request did not carry the model: %+v", last)
+	}
+
+	if _, err = captureStdout(t, func() error { return runOperatorAuth(client, []string{"delete", "missing.json"}) }); err == nil || !strings.Contains(err.Error(), "404 auth not found") {
+		t.Fatalf("expected the server error to surface, got %v", err)
+	}
+	if last = (*seen)[len(*seen)-1]; last.method != http.MethodDelete || last.query != "name=missing.json" {
+		t.Fatalf("unexpected delete request: %+v", last)
+	}
+
+	if err = runOperatorAuth(client, []string{"enable"}); err == nil {
+		t.Fatalf("expected a usage error without a credential name")
+	} else if _, ok := err.(operatorUsageError); !ok {
+		t.Fatalf("expected operatorUsageError, got %T", err)
+	}
+}
+
+func TestRunOperatorUsageAggregatesSince(t *testing.T) {
+	now := time.Now().UTC()
+	recent := now.Add(-time.Hour).Format(time.RFC3339Nano)
+	old := now.Add(-72 * time.Hour).Format(time.RFC3339Nano)
+	srv, _ := newOperatorTestServer(t, map[string]string{
+		"GET /v0/management/usage": `{"usage":{"apis":{"k":{"models":{"m1":{"details":[` +
+			`{"timestamp":"` + recent + `","failed":true,"tokens":{"input_tokens":3,"output_tokens":4,"total_tokens":7}},` +
+			`{"timestamp":"` + old + `","tokens":{"input_tokens":100,"output_tokens":100,"total_tokens":200}}]}}}}}}`,
+	})
+	client := &operatorClient{baseURL: srv.URL, output: "json", httpClient: srv.Client(), since: "24h"}
+
+	out, err := captureStdout(t, func() error { return runOperatorUsage(client, []string{"report"}) })
+	if err != nil {
+		t.Fatalf("usage report: %v", err)
+	}
+	var report struct {
+		Models []struct {
+			Model       string `json:"model"`
+			Requests    int64  `json:"requests"`
+			Failures    int64  `json:"failures"`
+			TotalTokens int64  `json:"total_tokens"`
+		} `json:"models"`
+	}
+	if err = json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out)
+	}
+	if len(report.Models) != 1 || report.Models[0].Requests != 1 || report.Models[0].Failures != 1 || report.Models[0].TotalTokens != 7 {
+		t.Fatalf("unexpected report: %+v", report.Models)
+	}
+}
+
+func TestRunOperatorConfig(t *testing.T) {
+	srv, seen := newOperatorTestServer(t, map[string]string{
+		"GET /v0/management/config":      `{"debug":true,"proxy-url":"socks5://proxy"}`,
+		"PUT /v0/management/request-log": `{"status":"ok"}`,
+	})
+	client := &operatorClient{baseURL: srv.URL, output: "table", httpClient: srv.Client()}
+
+	out, err := captureStdout(t, func() error { return runOperatorConfig(client, []string{"get", "proxy-url"}) })
+	if err != nil || strings.TrimSpace(out) != "socks5://proxy" {
+		t.Fatalf("config get = %q, %v", out, err)
+	}
+	if _, err = captureStdout(t, func() error { return runOperatorConfig(client, []string{"get", "missing"}) }); err == nil {
+		t.Fatalf("expected an error for a missing key")
+	}
+	if _, err = captureStdout(t, func() error { return runOperatorConfig(client, []string{"set", "request-log", "true"}) }); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	if last := (*seen)[len(*seen)-1]; last.method != http.MethodPut || last.body["value"] != true {
+		t.Fatalf("config set should send a JSON value, got %+v", last)
+	}
+}
+
+func TestParseOperatorSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":                     {},
+		"2h":                   now.Add(-2 * time.Hour),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2026-03-01T00:00:00Z": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for input, want := range cases {
+		got, err := parseOperatorSince(input, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("parseOperatorSince(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := parseOperatorSince("yesterday", now); err == nil {
+		t.Fatalf("expected an error for an invalid value")
+	}
+}
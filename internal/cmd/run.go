@@ -49,6 +49,12 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 		return
 	}
 
+	runCtx, cancelRun := context.WithCancel(runCtx)
+	defer cancelRun()
+	if cfg.Shutdown.Handoff {
+		watchHandoff(runCtx, service, cancelRun)
+	}
+
 	err = service.Run(runCtx)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Errorf("proxy service exited with error: %v", err)
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// DoValidateConfig strictly validates the configuration file at configPath, prints every issue
+// and returns the process exit code: 0 when the file has no errors, 1 otherwise.
+func DoValidateConfig(configPath string) int {
+	report, err := config.ValidateConfigFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	errorCount, warningCount := 0, 0
+	for _, issue := range report.Issues {
+		if issue.Severity == config.ValidationError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+		if issue.Line > 0 {
+			fmt.Printf("%s:%d: %s\n", configPath, issue.Line, issue.String())
+		} else {
+			fmt.Printf("%s: %s\n", configPath, issue.String())
+		}
+	}
+	if errorCount == 0 && warningCount == 0 {
+		fmt.Printf("%s: configuration is valid\n", configPath)
+		return 0
+	}
+	fmt.Printf("%s: %d error(s), %d warning(s)\n", configPath, errorCount, warningCount)
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// DoWriteConfigSchema writes the configuration JSON Schema to outputPath and returns the
+// process exit code.
+func DoWriteConfigSchema(outputPath string) int {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate config schema: %v\n", err)
+		return 1
+	}
+	if err = os.WriteFile(outputPath, schema, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write config schema: %v\n", err)
+		return 1
+	}
+	fmt.Printf("config schema written to %s\n", outputPath)
+	return 0
+}
@@ -30,12 +30,18 @@ type Config struct {
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
 
+	// Shutdown controls graceful draining on stop and zero-downtime restarts.
+	Shutdown ShutdownConfig `yaml:"shutdown,omitempty" json:"shutdown,omitempty"`
+
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// SecretStoreFile is a YAML file of name/value pairs serving ${local:name} secret references.
+	SecretStoreFile string `yaml:"secret-store-file,omitempty" json:"-"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
@@ -63,6 +69,9 @@ type Config struct {
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
 
+	// Quarantine takes credentials that keep failing with unrecoverable errors out of rotation.
+	Quarantine QuarantineConfig `yaml:"quarantine,omitempty" json:"quarantine,omitempty"`
+
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
@@ -102,7 +111,21 @@ type Config struct {
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// Notifications sends credential and routing incidents to webhooks.
+	Notifications NotificationsConfig `yaml:"notifications,omitempty" json:"-"`
+
+	// Pricing prices usage records per model and caps spend with currency budgets.
+	Pricing PricingConfig `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// Modules holds raw per-module configuration sections keyed by module name. Each section is
+	// decoded by the route module registered under that name.
+	Modules map[string]yaml.Node `yaml:"modules,omitempty" json:"-"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
+
+	// secretRefs maps the config paths of values resolved from ${scheme:ref} references to the
+	// reference text so saving the config keeps the references.
+	secretRefs map[string]secretRef `yaml:"-" json:"-"`
 }
 
 // TLSConfig holds HTTPS server settings.
@@ -115,6 +138,16 @@ type TLSConfig struct {
 	Key string `yaml:"key" json:"key"`
 }
 
+// ShutdownConfig holds graceful shutdown and restart settings.
+type ShutdownConfig struct {
+	// DrainTimeoutSeconds bounds how long in-flight requests may finish after the server stops
+	// accepting new connections. <= 0 uses the default of 30 seconds.
+	DrainTimeoutSeconds int `yaml:"drain-timeout-seconds,omitempty" json:"drain-timeout-seconds,omitempty"`
+	// Handoff enables SIGUSR2 restarts: the binary is re-executed with the listening socket
+	// inherited, and this process drains once the new one is serving.
+	Handoff bool `yaml:"handoff,omitempty" json:"handoff,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -126,6 +159,173 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// ConfigHistoryLimit is how many config versions written through the management API are kept
+	// for diffing and rollback. 0 uses the default of 50; a negative value disables the history.
+	ConfigHistoryLimit int `yaml:"config-history-limit,omitempty"`
+	// Keys defines additional named management keys limited to a set of scopes. The secret-key
+	// and MANAGEMENT_PASSWORD keep full access.
+	Keys []ManagementKey `yaml:"keys,omitempty"`
+}
+
+// Management key scopes.
+const (
+	// ManagementScopeReadOnly allows reading settings, logs and usage without secrets.
+	ManagementScopeReadOnly = "read-only"
+	// ManagementScopeUsage allows reading, exporting and importing usage statistics.
+	ManagementScopeUsage = "usage"
+	// ManagementScopeCredentialsWrite allows managing API keys, auth files and OAuth logins,
+	// including downloading raw credentials.
+	ManagementScopeCredentialsWrite = "credentials:write"
+	// ManagementScopeConfigWrite allows changing every other setting and reading the raw config.
+	// Changes to the remote-management section still require admin.
+	ManagementScopeConfigWrite = "config:write"
+	// ManagementScopeAdmin grants every scope.
+	ManagementScopeAdmin = "admin"
+)
+
+// ManagementScopes lists the scopes accepted in remote-management.keys.
+var ManagementScopes = []string{
+	ManagementScopeReadOnly,
+	ManagementScopeUsage,
+	ManagementScopeCredentialsWrite,
+	ManagementScopeConfigWrite,
+	ManagementScopeAdmin,
+}
+
+// Notification event types.
+const (
+	NotifyCredentialDisabled    = "credential-disabled"
+	NotifyCredentialQuarantined = "credential-quarantined"
+	NotifyRefreshFailed         = "refresh-failed"
+	NotifyCooldownEntered       = "cooldown-entered"
+	NotifyCooldownCleared       = "cooldown-cleared"
+	NotifyModelUnavailable      = "model-unavailable"
+	NotifyConfigReloaded        = "config-reloaded"
+	NotifyRelayConnected        = "relay-connected"
+	NotifyRelayDisconnected     = "relay-disconnected"
+	NotifyTest                  = "test"
+)
+
+// NotifyEvents lists the notification event types accepted in webhook event filters.
+var NotifyEvents = []string{
+	NotifyCredentialDisabled,
+	NotifyCredentialQuarantined,
+	NotifyRefreshFailed,
+	NotifyCooldownEntered,
+	NotifyCooldownCleared,
+	NotifyModelUnavailable,
+	NotifyConfigReloaded,
+	NotifyRelayConnected,
+	NotifyRelayDisconnected,
+	NotifyTest,
+}
+
+// NotificationsConfig lists the webhooks that receive incident notifications.
+type NotificationsConfig struct {
+	// Webhooks are the notification targets.
+	Webhooks []NotificationWebhook `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+// NotificationWebhook is one notification target.
+type NotificationWebhook struct {
+	// Name identifies the webhook in logs and test results.
+	Name string `yaml:"name" json:"name"`
+	// URL receives a POST per event.
+	URL string `yaml:"url" json:"url"`
+	// Format is "json" (the event object, default), "slack" ({"text": ...}, also accepted by
+	// Mattermost, Rocket.Chat and Google Chat) or "discord" ({"content": ...}).
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Events limits delivery to these event types; empty delivers every event.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+	// Headers are added to every request, e.g. an Authorization header.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// DedupSeconds suppresses repeats of the same event for the same subject within the window;
+	// 0 uses 300 and a negative value disables deduplication.
+	DedupSeconds int `yaml:"dedup-seconds,omitempty" json:"dedup-seconds,omitempty"`
+	// MaxPerMinute caps deliveries per minute, dropping the excess; 0 uses 30, negative disables.
+	MaxPerMinute int `yaml:"max-per-minute,omitempty" json:"max-per-minute,omitempty"`
+	// Retries is the number of retries after a failed delivery; 0 uses 3, negative disables.
+	Retries int `yaml:"retries,omitempty" json:"retries,omitempty"`
+	// TimeoutSeconds bounds each delivery attempt; 0 uses 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// Budget periods.
+const (
+	BudgetPeriodDaily   = "daily"
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodTotal   = "total"
+)
+
+// PricingConfig holds the model price table used for cost accounting and the spend budgets
+// enforced from it.
+type PricingConfig struct {
+	// Currency labels prices, costs and budgets, e.g. "USD" (default). Amounts are never converted.
+	Currency string `yaml:"currency,omitempty" json:"currency,omitempty"`
+	// DisableDefaults drops the built-in price table so only Models are used.
+	DisableDefaults bool `yaml:"disable-defaults,omitempty" json:"disable-defaults,omitempty"`
+	// Models are checked in order before the built-in defaults; the first match prices a record.
+	Models []ModelPrice `yaml:"models,omitempty" json:"models,omitempty"`
+	// Budgets reject requests from client API keys once their spend reaches a limit.
+	Budgets []SpendBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// ModelPrice prices a model pattern per token class, in currency units per million tokens.
+type ModelPrice struct {
+	// Model is the model name; "*" wildcards are allowed.
+	Model string `yaml:"model" json:"model"`
+	// Provider limits the entry to one provider (e.g. "claude", "gemini-cli", "codex" or an
+	// openai-compatibility name); empty matches every provider.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Input prices uncached prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+	// Output prices completion tokens.
+	Output float64 `yaml:"output" json:"output"`
+	// Reasoning prices reasoning tokens; 0 uses Output.
+	Reasoning float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+	// CachedInput prices prompt tokens served from the provider cache; 0 uses Input.
+	CachedInput float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+}
+
+// SpendBudget caps the spend of matching requests over a period.
+type SpendBudget struct {
+	// Name identifies the budget in errors and the spend report.
+	Name string `yaml:"name" json:"name"`
+	// APIKeys limits the budget to client API keys (wildcards allowed); empty matches every key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// Models limits the budget to requested models (wildcards allowed); empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// PerKey applies Limit to each matching client key separately instead of to their total.
+	PerKey bool `yaml:"per-key,omitempty" json:"per-key,omitempty"`
+	// Period is "daily", "weekly", "monthly" (default) or "total". Periods start at UTC midnight,
+	// on Mondays for weekly and on the first of the month for monthly budgets.
+	Period string `yaml:"period,omitempty" json:"period,omitempty"`
+	// Limit is the spend, in the pricing currency, after which requests are rejected.
+	Limit float64 `yaml:"limit" json:"limit"`
+}
+
+// CredentialSchedule limits when a credential may be selected. Auth files carry the same
+// object under a "schedule" key.
+type CredentialSchedule struct {
+	// Timezone is the IANA zone the windows are evaluated in, e.g. "Europe/Berlin"; empty uses UTC.
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Mode "allow" (default) makes the credential usable only inside Windows; "deny" makes it
+	// unusable inside them.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Windows are cron expressions (minute hour day-of-month month day-of-week); every minute
+	// an expression matches belongs to the window, e.g. "* 9-17 * * mon-fri" for office hours.
+	Windows []string `yaml:"windows" json:"windows"`
+}
+
+// ManagementKey is a named management API key limited to a set of scopes.
+type ManagementKey struct {
+	// Name identifies the key in the audit log and config history.
+	Name string `yaml:"name" json:"name"`
+	// Key is the key itself, in plaintext or as a bcrypt hash.
+	Key string `yaml:"key" json:"-"`
+	// Scopes lists the granted scopes: "read-only", "usage", "credentials:write", "config:write" or "admin".
+	Scopes []string `yaml:"scopes" json:"scopes"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -138,6 +338,17 @@ type QuotaExceeded struct {
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
 }
 
+// QuarantineConfig controls the failure-streak policy for auth-file credentials. A credential is
+// quarantined after FailureThreshold consecutive unrecoverable failures: refreshes rejected
+// with invalid_grant or 401/403, and requests still rejected with 401/403 after a refresh. A
+// quarantined credential is disabled, is no longer refreshed and stays so across restarts until
+// it is re-enabled or logged in again from the management API.
+type QuarantineConfig struct {
+	// FailureThreshold is the streak length that quarantines a credential; 0 uses 3 and a
+	// negative value disables quarantine.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
@@ -260,6 +471,9 @@ type ClaudeKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// Schedule restricts when this key may be selected.
+	Schedule *CredentialSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // ClaudeModel describes a mapping between an alias and the actual upstream model name.
@@ -298,6 +512,9 @@ type CodexKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// Schedule restricts when this key may be selected.
+	Schedule *CredentialSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // CodexModel describes a mapping between an alias and the actual upstream model name.
@@ -335,6 +552,9 @@ type GeminiKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// Schedule restricts when this key may be selected.
+	Schedule *CredentialSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // GeminiModel describes a mapping between an alias and the actual upstream model name.
@@ -369,6 +589,27 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Discovery optionally registers models returned by the provider's GET /models endpoint.
+	Discovery OpenAICompatibilityDiscovery `yaml:"discovery,omitempty" json:"discovery,omitempty"`
+}
+
+// OpenAICompatibilityDiscovery configures periodic model discovery for an OpenAI-compatible provider.
+// Discovered models are registered alongside the manually configured models, which take
+// precedence when both refer to the same upstream model.
+type OpenAICompatibilityDiscovery struct {
+	// Enabled toggles periodic discovery via GET {base-url}/models.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// IntervalSeconds controls how often the model list is refreshed. <= 0 uses the default (1 hour).
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+
+	// Include limits discovered models to IDs matching at least one wildcard pattern (e.g., "openai/*").
+	// Empty means all discovered models are included.
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+
+	// Exclude drops discovered models matching any wildcard pattern (e.g., "*-preview").
+	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -378,6 +619,9 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Schedule restricts when this key may be selected.
+	Schedule *CredentialSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -390,6 +634,9 @@ type OpenAICompatibilityModel struct {
 	Alias string `yaml:"alias" json:"alias"`
 }
 
+func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
+func (m OpenAICompatibilityModel) GetAlias() string { return m.Alias }
+
 // LoadConfig reads a YAML configuration file from the given path,
 // unmarshals it into a Config struct, applies environment variable overrides,
 // and returns it.
@@ -456,6 +703,14 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		}
 	}
 
+	// Resolve ${env:...}, ${file:...} and other secret references before anything reads the values.
+	for _, errRef := range cfg.resolveSecretRefs() {
+		if errors.Is(errRef, ErrUnknownSecretScheme) {
+			continue
+		}
+		return nil, fmt.Errorf("failed to resolve secret reference: %w", errRef)
+	}
+
 	// Hash remote management key if plaintext is detected (nested)
 	// We consider a value to be already hashed if it looks like a bcrypt hash ($2a$, $2b$, or $2y$ prefix).
 	if cfg.RemoteManagement.SecretKey != "" && !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
@@ -463,11 +718,16 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		if errHash != nil {
 			return nil, fmt.Errorf("failed to hash remote management key: %w", errHash)
 		}
+		const secretKeyPath = "remote-management.secret-key"
+		if ref, fromRef := cfg.secretRefs[secretKeyPath]; fromRef {
+			// Keep the reference in the file; only the in-memory value is hashed.
+			cfg.setSecretRef(secretKeyPath, ref.text, hashed)
+		} else {
+			// Persist the hashed value back to the config file to avoid re-hashing on next startup.
+			// Preserve YAML comments and ordering; update only the nested key.
+			_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
+		}
 		cfg.RemoteManagement.SecretKey = hashed
-
-		// Persist the hashed value back to the config file to avoid re-hashing on next startup.
-		// Preserve YAML comments and ordering; update only the nested key.
-		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
@@ -571,6 +831,11 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e.Prefix = normalizeModelPrefix(e.Prefix)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
+		e.Discovery.Include = NormalizeExcludedModels(e.Discovery.Include)
+		e.Discovery.Exclude = NormalizeExcludedModels(e.Discovery.Exclude)
+		if e.Discovery.IntervalSeconds < 0 {
+			e.Discovery.IntervalSeconds = 0
+		}
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
@@ -786,6 +1051,8 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	if generated.Content[0].Kind != yaml.MappingNode {
 		return fmt.Errorf("expected generated root mapping node")
 	}
+	// Write secret references back instead of the values they resolved to.
+	restoreSecretRefs(generated.Content[0], "", cfg.secretRefs)
 
 	// Remove deprecated sections before merging back the sanitized config.
 	removeLegacyAuthBlock(original.Content[0])
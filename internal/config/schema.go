@@ -0,0 +1,212 @@
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configSources provides the doc comments used as schema descriptions, so the published
+// schema stays in sync with the field documentation in this package.
+//
+//go:embed config.go sdk_config.go vertex_compat.go
+var configSources embed.FS
+
+// jsonSchema is the subset of JSON Schema (draft-07) emitted for the configuration.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AllOf                []*jsonSchema          `json:"allOf,omitempty"`
+	Definitions          map[string]*jsonSchema `json:"definitions,omitempty"`
+}
+
+var (
+	schemaOnce sync.Once
+	schemaData []byte
+	schemaErr  error
+)
+
+// JSONSchema returns a JSON Schema describing config.yaml, generated from the Config struct,
+// its yaml tags and field doc comments. Point an editor at it, for example with
+// "# yaml-language-server: $schema=config.schema.json", to get completion and key checking.
+func JSONSchema() ([]byte, error) {
+	schemaOnce.Do(func() {
+		gen := &schemaGenerator{docs: loadFieldDocs(), definitions: make(map[string]*jsonSchema)}
+		root := gen.structSchema(reflect.TypeOf(Config{}))
+		root.Schema = "http://json-schema.org/draft-07/schema#"
+		root.ID = "https://github.com/router-for-me/CLIProxyAPI/config.schema.json"
+		root.Title = "CLIProxyAPI configuration"
+		root.Definitions = gen.definitions
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			schemaErr = err
+			return
+		}
+		schemaData = append(data, '\n')
+	})
+	if schemaErr != nil {
+		return nil, schemaErr
+	}
+	return append([]byte(nil), schemaData...), nil
+}
+
+type schemaGenerator struct {
+	// docs maps "Type.Field" (and "Type" for type docs) to doc comment text.
+	docs        map[string]string
+	definitions map[string]*jsonSchema
+}
+
+var yamlNodeType = reflect.TypeOf(yaml.Node{})
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == yamlNodeType {
+			return &jsonSchema{}
+		}
+		name := t.Name()
+		if _, ok := g.definitions[name]; !ok {
+			g.definitions[name] = nil // reserve to stop recursion
+			g.definitions[name] = g.structSchema(t)
+		}
+		return &jsonSchema{Ref: "#/definitions/" + name}
+	default:
+		return &jsonSchema{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *jsonSchema {
+	out := &jsonSchema{
+		Type:                 "object",
+		Description:          g.docs[t.Name()],
+		Properties:           make(map[string]*jsonSchema),
+		AdditionalProperties: false,
+	}
+	g.addFields(out, t)
+	return out
+}
+
+func (g *schemaGenerator) addFields(out *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			g.addFields(out, field.Type)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		prop := g.typeSchema(field.Type)
+		if doc := g.docs[t.Name()+"."+field.Name]; doc != "" {
+			if prop.Ref != "" {
+				// draft-07 ignores siblings of $ref, so wrap the reference.
+				prop = &jsonSchema{Description: doc, AllOf: []*jsonSchema{prop}}
+			} else {
+				prop.Description = doc
+			}
+		}
+		out.Properties[name] = prop
+	}
+}
+
+// loadFieldDocs parses the embedded package sources and collects type and field doc comments.
+func loadFieldDocs() map[string]string {
+	docs := make(map[string]string)
+	fset := token.NewFileSet()
+	entries, err := configSources.ReadDir(".")
+	if err != nil {
+		return docs
+	}
+	for _, entry := range entries {
+		src, errRead := configSources.ReadFile(entry.Name())
+		if errRead != nil {
+			continue
+		}
+		file, errParse := parser.ParseFile(fset, entry.Name(), src, parser.ParseComments)
+		if errParse != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec, okSpec := spec.(*ast.TypeSpec)
+				if !okSpec {
+					continue
+				}
+				doc := typeSpec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if text := docText(doc); text != "" {
+					docs[typeSpec.Name.Name] = text
+				}
+				structType, okStruct := typeSpec.Type.(*ast.StructType)
+				if !okStruct {
+					continue
+				}
+				for _, field := range structType.Fields.List {
+					text := docText(field.Doc)
+					if text == "" {
+						text = docText(field.Comment)
+					}
+					if text == "" {
+						continue
+					}
+					for _, name := range field.Names {
+						docs[typeSpec.Name.Name+"."+name.Name] = text
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
+
+// docText flattens a comment group into a single line.
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
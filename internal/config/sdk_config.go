@@ -19,6 +19,30 @@ type StickyIndexConfig struct {
 	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
 }
 
+// StickyRoutingConfig selects which providers route conversations to the same credential
+// to preserve upstream prompt caches, and how conversations are fingerprinted.
+type StickyRoutingConfig struct {
+	// Providers maps a provider key (e.g. "codex", "claude", "gemini-cli", "antigravity",
+	// "openai-compatibility" or a specific compat provider name) to a strategy:
+	// "message-hash" (per user-message hashes), "prefix" (system prompt plus leading
+	// messages) or "off". When empty, only codex uses "message-hash".
+	Providers map[string]string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// PrefixMessages bounds how many leading messages contribute to prefix fingerprints; <=0 uses 4.
+	PrefixMessages int `yaml:"prefix-messages,omitempty" json:"prefix-messages,omitempty"`
+}
+
+// ConcurrencyConfig caps in-flight requests per credential and queues the overflow.
+type ConcurrencyConfig struct {
+	// Providers maps a provider key (e.g. "claude", "codex", "openai-compatibility" or a
+	// compat provider name) to the maximum in-flight requests per credential; <=0 is unlimited.
+	// Credentials may override it with "max_concurrency" in their auth file.
+	Providers map[string]int `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// MaxQueue bounds waiting requests per provider; <=0 uses 100.
+	MaxQueue int `yaml:"max-queue,omitempty" json:"max-queue,omitempty"`
+	// QueueTimeoutSeconds bounds how long a request waits for a free credential; <=0 uses 30.
+	QueueTimeoutSeconds int `yaml:"queue-timeout-seconds,omitempty" json:"queue-timeout-seconds,omitempty"`
+}
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -45,9 +69,64 @@ type SDKConfig struct {
 	// used by SmartStickySelector to keep sticky routing across restarts.
 	StickyIndex StickyIndexConfig `yaml:"sticky-index,omitempty" json:"sticky-index,omitempty"`
 
+	// StickyRouting configures per-provider conversation-aware credential affinity.
+	StickyRouting StickyRoutingConfig `yaml:"sticky-routing,omitempty" json:"sticky-routing,omitempty"`
+
+	// Concurrency limits parallel requests per credential with fair queueing across client keys.
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// ResponseRules rewrite response bodies (non-streaming and SSE chunks) and headers
+	// before they reach the client. They complement the request-side payload rules.
+	ResponseRules []ResponseRule `yaml:"response-rules,omitempty" json:"response-rules,omitempty"`
+
+	// MediaFetch configures server-side downloading of remote image/file URLs so they can be
+	// inlined as base64 for providers that cannot fetch URLs themselves.
+	MediaFetch MediaFetchConfig `yaml:"media-fetch,omitempty" json:"media-fetch,omitempty"`
+
+	// ContextLimits rejects prompts that cannot fit the target model's context window
+	// before a credential is selected.
+	ContextLimits ContextLimitsConfig `yaml:"context-limits,omitempty" json:"context-limits,omitempty"`
+
+	// Audio configures the OpenAI-compatible transcription and translation endpoints.
+	Audio AudioConfig `yaml:"audio,omitempty" json:"audio,omitempty"`
+
+	// StructuredOutputRetry re-sends a non-streaming chat completion once when a backend without
+	// native structured output returns content violating the requested json_schema. Each retry
+	// is a second upstream call, so it is off by default.
+	StructuredOutputRetry bool `yaml:"structured-output-retry,omitempty" json:"structured-output-retry,omitempty"`
+}
+
+// ResponseRule describes a rewrite applied to responses returned to matching requests.
+// All match conditions must hold; empty conditions match every request.
+type ResponseRule struct {
+	// Models restricts the rule to requested model names (wildcards allowed) and client
+	// protocols ("openai", "openai-response", "claude", "gemini", "gemini-cli").
+	Models []PayloadModelRule `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys restricts the rule to client principals (wildcards allowed).
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// MatchHeaders restricts the rule to requests whose headers match the given values (wildcards allowed).
+	MatchHeaders map[string]string `yaml:"match-headers,omitempty" json:"match-headers,omitempty"`
+
+	// RewriteModel replaces model fields in the response with the model name the client requested.
+	RewriteModel bool `yaml:"rewrite-model,omitempty" json:"rewrite-model,omitempty"`
+
+	// FinishReasons maps finish/stop reason values (e.g., "length" -> "max_tokens").
+	FinishReasons map[string]string `yaml:"finish-reasons,omitempty" json:"finish-reasons,omitempty"`
+
+	// Remove lists JSON paths (gjson/sjson syntax) deleted from the response.
+	Remove []string `yaml:"remove,omitempty" json:"remove,omitempty"`
+
+	// Set maps JSON paths (gjson/sjson syntax) to values written into the response.
+	Set map[string]any `yaml:"set,omitempty" json:"set,omitempty"`
+
+	// Headers are added to the HTTP response.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -60,6 +139,54 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// MidstreamRetries controls how many times a stream that fails after bytes were sent may be
+	// resumed on another credential, using the partial output as an assistant prefill.
+	// <= 0 disables mid-stream recovery. Default is 0.
+	MidstreamRetries int `yaml:"midstream-retries,omitempty" json:"midstream-retries,omitempty"`
+}
+
+// MediaFetchConfig controls the optional remote media fetcher.
+type MediaFetchConfig struct {
+	// Enabled turns on downloading and inlining of http(s) media URLs in requests.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Providers restricts inlining to requests routed to these providers; empty means all.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// AllowedHosts restricts fetches to matching hosts (wildcards such as "*.example.com"); empty allows any public host.
+	AllowedHosts []string `yaml:"allowed-hosts,omitempty" json:"allowed-hosts,omitempty"`
+	// AllowPrivateNetworks permits loopback, private and link-local destinations. Keep false unless required.
+	AllowPrivateNetworks bool `yaml:"allow-private-networks,omitempty" json:"allow-private-networks,omitempty"`
+	// MaxBytes bounds a single download; <= 0 uses 20 MiB.
+	MaxBytes int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+	// TimeoutSeconds bounds a single download; <= 0 uses 15 seconds.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// CacheTTLSeconds keeps downloaded media keyed by URL hash; 0 uses 600 seconds, < 0 disables caching.
+	CacheTTLSeconds int `yaml:"cache-ttl-seconds,omitempty" json:"cache-ttl-seconds,omitempty"`
+}
+
+// ContextLimitsConfig controls pre-flight context-length enforcement.
+type ContextLimitsConfig struct {
+	// Enabled turns on token estimation and rejection of over-long prompts.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Models overrides the limit taken from model metadata; the first matching entry wins.
+	Models []ContextLimitModel `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ContextLimitModel overrides the input token limit for matching models.
+type ContextLimitModel struct {
+	// Name is the model name or wildcard pattern (e.g., "claude-*", "gemini-2.5-*").
+	Name string `yaml:"name" json:"name"`
+	// InputTokens is the maximum prompt size; 0 uses model metadata, negative disables the check.
+	InputTokens int `yaml:"input-tokens" json:"input-tokens"`
+}
+
+// AudioConfig controls /v1/audio/transcriptions and /v1/audio/translations.
+type AudioConfig struct {
+	// Model is the Gemini or Vertex model that receives the audio when the requested model
+	// (e.g., "whisper-1") is not served by any credential. Empty uses gemini-2.5-flash.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// MaxUploadBytes bounds the multipart upload; <= 0 uses 25 MiB.
+	MaxUploadBytes int64 `yaml:"max-upload-bytes,omitempty" json:"max-upload-bytes,omitempty"`
 }
 
 // AccessConfig groups request authentication providers.
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretResolveTimeout bounds how long a config load waits for secret resolvers.
+const secretResolveTimeout = 30 * time.Second
+
+// ErrUnknownSecretScheme is reported for ${scheme:...} references without a registered resolver.
+// Such values are kept verbatim.
+var ErrUnknownSecretScheme = errors.New("no secret resolver registered for scheme")
+
+// secretRefPattern matches ${scheme:reference} placeholders inside config strings.
+var secretRefPattern = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]+)\}`)
+
+// SecretResolver resolves the reference part of a ${scheme:reference} placeholder.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// ResolveSecret implements SecretResolver.
+func (f SecretResolverFunc) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"env":  SecretResolverFunc(resolveEnvSecret),
+		"file": SecretResolverFunc(resolveFileSecret),
+	}
+)
+
+// RegisterSecretResolver makes resolver available for ${scheme:...} references in config
+// files, for example to read keys from an external secret manager. Registering a nil resolver
+// removes the scheme. The built-in schemes are env, file and local.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" {
+		return
+	}
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	if resolver == nil {
+		delete(secretResolvers, scheme)
+		return
+	}
+	secretResolvers[scheme] = resolver
+}
+
+func resolveEnvSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(strings.TrimSpace(name))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// LocalSecretStore is a stand-in for an external secret manager: it serves ${local:name}
+// references from a YAML (or JSON) file of name/value pairs, configured by secret-store-file.
+type LocalSecretStore struct {
+	path    string
+	once    sync.Once
+	secrets map[string]string
+	err     error
+}
+
+// NewLocalSecretStore returns a store reading secrets from path on first use.
+func NewLocalSecretStore(path string) *LocalSecretStore {
+	return &LocalSecretStore{path: path}
+}
+
+// ResolveSecret implements SecretResolver.
+func (s *LocalSecretStore) ResolveSecret(_ context.Context, name string) (string, error) {
+	if strings.TrimSpace(s.path) == "" {
+		return "", fmt.Errorf("secret-store-file is not configured")
+	}
+	s.once.Do(func() {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			s.err = fmt.Errorf("read secret store: %w", err)
+			return
+		}
+		if err = yaml.Unmarshal(data, &s.secrets); err != nil {
+			s.err = fmt.Errorf("parse secret store: %w", err)
+		}
+	})
+	if s.err != nil {
+		return "", s.err
+	}
+	value, ok := s.secrets[strings.TrimSpace(name)]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", name, s.path)
+	}
+	return value, nil
+}
+
+// SecretRefError reports a secret reference that could not be resolved.
+type SecretRefError struct {
+	// Path is the config key holding the reference, e.g. "gemini-api-key[0].api-key".
+	Path string
+	// Ref is the placeholder, e.g. "${env:GEMINI_KEY}".
+	Ref string
+	Err error
+}
+
+func (e *SecretRefError) Error() string {
+	return fmt.Sprintf("%s: resolve %s: %v", e.Path, e.Ref, e.Err)
+}
+
+func (e *SecretRefError) Unwrap() error { return e.Err }
+
+// secretRef records the reference text a config value was resolved from.
+type secretRef struct {
+	// text is the original value, e.g. "${env:GEMINI_KEY}".
+	text string
+	// value is the value the config held after resolution.
+	value string
+}
+
+// resolveSecretRefs replaces ${scheme:ref} placeholders in every string of cfg, including
+// raw module sections. It records the original text of each resolved value by its config
+// path so the references, not the secrets, are written back when the config is saved.
+// References with unknown schemes are left as they are and reported with ErrUnknownSecretScheme.
+func (cfg *Config) resolveSecretRefs() []*SecretRefError {
+	return cfg.walkSecretRefs(true)
+}
+
+// unresolvableSecretRefs reports the ${scheme:ref} placeholders of cfg that cannot be resolved.
+// It resolves each reference to check it but leaves cfg unchanged, so no secret value reaches
+// the caller.
+func (cfg *Config) unresolvableSecretRefs() []*SecretRefError {
+	return cfg.walkSecretRefs(false)
+}
+
+func (cfg *Config) walkSecretRefs(apply bool) []*SecretRefError {
+	secretResolversMu.RLock()
+	resolvers := make(map[string]SecretResolver, len(secretResolvers)+1)
+	for scheme, resolver := range secretResolvers {
+		resolvers[scheme] = resolver
+	}
+	secretResolversMu.RUnlock()
+	if _, ok := resolvers["local"]; !ok {
+		resolvers["local"] = NewLocalSecretStore(cfg.SecretStoreFile)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+	var errs []*SecretRefError
+	resolve := func(path, text string) (string, bool) {
+		if !strings.Contains(text, "${") {
+			return text, false
+		}
+		changed := false
+		resolved := secretRefPattern.ReplaceAllStringFunc(text, func(match string) string {
+			parts := secretRefPattern.FindStringSubmatch(match)
+			resolver, ok := resolvers[strings.ToLower(parts[1])]
+			if !ok {
+				errs = append(errs, &SecretRefError{Path: path, Ref: match, Err: ErrUnknownSecretScheme})
+				return match
+			}
+			value, err := resolver.ResolveSecret(ctx, parts[2])
+			if err != nil {
+				errs = append(errs, &SecretRefError{Path: path, Ref: match, Err: err})
+				return match
+			}
+			changed = true
+			return value
+		})
+		if !changed || !apply {
+			return text, false
+		}
+		cfg.setSecretRef(path, text, resolved)
+		return resolved, true
+	}
+	walkConfigStrings(reflect.ValueOf(cfg).Elem(), "", resolve)
+	return errs
+}
+
+// walkConfigStrings calls fn for every string reachable from v, replacing the string when fn
+// reports a change. Paths use the yaml key names.
+func walkConfigStrings(v reflect.Value, path string, fn func(path, value string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkConfigStrings(v.Elem(), path, fn)
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return
+		}
+		if updated, ok := fn(path, v.String()); ok {
+			v.SetString(updated)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkConfigStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			walkConfigStrings(elem, joinConfigPath(path, fmt.Sprint(iter.Key().Interface())), fn)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Struct:
+		if v.Type() == yamlNodeType {
+			walkYAMLNodeStrings(v.Addr().Interface().(*yaml.Node), path, fn)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if strings.Contains(opts, "inline") {
+				walkConfigStrings(v.Field(i), path, fn)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			walkConfigStrings(v.Field(i), joinConfigPath(path, name), fn)
+		}
+	}
+}
+
+func walkYAMLNodeStrings(node *yaml.Node, path string, fn func(path, value string) (string, bool)) {
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" || node.Tag == "" {
+			if updated, ok := fn(path, node.Value); ok {
+				node.Value = updated
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkYAMLNodeStrings(node.Content[i+1], joinConfigPath(path, node.Content[i].Value), fn)
+		}
+	default:
+		for i, child := range node.Content {
+			walkYAMLNodeStrings(child, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// setSecretRef records that the value at path was resolved from text.
+func (cfg *Config) setSecretRef(path, text, value string) {
+	if cfg.secretRefs == nil {
+		cfg.secretRefs = make(map[string]secretRef)
+	}
+	cfg.secretRefs[path] = secretRef{text: text, value: value}
+}
+
+// listIndexPattern matches the list indexes of a config path.
+var listIndexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// secretRefKey identifies a resolved value by the field holding it, ignoring list indexes, so
+// a reference survives entries being deleted or reordered before the config is saved.
+func secretRefKey(path, value string) string {
+	return listIndexPattern.ReplaceAllString(path, "[]") + "\x00" + value
+}
+
+// restoreSecretRefs puts the original ${scheme:ref} text back into the scalars of a rendered
+// config that still hold a resolved value in the field it was resolved for, wherever the list
+// entry holding it has moved. A scalar whose value was changed since loading keeps the new
+// value, and the same value in an unrelated field is left as it is.
+func restoreSecretRefs(node *yaml.Node, path string, refs map[string]secretRef) {
+	if node == nil || len(refs) == 0 {
+		return
+	}
+	texts := make(map[string]string, len(refs))
+	for refPath, ref := range refs {
+		texts[secretRefKey(refPath, ref.value)] = ref.text
+	}
+	restoreSecretRefTexts(node, path, texts)
+}
+
+func restoreSecretRefTexts(node *yaml.Node, path string, texts map[string]string) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if text, ok := texts[secretRefKey(path, node.Value)]; ok {
+			node.Value = text
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			restoreSecretRefTexts(node.Content[i+1], joinConfigPath(path, node.Content[i].Value), texts)
+		}
+	default:
+		for i, child := range node.Content {
+			restoreSecretRefTexts(child, fmt.Sprintf("%s[%d]", path, i), texts)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigResolvesSecretRefsAndSaveKeepsThem(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_GEMINI_KEY", "gemini-secret")
+	t.Setenv("TEST_MGMT_KEY", "mgmt-secret")
+	keyFile := filepath.Join(dir, "claude.key")
+	if err := os.WriteFile(keyFile, []byte("claude-secret\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	storeFile := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(storeFile, []byte("amp: amp-secret\n"), 0o600); err != nil {
+		t.Fatalf("write secret store: %v", err)
+	}
+	RegisterSecretResolver("test-vault", SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+		return "vault-" + ref, nil
+	}))
+	t.Cleanup(func() { RegisterSecretResolver("test-vault", nil) })
+
+	configPath := filepath.Join(dir, "config.yaml")
+	content := `port: 8317
+secret-store-file: "` + storeFile + `"
+remote-management:
+  secret-key: "${env:TEST_MGMT_KEY}"
+gemini-api-key:
+  - api-key: "${env:TEST_GEMINI_KEY}"
+claude-api-key:
+  - api-key: "${file:` + keyFile + `}"
+codex-api-key:
+  - api-key: "${test-vault:codex}"
+    base-url: "https://example.com"
+ampcode:
+  upstream-api-key: "${local:amp}"
+  upstream-url: "https://ampcode.com/${unknown:kept}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if got := cfg.GeminiKey[0].APIKey; got != "gemini-secret" {
+		t.Fatalf("gemini key = %q", got)
+	}
+	if got := cfg.ClaudeKey[0].APIKey; got != "claude-secret" {
+		t.Fatalf("claude key = %q", got)
+	}
+	if got := cfg.CodexKey[0].APIKey; got != "vault-codex" {
+		t.Fatalf("codex key = %q", got)
+	}
+	if got := cfg.AmpCode.UpstreamAPIKey; got != "amp-secret" {
+		t.Fatalf("amp key = %q", got)
+	}
+	if got := cfg.AmpCode.UpstreamURL; got != "https://ampcode.com/${unknown:kept}" {
+		t.Fatalf("unknown scheme should be kept verbatim, got %q", got)
+	}
+	if !looksLikeBcrypt(cfg.RemoteManagement.SecretKey) {
+		t.Fatalf("secret key from a reference should be hashed in memory")
+	}
+
+	cfg.Port = 9000
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read saved config: %v", err)
+	}
+	for _, want := range []string{"port: 9000", "${env:TEST_GEMINI_KEY}", "${file:" + keyFile + "}", "${test-vault:codex}", "${local:amp}", "${env:TEST_MGMT_KEY}"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("saved config missing %q:\n%s", want, saved)
+		}
+	}
+	for _, leaked := range []string{"gemini-secret", "claude-secret", "vault-codex", "amp-secret", "$2a$"} {
+		if strings.Contains(string(saved), leaked) {
+			t.Errorf("saved config contains resolved value %q:\n%s", leaked, saved)
+		}
+	}
+}
+
+func TestLoadConfigFailsOnUnresolvableSecretRef(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "gemini-api-key:\n  - api-key: \"${env:TEST_MISSING_SECRET_VAR}\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "gemini-api-key[0].api-key") {
+		t.Fatalf("expected resolution error naming the key, got %v", err)
+	}
+
+	report := ValidateConfigYAML([]byte(content))
+	if !report.HasErrors() {
+		t.Fatalf("validator should report the unresolvable reference: %+v", report.Issues)
+	}
+}
+
+func TestValidateConfigYAMLDoesNotRevealSecretValues(t *testing.T) {
+	t.Setenv("TEST_PROXY_SECRET", "not a proxy url: hunter2")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("file-hunter2"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	content := `proxy-url: "${env:TEST_PROXY_SECRET}"
+claude-api-key:
+  - api-key: "k"
+    base-url: "${file:` + secretFile + `}"
+`
+	report := ValidateConfigYAML([]byte(content))
+	for _, issue := range report.Issues {
+		if strings.Contains(issue.Message, "hunter2") {
+			t.Fatalf("validation issue reveals a secret value: %s", issue)
+		}
+	}
+	if report.HasErrors() {
+		t.Fatalf("resolvable references should validate: %+v", report.Issues)
+	}
+}
+
+func TestSaveConfigRestoresSecretRefsByPath(t *testing.T) {
+	t.Setenv("TEST_SHARED_KEY", "shared-value")
+	t.Setenv("TEST_OTHER_KEY", "other-value")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `gemini-api-key:
+  - api-key: "${env:TEST_SHARED_KEY}"
+  - api-key: "${env:TEST_OTHER_KEY}"
+claude-api-key:
+  - api-key: "shared-value"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	// A value replaced after loading must be saved as is rather than as the old reference.
+	cfg.GeminiKey[1].APIKey = "rotated"
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	saved, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("reload config: %v", err)
+	}
+	raw, _ := os.ReadFile(configPath)
+	if got := saved.secretRefs["gemini-api-key[0].api-key"].text; got != "${env:TEST_SHARED_KEY}" {
+		t.Fatalf("gemini-api-key[0] reference not kept:\n%s", raw)
+	}
+	if saved.GeminiKey[1].APIKey != "rotated" {
+		t.Fatalf("gemini-api-key[1] = %q, want the new value:\n%s", saved.GeminiKey[1].APIKey, raw)
+	}
+	if _, fromRef := saved.secretRefs["claude-api-key[0].api-key"]; fromRef || saved.ClaudeKey[0].APIKey != "shared-value" {
+		t.Fatalf("unrelated field with the same value was rewritten:\n%s", raw)
+	}
+}
+
+func TestSaveConfigKeepsSecretRefsWhenListEntriesShift(t *testing.T) {
+	t.Setenv("TEST_SHIFTED_KEY", "sk-super-secret")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `gemini-api-key:
+  - api-key: "plain-key-a"
+  - api-key: "${env:TEST_SHIFTED_KEY}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	// Delete the first entry the way the management API does.
+	cfg.GeminiKey = append(cfg.GeminiKey[:0], cfg.GeminiKey[1:]...)
+	if err = SaveConfigPreserveComments(configPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	raw, _ := os.ReadFile(configPath)
+	if strings.Contains(string(raw), "sk-super-secret") || !strings.Contains(string(raw), "${env:TEST_SHIFTED_KEY}") {
+		t.Fatalf("reference not kept after deleting an earlier entry:\n%s", raw)
+	}
+	if strings.Contains(string(raw), "plain-key-a") {
+		t.Fatalf("deleted entry still saved:\n%s", raw)
+	}
+}
@@ -0,0 +1,751 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+// Validation issue severities.
+const (
+	// ValidationError marks a problem that prevents the setting from working as written.
+	ValidationError = "error"
+	// ValidationWarning marks a setting that is accepted but probably not what was intended.
+	ValidationWarning = "warning"
+)
+
+// ValidationIssue describes a single problem found in a configuration document.
+type ValidationIssue struct {
+	// Severity is ValidationError or ValidationWarning.
+	Severity string `json:"severity"`
+	// Path locates the offending key (e.g., "claude-api-key[0].base-url").
+	Path string `json:"path,omitempty"`
+	// Line is the 1-based line in the YAML document; 0 when unknown.
+	Line int `json:"line,omitempty"`
+	// Message explains the problem.
+	Message string `json:"message"`
+}
+
+// String formats the issue as "severity: path: message".
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	b.WriteString(i.Severity)
+	b.WriteString(": ")
+	if i.Path != "" {
+		b.WriteString(i.Path)
+		b.WriteString(": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidationReport collects the issues found while validating a configuration document.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// HasErrors reports whether the report contains at least one error.
+func (r *ValidationReport) HasErrors() bool {
+	if r == nil {
+		return false
+	}
+	for _, issue := range r.Issues {
+		if issue.Severity == ValidationError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfigFile reads configFile and validates it with ValidateConfigYAML.
+func ValidateConfigFile(configFile string) (*ValidationReport, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return ValidateConfigYAML(data), nil
+}
+
+var (
+	yamlLinePattern   = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	unknownKeyPattern = regexp.MustCompile(`^field (.+) not found in type (\S+)$`)
+	regexMetaPattern  = regexp.MustCompile(`\.\*|\.\+|[\^$\[\]{}|\\?]`)
+	payloadProtocols  = []string{"openai", "gemini", "claude", "codex", "antigravity"}
+	legacyConfigPaths = map[string]string{
+		"generative-language-api-key":          "gemini-api-key",
+		"amp-upstream-url":                     "ampcode.upstream-url",
+		"amp-upstream-api-key":                 "ampcode.upstream-api-key",
+		"amp-restrict-management-to-localhost": "ampcode.restrict-management-to-localhost",
+		"amp-model-mappings":                   "ampcode.model-mappings",
+		"openai-compatibility[].api-keys":      "openai-compatibility[].api-key-entries",
+	}
+)
+
+// ValidateConfigYAML strictly decodes data into Config and checks it for mistakes that the
+// regular loader silently tolerates: unknown keys, malformed proxy and base URLs, invalid
+// model prefixes, duplicate prefixes and overlapping aliases, invalid Amp regex mappings,
+// regex syntax in wildcard-only model patterns and payload rules that can never apply.
+func ValidateConfigYAML(data []byte) *ValidationReport {
+	v := &configValidator{report: &ValidationReport{Issues: []ValidationIssue{}}}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		v.addYAMLError(err)
+		return v.report
+	}
+	if len(root.Content) == 0 {
+		return v.report
+	}
+	v.indexNode(root.Content[0], "")
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			v.addYAMLError(err)
+			return v.report
+		}
+		for _, msg := range typeErr.Errors {
+			v.addDecodeError(msg)
+		}
+	}
+
+	v.checkConfig(&cfg)
+	sort.SliceStable(v.report.Issues, func(i, j int) bool {
+		return v.report.Issues[i].Line < v.report.Issues[j].Line
+	})
+	return v.report
+}
+
+type configValidator struct {
+	report *ValidationReport
+	// lines maps key paths to the line of the key (or sequence item) in the document.
+	lines map[string]int
+	// keysByLine lists key paths declared on each line, used to locate decoder errors.
+	keysByLine map[int][]string
+}
+
+func (v *configValidator) add(severity, path, format string, args ...any) {
+	v.report.Issues = append(v.report.Issues, ValidationIssue{
+		Severity: severity,
+		Path:     path,
+		Line:     v.lineOf(path),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lineOf returns the line of path or of its closest indexed ancestor.
+func (v *configValidator) lineOf(path string) int {
+	for path != "" {
+		if line, ok := v.lines[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut <= 0 {
+			break
+		}
+		path = path[:cut]
+	}
+	return 0
+}
+
+func (v *configValidator) indexNode(node *yaml.Node, path string) {
+	if v.lines == nil {
+		v.lines = make(map[string]int)
+		v.keysByLine = make(map[int][]string)
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			v.lines[child] = node.Content[i].Line
+			v.keysByLine[node.Content[i].Line] = append(v.keysByLine[node.Content[i].Line], child)
+			v.indexNode(node.Content[i+1], child)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			v.lines[child] = item.Line
+			v.indexNode(item, child)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			v.indexNode(node.Alias, path)
+		}
+	}
+}
+
+func (v *configValidator) addYAMLError(err error) {
+	issue := ValidationIssue{Severity: ValidationError, Message: err.Error()}
+	if m := yamlLinePattern.FindStringSubmatch(strings.TrimSpace(err.Error())); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Message = m[2]
+	}
+	v.report.Issues = append(v.report.Issues, issue)
+}
+
+func (v *configValidator) addDecodeError(msg string) {
+	m := yamlLinePattern.FindStringSubmatch(msg)
+	if m == nil {
+		v.report.Issues = append(v.report.Issues, ValidationIssue{Severity: ValidationError, Message: msg})
+		return
+	}
+	line, _ := strconv.Atoi(m[1])
+	issue := ValidationIssue{Severity: ValidationError, Line: line, Message: m[2]}
+	if km := unknownKeyPattern.FindStringSubmatch(m[2]); km != nil {
+		key := km[1]
+		for _, candidate := range v.keysByLine[line] {
+			if candidate == key || strings.HasSuffix(candidate, "."+key) {
+				issue.Path = candidate
+				break
+			}
+		}
+		issue.Message = fmt.Sprintf("unknown key %q (not a field of %s)", key, km[2])
+		if replacement, ok := legacyConfigPaths[stripIndexes(issue.Path)]; ok {
+			issue.Severity = ValidationWarning
+			issue.Message = fmt.Sprintf("deprecated key %q is migrated to %q on load", key, replacement)
+		}
+	} else if keys := v.keysByLine[line]; len(keys) == 1 {
+		issue.Path = keys[0]
+	}
+	v.report.Issues = append(v.report.Issues, issue)
+}
+
+// stripIndexes turns "a[0].b[12]" into "a[].b[]".
+func stripIndexes(path string) string {
+	var b strings.Builder
+	skip := false
+	for _, r := range path {
+		switch {
+		case r == '[':
+			skip = true
+			b.WriteString("[]")
+		case r == ']':
+			skip = false
+		case !skip:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// prefixOwner records where a model prefix was declared.
+type prefixOwner struct {
+	provider string
+	path     string
+}
+
+func (v *configValidator) checkConfig(cfg *Config) {
+	v.checkSecretRefs(cfg)
+	v.checkProxyURL("proxy-url", cfg.ProxyURL)
+	v.checkManagementKeys(cfg.RemoteManagement.Keys)
+	v.checkNotifications(cfg.Notifications)
+	v.checkPricing(cfg.Pricing)
+	prefixes := make(map[string][]prefixOwner)
+	notePrefix := func(provider, path, prefix string) {
+		if strings.TrimSpace(prefix) == "" {
+			return
+		}
+		normalized := normalizeModelPrefix(prefix)
+		if normalized == "" {
+			v.add(ValidationError, path, "prefix %q must not contain '/' and is ignored", prefix)
+			return
+		}
+		prefixes[normalized] = append(prefixes[normalized], prefixOwner{provider: provider, path: path})
+	}
+
+	for i, key := range cfg.GeminiKey {
+		base := fmt.Sprintf("gemini-api-key[%d]", i)
+		v.checkAPIKey(base, key.APIKey)
+		v.checkBaseURL(base+".base-url", key.BaseURL, false)
+		v.checkProxyURL(base+".proxy-url", key.ProxyURL)
+		notePrefix("gemini-api-key", base+".prefix", key.Prefix)
+		v.checkAliases(base+".models", modelPairs(key.Models))
+		v.checkPatterns(base+".excluded-models", key.ExcludedModels)
+		v.checkSchedule(base+".schedule", key.Schedule)
+	}
+	for i, key := range cfg.CodexKey {
+		base := fmt.Sprintf("codex-api-key[%d]", i)
+		v.checkAPIKey(base, key.APIKey)
+		v.checkBaseURL(base+".base-url", key.BaseURL, true)
+		v.checkProxyURL(base+".proxy-url", key.ProxyURL)
+		notePrefix("codex-api-key", base+".prefix", key.Prefix)
+		v.checkAliases(base+".models", modelPairs(key.Models))
+		v.checkPatterns(base+".excluded-models", key.ExcludedModels)
+		v.checkSchedule(base+".schedule", key.Schedule)
+	}
+	for i, key := range cfg.ClaudeKey {
+		base := fmt.Sprintf("claude-api-key[%d]", i)
+		v.checkAPIKey(base, key.APIKey)
+		v.checkBaseURL(base+".base-url", key.BaseURL, false)
+		v.checkProxyURL(base+".proxy-url", key.ProxyURL)
+		notePrefix("claude-api-key", base+".prefix", key.Prefix)
+		v.checkAliases(base+".models", modelPairs(key.Models))
+		v.checkPatterns(base+".excluded-models", key.ExcludedModels)
+		v.checkSchedule(base+".schedule", key.Schedule)
+	}
+	for i, key := range cfg.VertexCompatAPIKey {
+		base := fmt.Sprintf("vertex-api-key[%d]", i)
+		v.checkAPIKey(base, key.APIKey)
+		v.checkBaseURL(base+".base-url", key.BaseURL, true)
+		v.checkProxyURL(base+".proxy-url", key.ProxyURL)
+		notePrefix("vertex-api-key", base+".prefix", key.Prefix)
+		v.checkAliases(base+".models", modelPairs(key.Models))
+		v.checkSchedule(base+".schedule", key.Schedule)
+	}
+	compatNames := make(map[string]string)
+	for i, compat := range cfg.OpenAICompatibility {
+		base := fmt.Sprintf("openai-compatibility[%d]", i)
+		name := strings.ToLower(strings.TrimSpace(compat.Name))
+		if name == "" {
+			v.add(ValidationError, base, "provider name is required")
+		} else if previous, dup := compatNames[name]; dup {
+			v.add(ValidationError, base+".name", "provider name %q is already used by %s", compat.Name, previous)
+		} else {
+			compatNames[name] = base
+		}
+		v.checkBaseURL(base+".base-url", compat.BaseURL, true)
+		for j, entry := range compat.APIKeyEntries {
+			entryBase := fmt.Sprintf("%s.api-key-entries[%d]", base, j)
+			v.checkProxyURL(entryBase+".proxy-url", entry.ProxyURL)
+			v.checkSchedule(entryBase+".schedule", entry.Schedule)
+		}
+		notePrefix("openai-compatibility "+compat.Name, base+".prefix", compat.Prefix)
+		v.checkAliases(base+".models", modelPairs(compat.Models))
+		v.checkPatterns(base+".discovery.include", compat.Discovery.Include)
+		v.checkPatterns(base+".discovery.exclude", compat.Discovery.Exclude)
+	}
+	v.checkPrefixes(prefixes)
+
+	for _, channel := range sortedKeys(cfg.OAuthExcludedModels) {
+		v.checkPatterns("oauth-excluded-models."+channel, cfg.OAuthExcludedModels[channel])
+	}
+	v.checkOAuthModelMappings(cfg.OAuthModelMappings)
+	v.checkBaseURL("ampcode.upstream-url", cfg.AmpCode.UpstreamURL, false)
+	v.checkAmpModelMappings(cfg.AmpCode.ModelMappings)
+	v.checkPayloadRules("payload.default", cfg.Payload.Default, false)
+	v.checkPayloadRules("payload.override", cfg.Payload.Override, true)
+}
+
+// checkSecretRefs reports ${scheme:ref} references that cannot be resolved. The references
+// are not substituted, so no secret value can appear in the report.
+func (v *configValidator) checkSecretRefs(cfg *Config) {
+	for _, errRef := range cfg.unresolvableSecretRefs() {
+		if errors.Is(errRef, ErrUnknownSecretScheme) {
+			v.add(ValidationWarning, errRef.Path, "%s has no registered secret resolver and is used verbatim", errRef.Ref)
+			continue
+		}
+		v.add(ValidationError, errRef.Path, "cannot resolve %s: %v", errRef.Ref, errRef.Err)
+	}
+}
+
+func (v *configValidator) checkManagementKeys(keys []ManagementKey) {
+	names := make(map[string]string)
+	for i, key := range keys {
+		base := fmt.Sprintf("remote-management.keys[%d]", i)
+		name := strings.TrimSpace(key.Name)
+		if name == "" {
+			v.add(ValidationError, base+".name", "management key name is required")
+		} else if previous, dup := names[name]; dup {
+			v.add(ValidationError, base+".name", "management key name %q is already used by %s", key.Name, previous)
+		} else {
+			names[name] = base
+		}
+		if strings.TrimSpace(key.Key) == "" {
+			v.add(ValidationError, base+".key", "key is empty; the entry never matches")
+		}
+		if len(key.Scopes) == 0 {
+			v.add(ValidationWarning, base+".scopes", "no scopes granted; every request with this key is rejected")
+		}
+		for j, scope := range key.Scopes {
+			if !containsString(ManagementScopes, scope) {
+				v.add(ValidationError, fmt.Sprintf("%s.scopes[%d]", base, j), "unknown scope %q (expected one of %s)", scope, strings.Join(ManagementScopes, ", "))
+			}
+		}
+	}
+}
+
+func (v *configValidator) checkNotifications(notifications NotificationsConfig) {
+	names := make(map[string]string)
+	for i, webhook := range notifications.Webhooks {
+		base := fmt.Sprintf("notifications.webhooks[%d]", i)
+		if name := strings.TrimSpace(webhook.Name); name != "" {
+			if previous, dup := names[name]; dup {
+				v.add(ValidationWarning, base+".name", "webhook name %q is already used by %s; test-fire by name reaches both", webhook.Name, previous)
+			} else {
+				names[name] = base
+			}
+		}
+		if strings.TrimSpace(webhook.URL) == "" {
+			v.add(ValidationError, base+".url", "url is required; the webhook is ignored without it")
+		} else {
+			v.checkBaseURL(base+".url", webhook.URL, false)
+		}
+		switch strings.ToLower(strings.TrimSpace(webhook.Format)) {
+		case "", "json", "slack", "discord":
+		default:
+			v.add(ValidationError, base+".format", "unsupported format %q (use json, slack or discord)", webhook.Format)
+		}
+		for j, event := range webhook.Events {
+			if !containsString(NotifyEvents, strings.ToLower(strings.TrimSpace(event))) {
+				v.add(ValidationError, fmt.Sprintf("%s.events[%d]", base, j), "unknown event %q (expected one of %s)", event, strings.Join(NotifyEvents, ", "))
+			}
+		}
+	}
+}
+
+func (v *configValidator) checkPricing(pricing PricingConfig) {
+	for i, price := range pricing.Models {
+		base := fmt.Sprintf("pricing.models[%d]", i)
+		if strings.TrimSpace(price.Model) == "" {
+			v.add(ValidationError, base+".model", "model is required; the entry is ignored")
+		}
+		if price.Input < 0 || price.Output < 0 || price.Reasoning < 0 || price.CachedInput < 0 {
+			v.add(ValidationError, base, "prices must not be negative")
+		}
+	}
+	names := make(map[string]string)
+	for i, budget := range pricing.Budgets {
+		base := fmt.Sprintf("pricing.budgets[%d]", i)
+		if name := strings.TrimSpace(budget.Name); name == "" {
+			v.add(ValidationWarning, base+".name", "budget has no name; it is reported as budget-%d", i)
+		} else if previous, dup := names[name]; dup {
+			v.add(ValidationWarning, base+".name", "budget name %q is already used by %s", budget.Name, previous)
+		} else {
+			names[name] = base
+		}
+		switch strings.ToLower(strings.TrimSpace(budget.Period)) {
+		case "", BudgetPeriodDaily, BudgetPeriodWeekly, BudgetPeriodMonthly, BudgetPeriodTotal:
+		default:
+			v.add(ValidationError, base+".period", "unknown period %q (use daily, weekly, monthly or total)", budget.Period)
+		}
+		if budget.Limit <= 0 {
+			v.add(ValidationError, base+".limit", "limit must be positive; the budget is ignored")
+		}
+		v.checkPatterns(base+".models", budget.Models)
+	}
+}
+
+func (v *configValidator) checkAPIKey(path, key string) {
+	if strings.TrimSpace(key) == "" {
+		v.add(ValidationError, path+".api-key", "api-key is empty; the entry is ignored")
+	}
+}
+
+func (v *configValidator) checkSchedule(path string, sched *CredentialSchedule) {
+	if sched == nil {
+		return
+	}
+	if _, err := schedule.Compile(sched.Timezone, sched.Mode, sched.Windows); err != nil {
+		v.add(ValidationError, path, "%v; the credential stays unavailable until this is fixed", err)
+	}
+}
+
+func (v *configValidator) checkProxyURL(path, raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	// The value is only known once its secret reference is resolved.
+	if secretRefPattern.MatchString(raw) {
+		return
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		v.add(ValidationError, path, "invalid proxy URL: %v", err)
+		return
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		v.add(ValidationError, path, "unsupported proxy scheme %q (use http, https or socks5)", parsed.Scheme)
+		return
+	}
+	if parsed.Host == "" {
+		v.add(ValidationError, path, "proxy URL %q has no host", raw)
+	}
+}
+
+func (v *configValidator) checkBaseURL(path, raw string, required bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if required {
+			v.add(ValidationError, path, "base-url is required; the entry is ignored without it")
+		}
+		return
+	}
+	// The value is only known once its secret reference is resolved.
+	if secretRefPattern.MatchString(raw) {
+		return
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		v.add(ValidationError, path, "invalid URL: %v", err)
+		return
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		v.add(ValidationError, path, "URL %q must start with http:// or https://", raw)
+		return
+	}
+	if parsed.Host == "" {
+		v.add(ValidationError, path, "URL %q has no host", raw)
+		return
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		v.add(ValidationWarning, path, "URL %q contains a query or fragment that breaks request path joining", raw)
+	}
+}
+
+func (v *configValidator) checkPrefixes(prefixes map[string][]prefixOwner) {
+	for _, prefix := range sortedKeys(prefixes) {
+		owners := prefixes[prefix]
+		first := owners[0]
+		for _, owner := range owners[1:] {
+			if owner.provider == first.provider {
+				continue
+			}
+			v.add(ValidationWarning, owner.path, "prefix %q is also used by %s (%s); %q models may resolve to either provider",
+				prefix, first.provider, first.path, prefix+"/")
+		}
+	}
+}
+
+// modelPair is the name/alias pair shared by all per-credential model entries.
+type modelPair struct {
+	name  string
+	alias string
+}
+
+func modelPairs[T interface {
+	GetName() string
+	GetAlias() string
+}](models []T) []modelPair {
+	out := make([]modelPair, 0, len(models))
+	for _, m := range models {
+		out = append(out, modelPair{name: strings.TrimSpace(m.GetName()), alias: strings.TrimSpace(m.GetAlias())})
+	}
+	return out
+}
+
+// checkAliases reports aliases that map to several upstream models or hide another upstream model.
+func (v *configValidator) checkAliases(path string, models []modelPair) {
+	aliases := make(map[string]int, len(models))
+	names := make(map[string]int, len(models))
+	for i, m := range models {
+		if m.name != "" {
+			if _, ok := names[strings.ToLower(m.name)]; !ok {
+				names[strings.ToLower(m.name)] = i
+			}
+		}
+	}
+	for i, m := range models {
+		entry := fmt.Sprintf("%s[%d]", path, i)
+		if m.name == "" {
+			v.add(ValidationWarning, entry+".name", "model entry has no name and is ignored")
+			continue
+		}
+		if m.alias == "" {
+			continue
+		}
+		key := strings.ToLower(m.alias)
+		if previous, dup := aliases[key]; dup && !strings.EqualFold(models[previous].name, m.name) {
+			v.add(ValidationWarning, entry+".alias", "alias %q overlaps %s[%d] which maps it to %q", m.alias, path, previous, models[previous].name)
+			continue
+		}
+		aliases[key] = i
+		if other, ok := names[key]; ok && other != i && !strings.EqualFold(m.name, m.alias) {
+			v.add(ValidationWarning, entry+".alias", "alias %q shadows upstream model %s[%d]", m.alias, path, other)
+		}
+	}
+}
+
+func (v *configValidator) checkOAuthModelMappings(mappings map[string][]ModelNameMapping) {
+	for _, channel := range sortedKeys(mappings) {
+		base := "oauth-model-mappings." + channel
+		seen := make(map[string]int)
+		for i, mapping := range mappings[channel] {
+			entry := fmt.Sprintf("%s[%d]", base, i)
+			name, alias := strings.TrimSpace(mapping.Name), strings.TrimSpace(mapping.Alias)
+			switch {
+			case name == "" || alias == "":
+				v.add(ValidationWarning, entry, "mapping needs both name and alias and is ignored")
+			case strings.EqualFold(name, alias):
+				v.add(ValidationWarning, entry, "alias equals name; the mapping is ignored")
+			default:
+				key := strings.ToLower(alias)
+				if previous, dup := seen[key]; dup {
+					v.add(ValidationWarning, entry+".alias", "alias %q overlaps %s[%d]; only the first mapping is used", alias, base, previous)
+					continue
+				}
+				seen[key] = i
+			}
+		}
+	}
+}
+
+func (v *configValidator) checkAmpModelMappings(mappings []AmpModelMapping) {
+	seen := make(map[string]int)
+	for i, mapping := range mappings {
+		entry := fmt.Sprintf("ampcode.model-mappings[%d]", i)
+		from, to := strings.TrimSpace(mapping.From), strings.TrimSpace(mapping.To)
+		if from == "" || to == "" {
+			v.add(ValidationWarning, entry, "mapping needs both from and to and is ignored")
+			continue
+		}
+		if mapping.Regex {
+			if _, err := regexp.Compile("(?i)" + from); err != nil {
+				v.add(ValidationError, entry+".from", "invalid regular expression: %v", err)
+			}
+			continue
+		}
+		key := strings.ToLower(from)
+		if previous, dup := seen[key]; dup {
+			v.add(ValidationWarning, entry+".from", "model %q is already mapped by ampcode.model-mappings[%d]", from, previous)
+			continue
+		}
+		seen[key] = i
+		if regexMetaPattern.MatchString(from) {
+			v.add(ValidationWarning, entry+".from", "%q looks like a regular expression but regex is false; it is matched exactly", from)
+		}
+	}
+}
+
+// checkPatterns flags regex syntax in model patterns that only support '*' wildcards.
+func (v *configValidator) checkPatterns(path string, patterns []string) {
+	for i, pattern := range patterns {
+		if regexMetaPattern.MatchString(pattern) {
+			v.add(ValidationWarning, fmt.Sprintf("%s[%d]", path, i),
+				"pattern %q contains regular expression syntax; only '*' wildcards are supported and other characters match literally", pattern)
+		}
+	}
+}
+
+// checkPayloadRules reports payload rules that can never change a request. Default rules are
+// applied first-write-wins, so a later default is unreachable when an earlier one covers all of its
+// models and parameters; override rules are last-write-wins, so the covering rule comes later.
+func (v *configValidator) checkPayloadRules(path string, rules []PayloadRule, override bool) {
+	for i, rule := range rules {
+		entry := fmt.Sprintf("%s[%d]", path, i)
+		if len(rule.Params) == 0 {
+			v.add(ValidationWarning, entry+".params", "rule has no params and never changes a payload")
+		}
+		live := 0
+		for j, model := range rule.Models {
+			modelPath := fmt.Sprintf("%s.models[%d]", entry, j)
+			name := strings.TrimSpace(model.Name)
+			protocol := strings.ToLower(strings.TrimSpace(model.Protocol))
+			switch {
+			case name == "":
+				v.add(ValidationWarning, modelPath+".name", "model entry has no name and never matches")
+			case protocol != "" && !containsString(payloadProtocols, protocol):
+				v.add(ValidationWarning, modelPath+".protocol", "unknown protocol %q never matches (use %s)", model.Protocol, strings.Join(payloadProtocols, ", "))
+			default:
+				live++
+				v.checkPatterns(modelPath+".name", []string{name})
+			}
+		}
+		if live == 0 {
+			v.add(ValidationWarning, entry+".models", "rule has no model that can match and never applies")
+			continue
+		}
+		if len(rule.Params) == 0 {
+			continue
+		}
+		for k, other := range rules {
+			shadowing := (!override && k < i) || (override && k > i)
+			if !shadowing || !payloadRuleCovers(other, rule) {
+				continue
+			}
+			v.add(ValidationWarning, entry, "rule is unreachable: %s[%d] sets the same params for every model it matches", path, k)
+			break
+		}
+	}
+}
+
+// payloadRuleCovers reports whether every model and param of inner is also matched and written by outer.
+func payloadRuleCovers(outer, inner PayloadRule) bool {
+	for param := range inner.Params {
+		if _, ok := outer.Params[param]; !ok {
+			return false
+		}
+	}
+	for _, model := range inner.Models {
+		name := strings.TrimSpace(model.Name)
+		if name == "" {
+			continue
+		}
+		covered := false
+		for _, candidate := range outer.Models {
+			pattern := strings.TrimSpace(candidate.Name)
+			if pattern == "" {
+				continue
+			}
+			if cp := strings.TrimSpace(candidate.Protocol); cp != "" && !strings.EqualFold(cp, strings.TrimSpace(model.Protocol)) {
+				continue
+			}
+			if pattern == "*" || pattern == name || (!strings.Contains(name, "*") && matchWildcardPattern(pattern, name)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWildcardPattern matches value against a pattern where '*' matches any substring.
+func matchWildcardPattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func findIssue(report *ValidationReport, path string) *ValidationIssue {
+	for i := range report.Issues {
+		if report.Issues[i].Path == path {
+			return &report.Issues[i]
+		}
+	}
+	return nil
+}
+
+func TestValidateConfigYAML_UnknownAndDeprecatedKeys(t *testing.T) {
+	data := []byte(`port: 8317
+reqeust-log: true
+amp-upstream-url: "https://ampcode.com"
+claude-api-key:
+  - api-key: "k"
+    base-urll: "https://api.anthropic.com"
+`)
+	report := ValidateConfigYAML(data)
+	if !report.HasErrors() {
+		t.Fatalf("expected errors, got %+v", report.Issues)
+	}
+	issue := findIssue(report, "reqeust-log")
+	if issue == nil || issue.Severity != ValidationError || issue.Line != 2 {
+		t.Fatalf("unknown top-level key not reported correctly: %+v", issue)
+	}
+	issue = findIssue(report, "claude-api-key[0].base-urll")
+	if issue == nil || issue.Line != 6 {
+		t.Fatalf("unknown nested key not reported correctly: %+v", issue)
+	}
+	issue = findIssue(report, "amp-upstream-url")
+	if issue == nil || issue.Severity != ValidationWarning {
+		t.Fatalf("legacy key should be a warning: %+v", issue)
+	}
+}
+
+func TestValidateConfigYAML_SemanticChecks(t *testing.T) {
+	data := []byte(`proxy-url: "ftp://proxy"
+codex-api-key:
+  - api-key: "k"
+    prefix: "team"
+claude-api-key:
+  - api-key: "k"
+    base-url: "api.anthropic.com"
+    prefix: "team"
+    models:
+      - name: "claude-a"
+        alias: "fast"
+      - name: "claude-b"
+        alias: "fast"
+    excluded-models: ["claude-.*"]
+ampcode:
+  model-mappings:
+    - from: "claude-(["
+      to: "claude-b"
+      regex: true
+`)
+	report := ValidateConfigYAML(data)
+	cases := map[string]string{
+		"proxy-url":                            ValidationError,
+		"codex-api-key[0].base-url":            ValidationError,
+		"claude-api-key[0].base-url":           ValidationError,
+		"claude-api-key[0].prefix":             ValidationWarning,
+		"claude-api-key[0].models[1].alias":    ValidationWarning,
+		"claude-api-key[0].excluded-models[0]": ValidationWarning,
+		"ampcode.model-mappings[0].from":       ValidationError,
+	}
+	for path, severity := range cases {
+		issue := findIssue(report, path)
+		if issue == nil {
+			t.Errorf("missing issue for %s; got %+v", path, report.Issues)
+			continue
+		}
+		if issue.Severity != severity {
+			t.Errorf("%s: severity = %s, want %s", path, issue.Severity, severity)
+		}
+		if issue.Line == 0 {
+			t.Errorf("%s: missing line number", path)
+		}
+	}
+}
+
+func TestValidateConfigYAML_UnreachablePayloadRules(t *testing.T) {
+	data := []byte(`payload:
+  default:
+    - models: [{name: "gpt-*"}]
+      params: {"reasoning.effort": "high", "temperature": 1}
+    - models: [{name: "gpt-5", protocol: "codex"}]
+      params: {"temperature": 0.5}
+    - models: [{name: "gpt-5", protocol: "responses"}]
+      params: {"top_p": 1}
+  override:
+    - models: [{name: "gemini-2.5-pro"}]
+      params: {"generationConfig.temperature": 0}
+    - models: [{name: "gemini-*"}]
+      params: {"generationConfig.temperature": 1}
+    - models: []
+      params: {"x": 1}
+`)
+	report := ValidateConfigYAML(data)
+	for _, path := range []string{
+		"payload.default[1]",
+		"payload.default[2].models[0].protocol",
+		"payload.override[0]",
+		"payload.override[2].models",
+	} {
+		if findIssue(report, path) == nil {
+			t.Errorf("missing issue for %s; got %+v", path, report.Issues)
+		}
+	}
+	if issue := findIssue(report, "payload.default[0]"); issue != nil {
+		t.Errorf("first default rule reported as unreachable: %+v", issue)
+	}
+}
+
+func TestValidateConfigYAML_ExampleConfigIsValid(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "config.example.yaml"))
+	if err != nil {
+		t.Fatalf("read example config: %v", err)
+	}
+	report := ValidateConfigYAML(data)
+	if report.HasErrors() {
+		t.Fatalf("example config has errors: %+v", report.Issues)
+	}
+}
+
+func TestJSONSchemaMatchesPublishedFile(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema: %v", err)
+	}
+	published, err := os.ReadFile(filepath.Join("..", "..", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("read published schema: %v", err)
+	}
+	if string(published) != string(schema) {
+		t.Fatal("config.schema.json is out of date; regenerate it with `go run ./cmd/server -config-schema config.schema.json`")
+	}
+	for _, key := range []string{`"claude-api-key"`, `"payload"`, `"additionalProperties": false`, `"RequestLog enables`} {
+		if !strings.Contains(string(schema), key) {
+			t.Errorf("schema missing %s", key)
+		}
+	}
+}
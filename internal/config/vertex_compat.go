@@ -30,6 +30,9 @@ type VertexCompatKey struct {
 
 	// Models defines the model configurations including aliases for routing.
 	Models []VertexCompatModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Schedule restricts when this key may be selected.
+	Schedule *CredentialSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // VertexCompatModel represents a model configuration for Vertex compatibility,
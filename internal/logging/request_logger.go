@@ -14,6 +14,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -329,6 +330,7 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 
 	// Start async writer goroutine
 	go writer.asyncWriter()
+	openStreamingWriters.Store(writer, struct{}{})
 
 	return writer, nil
 }
@@ -974,6 +976,31 @@ type FileStreamingLogWriter struct {
 
 	// apiResponse stores the upstream API response data.
 	apiResponse []byte
+
+	// mu guards closed against writes racing a shutdown flush.
+	mu        sync.Mutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// openStreamingWriters tracks streaming logs that have not been finalized yet.
+var openStreamingWriters sync.Map
+
+// CloseStreamingLogWriters finalizes every streaming log still open, so partially streamed
+// responses are written out when the server shuts down. It returns the number of logs closed.
+func CloseStreamingLogWriters() int {
+	closed := 0
+	openStreamingWriters.Range(func(key, _ any) bool {
+		if writer, ok := key.(*FileStreamingLogWriter); ok {
+			if errClose := writer.Close(); errClose != nil {
+				log.WithError(errClose).Warn("failed to flush streaming request log")
+			}
+			closed++
+		}
+		return true
+	})
+	return closed
 }
 
 // WriteChunkAsync writes a response chunk asynchronously (non-blocking).
@@ -981,7 +1008,9 @@ type FileStreamingLogWriter struct {
 // Parameters:
 //   - chunk: The response chunk to write
 func (w *FileStreamingLogWriter) WriteChunkAsync(chunk []byte) {
-	if w.chunkChan == nil {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.chunkChan == nil {
 		return
 	}
 
@@ -1009,6 +1038,11 @@ func (w *FileStreamingLogWriter) WriteStatus(status int, headers map[string][]st
 	if status == 0 {
 		return nil
 	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
 
 	w.responseStatus = status
 	if headers != nil {
@@ -1034,6 +1068,11 @@ func (w *FileStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
 	if len(apiRequest) == 0 {
 		return nil
 	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
 	w.apiRequest = bytes.Clone(apiRequest)
 	return nil
 }
@@ -1049,6 +1088,11 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	if len(apiResponse) == 0 {
 		return nil
 	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
 	w.apiResponse = bytes.Clone(apiResponse)
 	return nil
 }
@@ -1057,12 +1101,25 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 // It writes all buffered data to the file in the correct order:
 // API REQUEST -> API RESPONSE -> RESPONSE (status, headers, body chunks)
 //
+// Close is idempotent, as shutdown may flush a log its request is still finishing.
+//
 // Returns:
 //   - error: An error if closing fails, nil otherwise
 func (w *FileStreamingLogWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.closeErr = w.close()
+		openStreamingWriters.Delete(w)
+	})
+	return w.closeErr
+}
+
+func (w *FileStreamingLogWriter) close() error {
+	w.mu.Lock()
+	w.closed = true
 	if w.chunkChan != nil {
 		close(w.chunkChan)
 	}
+	w.mu.Unlock()
 
 	// Wait for async writer to finish spooling chunks
 	if w.closeChan != nil {
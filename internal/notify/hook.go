@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// AuthHook turns core auth manager callbacks into notifications: credentials being disabled or
+// quarantined, failed refreshes, per-model cooldowns and models left without any available credential.
+type AuthHook struct {
+	coreauth.NoopHook
+
+	notifier *Notifier
+	manager  *coreauth.Manager
+
+	mu        sync.Mutex
+	disabled  map[string]bool
+	cooling   map[string]bool
+	modelDown map[string]bool
+}
+
+// NewAuthHook returns a hook reporting to notifier. manager is queried for the credential
+// state after each result.
+func NewAuthHook(notifier *Notifier, manager *coreauth.Manager) *AuthHook {
+	return &AuthHook{
+		notifier:  notifier,
+		manager:   manager,
+		disabled:  make(map[string]bool),
+		cooling:   make(map[string]bool),
+		modelDown: make(map[string]bool),
+	}
+}
+
+// OnAuthRegistered implements coreauth.Hook.
+func (h *AuthHook) OnAuthRegistered(_ context.Context, auth *coreauth.Auth) {
+	if auth == nil {
+		return
+	}
+	h.mu.Lock()
+	h.disabled[auth.ID] = auth.Disabled
+	h.mu.Unlock()
+}
+
+// OnAuthUpdated implements coreauth.Hook.
+func (h *AuthHook) OnAuthUpdated(_ context.Context, auth *coreauth.Auth) {
+	h.checkDisabled(auth)
+}
+
+// OnRefreshFailed implements coreauth.RefreshFailureHook.
+func (h *AuthHook) OnRefreshFailed(_ context.Context, auth *coreauth.Auth, err error) {
+	if auth == nil {
+		return
+	}
+	message := "credential refresh failed"
+	if err != nil {
+		message = fmt.Sprintf("credential refresh failed: %v", err)
+	}
+	h.notifier.Notify(authEvent(config.NotifyRefreshFailed, auth, "", message, nil))
+	h.checkDisabled(auth)
+}
+
+// OnResult implements coreauth.Hook.
+func (h *AuthHook) OnResult(_ context.Context, result coreauth.Result) {
+	if h.manager == nil || result.AuthID == "" || !h.notifier.Enabled() {
+		return
+	}
+	auth, ok := h.manager.GetByID(result.AuthID)
+	if !ok || auth == nil {
+		return
+	}
+	h.checkDisabled(auth)
+	if result.Model == "" || auth.Disabled {
+		return
+	}
+
+	now := time.Now()
+	state := auth.ModelStates[result.Model]
+	cooling := state != nil && state.Unavailable && state.NextRetryAfter.After(now)
+	key := auth.ID + "\x00" + result.Model
+	h.mu.Lock()
+	wasCooling := h.cooling[key]
+	if cooling {
+		h.cooling[key] = true
+	} else {
+		delete(h.cooling, key)
+	}
+	h.mu.Unlock()
+
+	switch {
+	case cooling && !wasCooling:
+		details := map[string]any{"until": state.NextRetryAfter.UTC()}
+		if result.Error != nil && result.Error.HTTPStatus > 0 {
+			details["status"] = result.Error.HTTPStatus
+		}
+		if state.Quota.Exceeded {
+			details["reason"] = state.Quota.Reason
+		}
+		message := fmt.Sprintf("credential cooling down for %s until %s", result.Model, state.NextRetryAfter.UTC().Format(time.RFC3339))
+		h.notifier.Notify(authEvent(config.NotifyCooldownEntered, auth, result.Model, message, details))
+	case !cooling && wasCooling && result.Success:
+		h.notifier.Notify(authEvent(config.NotifyCooldownCleared, auth, result.Model, "credential available again for "+result.Model, nil))
+	}
+	if cooling != wasCooling {
+		h.checkModel(auth.Provider, result.Model)
+	}
+}
+
+// checkModel reports a model once every credential of the provider serving it is cooling down.
+func (h *AuthHook) checkModel(provider, model string) {
+	total, available, next := h.manager.ModelAvailability(provider, model)
+	key := strings.ToLower(provider) + "/" + model
+	down := total > 0 && available == 0
+	h.mu.Lock()
+	wasDown := h.modelDown[key]
+	if down {
+		h.modelDown[key] = true
+	} else {
+		delete(h.modelDown, key)
+	}
+	h.mu.Unlock()
+	if !down || wasDown {
+		return
+	}
+	details := map[string]any{"credentials": total}
+	message := fmt.Sprintf("all %d %s credential(s) for %s are cooling down", total, provider, model)
+	if !next.IsZero() {
+		details["next_recover_at"] = next.UTC()
+		message += " until " + next.UTC().Format(time.RFC3339)
+	}
+	h.notifier.Notify(Event{
+		Type:     config.NotifyModelUnavailable,
+		Subject:  key,
+		Provider: provider,
+		Model:    model,
+		Message:  message,
+		Details:  details,
+	})
+}
+
+func (h *AuthHook) checkDisabled(auth *coreauth.Auth) {
+	if auth == nil {
+		return
+	}
+	disabled := auth.Disabled || auth.Status == coreauth.StatusDisabled
+	h.mu.Lock()
+	was := h.disabled[auth.ID]
+	h.disabled[auth.ID] = disabled
+	h.mu.Unlock()
+	if !disabled || was {
+		return
+	}
+	if quarantined, reason, since := coreauth.QuarantineInfo(auth); quarantined {
+		details := map[string]any{"reason": reason}
+		if !since.IsZero() {
+			details["since"] = since.UTC()
+		}
+		h.notifier.Notify(authEvent(config.NotifyCredentialQuarantined, auth, "", "credential quarantined: "+reason, details))
+		return
+	}
+	message := "credential disabled"
+	if reason := strings.TrimSpace(auth.StatusMessage); reason != "" {
+		message += ": " + reason
+	}
+	h.notifier.Notify(authEvent(config.NotifyCredentialDisabled, auth, "", message, nil))
+}
+
+func authEvent(eventType string, auth *coreauth.Auth, model, message string, details map[string]any) Event {
+	subject := auth.ID
+	if model != "" {
+		subject += "/" + model
+	}
+	return Event{
+		Type:     eventType,
+		Subject:  subject,
+		Provider: auth.Provider,
+		Model:    model,
+		AuthID:   auth.ID,
+		Label:    auth.Label,
+		Message:  message,
+		Details:  details,
+	}
+}
+
+var _ coreauth.RefreshFailureHook = (*AuthHook)(nil)
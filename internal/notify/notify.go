@@ -0,0 +1,375 @@
+// Package notify delivers credential and routing incidents, such as a revoked refresh token
+// or every credential of a model cooling down, to generic JSON and chat webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDedupWindow  = 5 * time.Minute
+	defaultMaxPerMinute = 30
+	defaultRetries      = 3
+	defaultTimeout      = 10 * time.Second
+	queueSize           = 256
+)
+
+// retryBackoff is the delay before the first retry; it doubles for each further attempt.
+var retryBackoff = time.Second
+
+// Event describes one incident.
+type Event struct {
+	// Type is one of the config.Notify* event types.
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Subject identifies what the event is about (credential ID, model or channel) and is
+	// used together with Type for deduplication.
+	Subject  string         `json:"subject"`
+	Provider string         `json:"provider,omitempty"`
+	Model    string         `json:"model,omitempty"`
+	AuthID   string         `json:"auth_id,omitempty"`
+	Label    string         `json:"label,omitempty"`
+	Message  string         `json:"message"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// DeliveryResult reports the outcome of delivering an event to one webhook.
+type DeliveryResult struct {
+	Webhook  string `json:"webhook"`
+	OK       bool   `json:"ok"`
+	Status   int    `json:"status,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Notifier fans events out to the configured webhooks in the background.
+type Notifier struct {
+	mu        sync.RWMutex
+	sinks     []*sink
+	client    *http.Client
+	queue     chan Event
+	startOnce sync.Once
+}
+
+type sink struct {
+	cfg          config.NotificationWebhook
+	events       map[string]bool
+	dedup        time.Duration
+	maxPerMinute int
+	retries      int
+	timeout      time.Duration
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+	sent     []time.Time
+}
+
+var defaultNotifier = New()
+
+// Default returns the process-wide notifier.
+func Default() *Notifier { return defaultNotifier }
+
+// Notify queues an event on the process-wide notifier.
+func Notify(event Event) { defaultNotifier.Notify(event) }
+
+// New returns a notifier without webhooks.
+func New() *Notifier {
+	return &Notifier{client: &http.Client{}, queue: make(chan Event, queueSize)}
+}
+
+// SetConfig replaces the webhooks with those in cfg. Deduplication and rate limit state is
+// kept for webhooks whose name and URL are unchanged.
+func (n *Notifier) SetConfig(cfg *config.Config) {
+	if n == nil {
+		return
+	}
+	var webhooks []config.NotificationWebhook
+	client := &http.Client{}
+	if cfg != nil {
+		webhooks = cfg.Notifications.Webhooks
+		client = util.SetProxy(&cfg.SDKConfig, client)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	previous := make(map[string]*sink, len(n.sinks))
+	for _, s := range n.sinks {
+		previous[s.cfg.Name+"\x00"+s.cfg.URL] = s
+	}
+	sinks := make([]*sink, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if strings.TrimSpace(webhook.URL) == "" {
+			continue
+		}
+		s := newSink(webhook)
+		if old, ok := previous[webhook.Name+"\x00"+webhook.URL]; ok {
+			old.mu.Lock()
+			s.lastSent, s.sent = old.lastSent, old.sent
+			old.mu.Unlock()
+		}
+		sinks = append(sinks, s)
+	}
+	n.sinks = sinks
+	n.client = client
+}
+
+func newSink(cfg config.NotificationWebhook) *sink {
+	s := &sink{
+		cfg:          cfg,
+		dedup:        defaultDedupWindow,
+		maxPerMinute: defaultMaxPerMinute,
+		retries:      defaultRetries,
+		timeout:      defaultTimeout,
+		lastSent:     make(map[string]time.Time),
+	}
+	if len(cfg.Events) > 0 {
+		s.events = make(map[string]bool, len(cfg.Events))
+		for _, event := range cfg.Events {
+			s.events[strings.ToLower(strings.TrimSpace(event))] = true
+		}
+	}
+	switch {
+	case cfg.DedupSeconds < 0:
+		s.dedup = 0
+	case cfg.DedupSeconds > 0:
+		s.dedup = time.Duration(cfg.DedupSeconds) * time.Second
+	}
+	switch {
+	case cfg.MaxPerMinute < 0:
+		s.maxPerMinute = 0
+	case cfg.MaxPerMinute > 0:
+		s.maxPerMinute = cfg.MaxPerMinute
+	}
+	switch {
+	case cfg.Retries < 0:
+		s.retries = 0
+	case cfg.Retries > 0:
+		s.retries = cfg.Retries
+	}
+	if cfg.TimeoutSeconds > 0 {
+		s.timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return s
+}
+
+// Enabled reports whether any webhook is configured.
+func (n *Notifier) Enabled() bool {
+	if n == nil {
+		return false
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.sinks) > 0
+}
+
+// Notify queues event for delivery. It never blocks; events are dropped when the queue is full.
+func (n *Notifier) Notify(event Event) {
+	if !n.Enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	n.startOnce.Do(func() { go n.run() })
+	select {
+	case n.queue <- event:
+	default:
+		log.Warnf("notify: queue full, dropping %s event for %s", event.Type, event.Subject)
+	}
+}
+
+func (n *Notifier) run() {
+	for event := range n.queue {
+		n.mu.RLock()
+		sinks := n.sinks
+		client := n.client
+		n.mu.RUnlock()
+		for _, s := range sinks {
+			if !s.accepts(event.Type) || !s.allow(event, time.Now()) {
+				continue
+			}
+			go func(s *sink) {
+				result := s.deliver(context.Background(), client, event)
+				if !result.OK {
+					log.Warnf("notify: delivering %s event to webhook %s failed after %d attempt(s): %s", event.Type, result.Webhook, result.Attempts, result.Error)
+				}
+			}(s)
+		}
+	}
+}
+
+// Test delivers a test event synchronously to every webhook, or only the one named webhook,
+// bypassing event filters, deduplication and rate limits.
+func (n *Notifier) Test(ctx context.Context, webhook string) []DeliveryResult {
+	results := []DeliveryResult{}
+	if n == nil {
+		return results
+	}
+	n.mu.RLock()
+	sinks := n.sinks
+	client := n.client
+	n.mu.RUnlock()
+	event := Event{
+		Type:    config.NotifyTest,
+		Time:    time.Now().UTC(),
+		Subject: "test",
+		Message: "Test notification from CLIProxyAPI",
+	}
+	for _, s := range sinks {
+		if webhook != "" && s.cfg.Name != webhook {
+			continue
+		}
+		results = append(results, s.deliver(ctx, client, event))
+	}
+	return results
+}
+
+func (s *sink) accepts(eventType string) bool {
+	return s.events == nil || s.events[eventType]
+}
+
+// allow applies deduplication and the per-minute rate limit, recording the delivery.
+func (s *sink) allow(event Event, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := event.Type + "\x00" + event.Subject
+	if s.dedup > 0 {
+		if last, ok := s.lastSent[key]; ok && now.Sub(last) < s.dedup {
+			return false
+		}
+	}
+	if s.maxPerMinute > 0 {
+		cutoff := now.Add(-time.Minute)
+		kept := s.sent[:0]
+		for _, sentAt := range s.sent {
+			if sentAt.After(cutoff) {
+				kept = append(kept, sentAt)
+			}
+		}
+		s.sent = kept
+		if len(s.sent) >= s.maxPerMinute {
+			log.Warnf("notify: webhook %s rate limit reached, dropping %s event for %s", s.cfg.Name, event.Type, event.Subject)
+			return false
+		}
+		s.sent = append(s.sent, now)
+	}
+	if s.dedup > 0 {
+		for k, last := range s.lastSent {
+			if now.Sub(last) >= s.dedup {
+				delete(s.lastSent, k)
+			}
+		}
+		s.lastSent[key] = now
+	}
+	return true
+}
+
+// deliver posts event, retrying network errors, 429 and 5xx responses with exponential backoff.
+func (s *sink) deliver(ctx context.Context, client *http.Client, event Event) DeliveryResult {
+	result := DeliveryResult{Webhook: s.cfg.Name}
+	body, err := s.render(event)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	backoff := retryBackoff
+	for attempt := 0; attempt <= s.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return result
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		result.Attempts++
+		status, retry, errSend := s.send(ctx, client, body)
+		result.Status = status
+		if errSend == nil {
+			result.OK = true
+			result.Error = ""
+			return result
+		}
+		result.Error = errSend.Error()
+		if !retry {
+			break
+		}
+	}
+	return result
+}
+
+func (s *sink) send(ctx context.Context, client *http.Client, body []byte) (status int, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CLIProxyAPI-notify")
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Debugf("notify: close response body: %v", errClose)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return resp.StatusCode, retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+func (s *sink) render(event Event) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(s.cfg.Format)) {
+	case "", "json":
+		return json.Marshal(event)
+	case "slack":
+		return json.Marshal(map[string]string{"text": chatText(event)})
+	case "discord":
+		return json.Marshal(map[string]string{"content": chatText(event)})
+	default:
+		return nil, fmt.Errorf("unsupported webhook format %q", s.cfg.Format)
+	}
+}
+
+// chatText renders event as a single chat message.
+func chatText(event Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[CLIProxyAPI] %s: %s", event.Type, event.Message)
+	var parts []string
+	if event.Provider != "" {
+		parts = append(parts, "provider="+event.Provider)
+	}
+	if event.Model != "" {
+		parts = append(parts, "model="+event.Model)
+	}
+	if event.Label != "" {
+		parts = append(parts, "credential="+event.Label)
+	} else if event.AuthID != "" {
+		parts = append(parts, "credential="+event.AuthID)
+	}
+	if len(parts) > 0 {
+		b.WriteString(" (" + strings.Join(parts, ", ") + ")")
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+	fail   int
+}
+
+func (r *recorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.fail > 0 {
+			r.fail--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		data, _ := io.ReadAll(req.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("webhook body is not JSON: %s", data)
+		}
+		r.bodies = append(r.bodies, body)
+	}
+}
+
+func (r *recorder) wait(t *testing.T, n int) []map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		if len(r.bodies) >= n {
+			out := append([]map[string]any(nil), r.bodies...)
+			r.mu.Unlock()
+			return out
+		}
+		r.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.Fatalf("received %d webhook calls, want %d", len(r.bodies), n)
+	return nil
+}
+
+func TestNotifierFiltersDedupsAndRetries(t *testing.T) {
+	retryBackoff = time.Millisecond
+	jsonHook, chatHook := &recorder{fail: 1}, &recorder{}
+	jsonServer := httptest.NewServer(jsonHook.handler(t))
+	defer jsonServer.Close()
+	chatServer := httptest.NewServer(chatHook.handler(t))
+	defer chatServer.Close()
+
+	n := New()
+	cfg := &config.Config{}
+	cfg.Notifications.Webhooks = []config.NotificationWebhook{
+		{Name: "bus", URL: jsonServer.URL, Events: []string{config.NotifyCredentialDisabled}},
+		{Name: "chat", URL: chatServer.URL, Format: "slack"},
+	}
+	n.SetConfig(cfg)
+
+	disabled := Event{Type: config.NotifyCredentialDisabled, Subject: "claude-1", Provider: "claude", Message: "credential disabled"}
+	n.Notify(disabled)
+	n.Notify(disabled) // duplicate within the dedup window
+	n.Notify(Event{Type: config.NotifyConfigReloaded, Subject: "config", Message: "configuration reloaded"})
+
+	bus := jsonHook.wait(t, 1)
+	if bus[0]["type"] != config.NotifyCredentialDisabled || bus[0]["subject"] != "claude-1" {
+		t.Fatalf("unexpected json payload: %v", bus[0])
+	}
+	chat := chatHook.wait(t, 2)
+	time.Sleep(50 * time.Millisecond)
+	if got := len(jsonHook.wait(t, 1)); got != 1 {
+		t.Fatalf("json webhook calls = %d, want 1 (filtered and deduplicated)", got)
+	}
+	if got := len(chatHook.wait(t, 2)); got != 2 {
+		t.Fatalf("chat webhook calls = %d, want 2", got)
+	}
+	for _, body := range chat {
+		if _, ok := body["text"].(string); !ok {
+			t.Fatalf("slack payload without text: %v", body)
+		}
+	}
+
+	results := n.Test(context.Background(), "chat")
+	if len(results) != 1 || !results[0].OK || results[0].Webhook != "chat" {
+		t.Fatalf("unexpected test results: %+v", results)
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	s := newSink(config.NotificationWebhook{Name: "x", URL: "http://example.invalid", DedupSeconds: -1, MaxPerMinute: 2})
+	now := time.Now()
+	for i, want := range []bool{true, true, false} {
+		if got := s.allow(Event{Type: config.NotifyTest, Subject: "s"}, now); got != want {
+			t.Fatalf("event %d allowed=%t, want %t", i, got, want)
+		}
+	}
+	if !s.allow(Event{Type: config.NotifyTest, Subject: "s"}, now.Add(61*time.Second)) {
+		t.Fatalf("rate limit should reset after a minute")
+	}
+}
+
+func TestAuthHookReportsCooldownAndModelUnavailable(t *testing.T) {
+	hook := &recorder{}
+	server := httptest.NewServer(hook.handler(t))
+	defer server.Close()
+	n := New()
+	cfg := &config.Config{}
+	cfg.Notifications.Webhooks = []config.NotificationWebhook{{Name: "bus", URL: server.URL}}
+	n.SetConfig(cfg)
+
+	manager := coreauth.NewManager(nil, &coreauth.RoundRobinSelector{}, nil)
+	manager.AddHook(NewAuthHook(n, manager))
+	ctx := context.Background()
+	if _, err := manager.Register(ctx, &coreauth.Auth{ID: "claude-1", Provider: "claude", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	retry := time.Minute
+	manager.MarkResult(ctx, coreauth.Result{
+		AuthID:     "claude-1",
+		Provider:   "claude",
+		Model:      "claude-sonnet-4",
+		RetryAfter: &retry,
+		Error:      &coreauth.Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+	})
+
+	bodies := hook.wait(t, 2)
+	types := map[string]bool{}
+	for _, body := range bodies {
+		types[body["type"].(string)] = true
+	}
+	if !types[config.NotifyCooldownEntered] || !types[config.NotifyModelUnavailable] {
+		t.Fatalf("expected cooldown-entered and model-unavailable, got %v", bodies)
+	}
+}
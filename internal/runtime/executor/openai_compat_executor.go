@@ -16,6 +16,7 @@ import (
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -325,6 +326,58 @@ func (e *OpenAICompatExecutor) Refresh(ctx context.Context, auth *cliproxyauth.A
 	return auth, nil
 }
 
+// FetchOpenAICompatModels lists the model IDs exposed by an OpenAI-compatible provider
+// via GET {base_url}/models using the auth's API key, custom headers and proxy settings.
+func FetchOpenAICompatModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) ([]string, error) {
+	exec := &OpenAICompatExecutor{cfg: cfg}
+	baseURL, _ := exec.resolveCredentials(auth)
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai compat executor: missing base url")
+	}
+	modelsURL := strings.TrimSuffix(baseURL, "/") + "/models"
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL, nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if errPrepare := exec.PrepareRequest(httpReq, auth); errPrepare != nil {
+		return nil, errPrepare
+	}
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 30*time.Second)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		return nil, errDo
+	}
+	bodyBytes, errRead := io.ReadAll(httpResp.Body)
+	if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Errorf("openai compat executor: close response body error: %v", errClose)
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(bodyBytes)}
+	}
+	data := gjson.GetBytes(bodyBytes, "data")
+	if !data.IsArray() {
+		return nil, fmt.Errorf("openai compat executor: models response missing data array")
+	}
+	seen := make(map[string]struct{})
+	models := make([]string, 0, len(data.Array()))
+	for _, item := range data.Array() {
+		id := strings.TrimSpace(item.Get("id").String())
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		models = append(models, id)
+	}
+	return models, nil
+}
+
 func (e *OpenAICompatExecutor) resolveCredentials(auth *cliproxyauth.Auth) (baseURL, apiKey string) {
 	if auth == nil {
 		return "", ""
@@ -172,6 +172,75 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	return out
 }
 
+// PayloadRuleMatch identifies a configured payload rule that applies to a model/protocol pair.
+type PayloadRuleMatch struct {
+	// Kind is either "default" or "override".
+	Kind string `json:"kind"`
+	// Index is the position of the rule within its list in the config.
+	Index int `json:"index"`
+	// Params holds the parameter paths and values the rule writes.
+	Params map[string]any `json:"params"`
+}
+
+// MatchingPayloadRules returns the payload rules from cfg that would apply to the given
+// model and protocol, in evaluation order (defaults first, then overrides).
+func MatchingPayloadRules(cfg *config.Config, model, protocol string) []PayloadRuleMatch {
+	if cfg == nil {
+		return nil
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil
+	}
+	var matches []PayloadRuleMatch
+	for i := range cfg.Payload.Default {
+		if payloadRuleMatchesModel(&cfg.Payload.Default[i], model, protocol) {
+			matches = append(matches, PayloadRuleMatch{Kind: "default", Index: i, Params: cfg.Payload.Default[i].Params})
+		}
+	}
+	for i := range cfg.Payload.Override {
+		if payloadRuleMatchesModel(&cfg.Payload.Override[i], model, protocol) {
+			matches = append(matches, PayloadRuleMatch{Kind: "override", Index: i, Params: cfg.Payload.Override[i].Params})
+		}
+	}
+	return matches
+}
+
+// ApplyPayloadConfig applies the configured payload rules to payload exactly as executors do.
+// It is exported for dry-run tooling that needs to preview the outbound body.
+func ApplyPayloadConfig(cfg *config.Config, model, protocol, root string, payload, original []byte) []byte {
+	return applyPayloadConfigWithRoot(cfg, model, protocol, root, payload, original)
+}
+
+// PayloadTarget describes the translator format and payload rule scope used by a provider executor.
+type PayloadTarget struct {
+	// Format is the translator format the executor converts requests into.
+	Format string `json:"format"`
+	// Protocol is the protocol name payload rules are matched against.
+	Protocol string `json:"protocol"`
+	// Root is the JSON path payload rule parameters are relative to.
+	Root string `json:"root,omitempty"`
+}
+
+// PayloadTargetForProvider returns the translation target used by the executor registered
+// for provider. Unknown providers are treated as OpenAI-compatible.
+func PayloadTargetForProvider(provider string) PayloadTarget {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "gemini", "vertex", "aistudio":
+		return PayloadTarget{Format: "gemini", Protocol: "gemini"}
+	case "gemini-cli":
+		return PayloadTarget{Format: "gemini-cli", Protocol: "gemini", Root: "request"}
+	case "antigravity":
+		return PayloadTarget{Format: "antigravity", Protocol: "antigravity", Root: "request"}
+	case "claude":
+		return PayloadTarget{Format: "claude", Protocol: "claude"}
+	case "codex":
+		return PayloadTarget{Format: "codex", Protocol: "codex"}
+	default:
+		return PayloadTarget{Format: "openai", Protocol: "openai"}
+	}
+}
+
 func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) bool {
 	if rule == nil {
 		return false
@@ -257,6 +326,11 @@ func matchModelPattern(pattern, model string) bool {
 	return pi == len(pattern)
 }
 
+// MatchModelPattern reports whether model matches a glob-style pattern supporting only '*'.
+func MatchModelPattern(pattern, model string) bool {
+	return matchModelPattern(pattern, model)
+}
+
 // NormalizeThinkingConfig normalizes thinking-related fields in the payload
 // based on model capabilities. For models without thinking support, it strips
 // reasoning fields. For models with level-based thinking, it validates and
@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Per-family image costs used by EstimateInputTokens. They follow the providers'
+// published upper bounds for a single image so estimates err on the high side.
+const (
+	claudeImageTokens  = 1600
+	geminiImageTokens  = 258
+	defaultImageTokens = 765
+)
+
+// claudeTokenRatio scales cl100k counts to Claude's tokenizer, which typically
+// produces slightly more tokens for the same text.
+const claudeTokenRatio = 1.1
+
+// skippedEstimateKeys are request fields that never reach the model as prompt text.
+var skippedEstimateKeys = map[string]struct{}{
+	"model":             {},
+	"metadata":          {},
+	"user":              {},
+	"safetySettings":    {},
+	"generationConfig":  {},
+	"project":           {},
+	"stream_options":    {},
+	"reasoning":         {},
+	"thinking":          {},
+	"signature":         {},
+	"thoughtSignature":  {},
+	"encrypted_content": {},
+}
+
+// EstimateInputTokens approximates the prompt size of a request in any supported client
+// format (OpenAI chat, Responses, Claude messages, Gemini contents). OpenAI-family models
+// are counted with their tiktoken encoding; Claude and Gemini use per-family approximations.
+func EstimateInputTokens(model string, payload []byte) (int64, error) {
+	var text strings.Builder
+	media := 0
+	collectPromptText(gjson.ParseBytes(payload), &text, &media)
+
+	family := tokenEstimateFamily(model)
+	var enc tokenizer.Codec
+	var err error
+	imageTokens := defaultImageTokens
+	switch family {
+	case "claude":
+		enc, err = tokenizer.Get(tokenizer.Cl100kBase)
+		imageTokens = claudeImageTokens
+	case "gemini":
+		enc, err = tokenizer.Get(tokenizer.O200kBase)
+		imageTokens = geminiImageTokens
+	default:
+		enc, err = tokenizerForModel(model)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	if text.Len() > 0 {
+		if count, err = enc.Count(text.String()); err != nil {
+			return 0, err
+		}
+	}
+	if family == "claude" {
+		count = int(math.Ceil(float64(count) * claudeTokenRatio))
+	}
+	return int64(count + media*imageTokens), nil
+}
+
+func tokenEstimateFamily(model string) string {
+	lower := strings.ToLower(model)
+	switch {
+	case strings.Contains(lower, "claude"):
+		return "claude"
+	case strings.Contains(lower, "gemini"), strings.Contains(lower, "gemma"):
+		return "gemini"
+	default:
+		return "openai"
+	}
+}
+
+// collectPromptText walks a request payload and gathers every string that would be
+// rendered into the prompt. Inline media is counted separately instead of tokenized.
+func collectPromptText(node gjson.Result, text *strings.Builder, media *int) {
+	switch {
+	case node.IsObject():
+		node.ForEach(func(key, value gjson.Result) bool {
+			if _, skip := skippedEstimateKeys[key.String()]; skip {
+				return true
+			}
+			collectPromptText(value, text, media)
+			return true
+		})
+	case node.IsArray():
+		node.ForEach(func(_, value gjson.Result) bool {
+			collectPromptText(value, text, media)
+			return true
+		})
+	case node.Type == gjson.String:
+		value := node.String()
+		if strings.HasPrefix(value, "data:") || looksLikeBase64(value) {
+			*media++
+			return
+		}
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			text.WriteString(trimmed)
+			text.WriteByte('\n')
+		}
+	}
+}
+
+// looksLikeBase64 reports whether value is a long run of base64 characters, as used for
+// inline images, audio and documents.
+func looksLikeBase64(value string) bool {
+	if len(value) < 1024 {
+		return false
+	}
+	for i := 0; i < 256; i++ {
+		c := value[i]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/' || c == '=' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
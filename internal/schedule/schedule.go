@@ -0,0 +1,244 @@
+// Package schedule evaluates credential availability windows written as five-field cron
+// expressions (minute hour day-of-month month day-of-week) in a time zone.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modes.
+const (
+	// ModeAllow makes a credential usable only inside its windows.
+	ModeAllow = "allow"
+	// ModeDeny makes a credential unusable inside its windows.
+	ModeDeny = "deny"
+)
+
+// searchHorizon bounds how far ahead NextChange looks for the next window boundary.
+const searchHorizon = 366 * 24 * time.Hour
+
+// Schedule is a compiled set of windows.
+type Schedule struct {
+	loc     *time.Location
+	deny    bool
+	windows []window
+}
+
+// window is one cron expression. Every minute it matches belongs to the window.
+type window struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+type fieldSpec struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = fieldSpec{name: "minute", min: 0, max: 59}
+	hourField   = fieldSpec{name: "hour", min: 0, max: 23}
+	domField    = fieldSpec{name: "day-of-month", min: 1, max: 31}
+	monthField  = fieldSpec{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = fieldSpec{name: "day-of-week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Compile parses windows for timezone (an IANA name; empty means UTC) and mode ("allow",
+// the default, or "deny").
+func Compile(timezone, mode string, windows []string) (*Schedule, error) {
+	s := &Schedule{loc: time.UTC}
+	if tz := strings.TrimSpace(timezone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		s.loc = loc
+	}
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ModeAllow:
+	case ModeDeny:
+		s.deny = true
+	default:
+		return nil, fmt.Errorf("invalid mode %q (use allow or deny)", mode)
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("at least one window is required")
+	}
+	for i, expr := range windows {
+		w, err := parseWindow(expr)
+		if err != nil {
+			return nil, fmt.Errorf("windows[%d]: %w", i, err)
+		}
+		s.windows = append(s.windows, w)
+	}
+	return s, nil
+}
+
+func parseWindow(expr string) (window, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return window{}, fmt.Errorf("%q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	var w window
+	var err error
+	if w.minute, err = parseField(fields[0], minuteField); err != nil {
+		return window{}, err
+	}
+	if w.hour, err = parseField(fields[1], hourField); err != nil {
+		return window{}, err
+	}
+	if w.dom, err = parseField(fields[2], domField); err != nil {
+		return window{}, err
+	}
+	if w.month, err = parseField(fields[3], monthField); err != nil {
+		return window{}, err
+	}
+	if w.dow, err = parseField(fields[4], dowField); err != nil {
+		return window{}, err
+	}
+	// Sunday may be written as 0 or 7.
+	if w.dow&(1<<7) != 0 {
+		w.dow |= 1
+	}
+	w.domAny = fields[2] == "*"
+	w.dowAny = fields[4] == "*"
+	return w, nil
+}
+
+// parseField parses a comma separated list of "*", values, ranges and /steps into a bitset.
+func parseField(field string, spec fieldSpec) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, spec.name)
+			}
+			step = n
+		}
+		lo, hi := spec.min, spec.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = spec.value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = spec.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = spec.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, spec.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f fieldSpec) value(raw string) (int, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if v, ok := f.names[raw]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s value %q (expected %d-%d)", f.name, raw, f.min, f.max)
+	}
+	return v, nil
+}
+
+func has(bits uint64, v int) bool { return bits&(1<<uint(v)) != 0 }
+
+func (w window) dayMatches(t time.Time) bool {
+	if !has(w.month, int(t.Month())) {
+		return false
+	}
+	dom, dow := has(w.dom, t.Day()), has(w.dow, int(t.Weekday()))
+	// As in cron, a restricted day-of-month and day-of-week match when either does.
+	if w.domAny || w.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (w window) matches(t time.Time) bool {
+	return w.dayMatches(t) && has(w.hour, t.Hour()) && has(w.minute, t.Minute())
+}
+
+// stableUntil returns a time after t (in the schedule location) before which w.matches does
+// not change, letting NextChange skip whole hours and days.
+func (w window) stableUntil(t time.Time) time.Time {
+	nextDay := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	nextHour := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	const allMinutes, allHours = 1<<60 - 1, 1<<24 - 1
+	switch {
+	case !w.dayMatches(t):
+		return nextDay
+	case !has(w.hour, t.Hour()):
+		return nextHour
+	case w.minute == allMinutes && w.hour == allHours:
+		return nextDay
+	case w.minute == allMinutes:
+		return nextHour
+	default:
+		return t.Add(time.Minute)
+	}
+}
+
+// Location returns the time zone the windows are evaluated in.
+func (s *Schedule) Location() *time.Location { return s.loc }
+
+// Open reports whether the schedule allows use at t.
+func (s *Schedule) Open(t time.Time) bool {
+	t = t.In(s.loc)
+	inside := false
+	for _, w := range s.windows {
+		if w.matches(t) {
+			inside = true
+			break
+		}
+	}
+	return inside != s.deny
+}
+
+// NextChange returns the first minute after t at which Open changes, or the zero time when it
+// does not change within a year.
+func (s *Schedule) NextChange(t time.Time) time.Time {
+	current := s.Open(t)
+	t = t.In(s.loc).Truncate(time.Minute)
+	limit := t.Add(searchHorizon)
+	for t.Before(limit) {
+		next := time.Time{}
+		for _, w := range s.windows {
+			if until := w.stableUntil(t); next.IsZero() || until.Before(next) {
+				next = until
+			}
+		}
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+		if s.Open(t) != current {
+			return t
+		}
+	}
+	return time.Time{}
+}
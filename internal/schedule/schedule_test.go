@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleOpenAndNextChange(t *testing.T) {
+	business, err := Compile("Europe/Berlin", "", []string{"* 9-17 * * mon-fri"})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	berlin := business.Location()
+
+	monday := time.Date(2026, time.March, 2, 10, 30, 15, 0, berlin)
+	if !business.Open(monday) {
+		t.Fatalf("expected open on Monday 10:30")
+	}
+	if got, want := business.NextChange(monday), time.Date(2026, time.March, 2, 18, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Fatalf("next change = %v, want %v", got, want)
+	}
+	friday := time.Date(2026, time.March, 6, 20, 0, 0, 0, berlin)
+	if business.Open(friday) {
+		t.Fatalf("expected closed on Friday evening")
+	}
+	if got, want := business.NextChange(friday), time.Date(2026, time.March, 9, 9, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Fatalf("next change = %v, want %v", got, want)
+	}
+
+	offHours, err := Compile("Europe/Berlin", ModeDeny, []string{"* 9-17 * * 1-5"})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if offHours.Open(monday) || !offHours.Open(friday) {
+		t.Fatalf("deny mode should invert the windows")
+	}
+
+	halfHours, err := Compile("", "", []string{"0-29 */2 * * *", "0 0 1 jan *"})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	at := time.Date(2026, time.May, 1, 2, 29, 0, 0, time.UTC)
+	if !halfHours.Open(at) || halfHours.Open(at.Add(time.Minute)) || halfHours.Open(at.Add(time.Hour)) {
+		t.Fatalf("unexpected minute/step evaluation")
+	}
+	if got, want := halfHours.NextChange(at.Add(time.Minute)), time.Date(2026, time.May, 1, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("next change = %v, want %v", got, want)
+	}
+
+	always, err := Compile("", "", []string{"* * * * *"})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if !always.NextChange(at).IsZero() {
+		t.Fatalf("an always-open schedule never changes")
+	}
+}
+
+func TestCompileRejectsInvalidSchedules(t *testing.T) {
+	cases := []struct {
+		timezone, mode string
+		windows        []string
+	}{
+		{"Mars/Olympus", "", []string{"* * * * *"}},
+		{"", "sometimes", []string{"* * * * *"}},
+		{"", "", nil},
+		{"", "", []string{"* * * *"}},
+		{"", "", []string{"* 25 * * *"}},
+		{"", "", []string{"* 9-5 * * *"}},
+		{"", "", []string{"*/0 * * * *"}},
+		{"", "", []string{"* * * * funday"}},
+	}
+	for _, tc := range cases {
+		if _, err := Compile(tc.timezone, tc.mode, tc.windows); err == nil {
+			t.Errorf("Compile(%q, %q, %q) succeeded, want error", tc.timezone, tc.mode, tc.windows)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestReadAuthFileRestoresDisabledAndQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"disabled.json":    `{"type":"claude","email":"a@example.com","disabled":true}`,
+		"quarantined.json": `{"type":"claude","email":"b@example.com","quarantined":true,"quarantine_reason":"invalid_grant","quarantined_at":"2026-01-02T03:04:05Z"}`,
+		"active.json":      `{"type":"claude","email":"c@example.com"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	want := map[string]cliproxyauth.Status{
+		"disabled.json":    cliproxyauth.StatusDisabled,
+		"quarantined.json": cliproxyauth.StatusQuarantined,
+		"active.json":      cliproxyauth.StatusActive,
+	}
+
+	readers := map[string]func(path, baseDir string) (*cliproxyauth.Auth, error){
+		"git":    (&GitTokenStore{}).readAuthFile,
+		"object": (&ObjectTokenStore{}).readAuthFile,
+	}
+	for storeName, read := range readers {
+		for name, status := range want {
+			auth, err := read(filepath.Join(dir, name), dir)
+			if err != nil {
+				t.Fatalf("%s store: read %s: %v", storeName, name, err)
+			}
+			if auth.Status != status || auth.Disabled != (status != cliproxyauth.StatusActive) {
+				t.Fatalf("%s store: %s status = %s disabled = %t, want %s", storeName, name, auth.Status, auth.Disabled, status)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConfigHistoryLimit is the number of config versions kept when no limit is configured.
+const DefaultConfigHistoryLimit = 50
+
+// ErrConfigVersionNotFound is returned when a requested config version does not exist.
+var ErrConfigVersionNotFound = errors.New("config version not found")
+
+// ConfigVersion describes one recorded revision of the configuration file.
+type ConfigVersion struct {
+	// ID identifies the version; IDs sort chronologically.
+	ID string `json:"id"`
+	// CreatedAt is when the version was recorded.
+	CreatedAt time.Time `json:"created_at"`
+	// Author is the management identity that made the change.
+	Author string `json:"author,omitempty"`
+	// ClientIP is the address the change was made from.
+	ClientIP string `json:"client_ip,omitempty"`
+	// Action is "baseline", "update" or "rollback".
+	Action string `json:"action"`
+	// RollbackOf names the version restored by a rollback.
+	RollbackOf string `json:"rollback_of,omitempty"`
+	// Changes is the redacted change list relative to the previous version.
+	Changes []string `json:"changes"`
+	// SHA256 is the hex digest of the stored content.
+	SHA256 string `json:"sha256"`
+}
+
+// ConfigHistory stores versioned copies of the configuration file.
+type ConfigHistory interface {
+	// RecordConfigVersion stores content as a new version, assigning ID, CreatedAt and SHA256,
+	// and prunes the oldest versions beyond keep (<= 0 uses DefaultConfigHistoryLimit).
+	RecordConfigVersion(ctx context.Context, version *ConfigVersion, content []byte, keep int) error
+	// ListConfigVersions returns recorded versions, newest first.
+	ListConfigVersions(ctx context.Context) ([]ConfigVersion, error)
+	// GetConfigVersion returns a version and its content.
+	GetConfigVersion(ctx context.Context, id string) (*ConfigVersion, []byte, error)
+}
+
+// prepareConfigVersion fills the generated fields of version for content.
+func prepareConfigVersion(version *ConfigVersion, content []byte) {
+	now := time.Now().UTC()
+	sum := sha256.Sum256(content)
+	version.SHA256 = hex.EncodeToString(sum[:])
+	if version.CreatedAt.IsZero() {
+		version.CreatedAt = now
+	}
+	if version.ID == "" {
+		version.ID = version.CreatedAt.UTC().Format("20060102T150405.000000000Z") + "-" + version.SHA256[:8]
+	}
+	if version.Action == "" {
+		version.Action = "update"
+	}
+	if version.Changes == nil {
+		version.Changes = []string{}
+	}
+}
+
+// validConfigVersionID rejects IDs that could escape the history directory or key prefix.
+func validConfigVersionID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+		default:
+			return false
+		}
+	}
+	return !strings.Contains(id, "..")
+}
+
+func historyLimit(keep int) int {
+	if keep <= 0 {
+		return DefaultConfigHistoryLimit
+	}
+	return keep
+}
+
+// FileConfigHistory keeps config versions as "<id>.yaml" and "<id>.json" files in a directory.
+type FileConfigHistory struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileConfigHistory returns a history stored under dir.
+func NewFileConfigHistory(dir string) *FileConfigHistory {
+	return &FileConfigHistory{dir: dir}
+}
+
+// Dir returns the directory holding the versions.
+func (h *FileConfigHistory) Dir() string { return h.dir }
+
+// RecordConfigVersion implements ConfigHistory.
+func (h *FileConfigHistory) RecordConfigVersion(_ context.Context, version *ConfigVersion, content []byte, keep int) error {
+	_, _, err := h.record(version, content, keep)
+	return err
+}
+
+// record writes a version and returns the files written and the files removed by pruning.
+func (h *FileConfigHistory) record(version *ConfigVersion, content []byte, keep int) (written, removed []string, err error) {
+	if version == nil {
+		return nil, nil, fmt.Errorf("config history: version is nil")
+	}
+	prepareConfigVersion(version, content)
+	meta, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("config history: encode version: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err = os.MkdirAll(h.dir, 0o700); err != nil {
+		return nil, nil, fmt.Errorf("config history: create directory: %w", err)
+	}
+	contentPath := filepath.Join(h.dir, version.ID+".yaml")
+	metaPath := filepath.Join(h.dir, version.ID+".json")
+	if err = os.WriteFile(contentPath, content, 0o600); err != nil {
+		return nil, nil, fmt.Errorf("config history: write content: %w", err)
+	}
+	if err = os.WriteFile(metaPath, meta, 0o600); err != nil {
+		return nil, nil, fmt.Errorf("config history: write metadata: %w", err)
+	}
+	written = []string{contentPath, metaPath}
+
+	ids, err := h.idsLocked()
+	if err != nil {
+		return written, nil, err
+	}
+	limit := historyLimit(keep)
+	for len(ids) > limit {
+		oldest := ids[0]
+		ids = ids[1:]
+		for _, path := range []string{filepath.Join(h.dir, oldest+".yaml"), filepath.Join(h.dir, oldest+".json")} {
+			if errRemove := os.Remove(path); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+				return written, removed, fmt.Errorf("config history: prune %s: %w", oldest, errRemove)
+			}
+			removed = append(removed, path)
+		}
+	}
+	return written, removed, nil
+}
+
+// idsLocked lists version IDs oldest first.
+func (h *FileConfigHistory) idsLocked() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("config history: read directory: %w", err)
+	}
+	ids := make([]string, 0, len(entries)/2)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// ListConfigVersions implements ConfigHistory.
+func (h *FileConfigHistory) ListConfigVersions(_ context.Context) ([]ConfigVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids, err := h.idsLocked()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]ConfigVersion, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		version, errRead := h.readMetaLocked(ids[i])
+		if errRead != nil {
+			continue
+		}
+		versions = append(versions, *version)
+	}
+	return versions, nil
+}
+
+// GetConfigVersion implements ConfigHistory.
+func (h *FileConfigHistory) GetConfigVersion(_ context.Context, id string) (*ConfigVersion, []byte, error) {
+	if !validConfigVersionID(id) {
+		return nil, nil, ErrConfigVersionNotFound
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	version, err := h.readMetaLocked(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(h.dir, id+".yaml"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrConfigVersionNotFound
+		}
+		return nil, nil, fmt.Errorf("config history: read content: %w", err)
+	}
+	return version, content, nil
+}
+
+func (h *FileConfigHistory) readMetaLocked(id string) (*ConfigVersion, error) {
+	data, err := os.ReadFile(filepath.Join(h.dir, id+".json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrConfigVersionNotFound
+		}
+		return nil, fmt.Errorf("config history: read metadata: %w", err)
+	}
+	var version ConfigVersion
+	if err = json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("config history: decode metadata: %w", err)
+	}
+	return &version, nil
+}
+
+var (
+	_ ConfigHistory = (*FileConfigHistory)(nil)
+	_ ConfigHistory = (*GitTokenStore)(nil)
+	_ ConfigHistory = (*ObjectTokenStore)(nil)
+	_ ConfigHistory = (*PostgresStore)(nil)
+)
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFileConfigHistoryPrunesOldestVersions(t *testing.T) {
+	ctx := context.Background()
+	history := NewFileConfigHistory(t.TempDir())
+	var ids []string
+	for i := 0; i < 4; i++ {
+		version := &ConfigVersion{Author: "tester"}
+		if err := history.RecordConfigVersion(ctx, version, []byte(fmt.Sprintf("port: %d\n", 8000+i)), 3); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		ids = append(ids, version.ID)
+	}
+
+	versions, err := history.ListConfigVersions(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(versions) != 3 || versions[0].ID != ids[3] || versions[2].ID != ids[1] {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if _, _, err = history.GetConfigVersion(ctx, ids[0]); !errors.Is(err, ErrConfigVersionNotFound) {
+		t.Fatalf("pruned version lookup err = %v", err)
+	}
+	version, content, err := history.GetConfigVersion(ctx, ids[3])
+	if err != nil || string(content) != "port: 8003\n" || version.Action != "update" {
+		t.Fatalf("get latest = %+v %q %v", version, content, err)
+	}
+	if _, _, err = history.GetConfigVersion(ctx, "../"+ids[3]); !errors.Is(err, ErrConfigVersionNotFound) {
+		t.Fatalf("path traversal lookup err = %v", err)
+	}
+}
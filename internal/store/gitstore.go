@@ -442,6 +442,7 @@ func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 	if email, ok := metadata["email"].(string); ok && email != "" {
 		auth.Attributes["email"] = email
 	}
+	restoreAuthState(auth)
 	return auth, nil
 }
 
@@ -676,6 +677,59 @@ func (s *GitTokenStore) PersistConfig(_ context.Context) error {
 	return s.commitAndPushLocked("Update config", rel)
 }
 
+// configHistory returns the version history kept next to the managed config in the repository.
+func (s *GitTokenStore) configHistory() (*FileConfigHistory, error) {
+	configPath := s.ConfigPath()
+	if configPath == "" {
+		return nil, fmt.Errorf("git token store: config path not configured")
+	}
+	return NewFileConfigHistory(filepath.Join(filepath.Dir(configPath), "history")), nil
+}
+
+// RecordConfigVersion writes a config version into the repository, then commits and pushes it.
+func (s *GitTokenStore) RecordConfigVersion(_ context.Context, version *ConfigVersion, content []byte, keep int) error {
+	if err := s.EnsureRepository(); err != nil {
+		return err
+	}
+	history, err := s.configHistory()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	written, removed, err := history.record(version, content, keep)
+	if err != nil {
+		return err
+	}
+	rels := make([]string, 0, len(written)+len(removed))
+	for _, path := range append(written, removed...) {
+		rel, errRel := s.relativeToRepo(path)
+		if errRel != nil {
+			return errRel
+		}
+		rels = append(rels, rel)
+	}
+	return s.commitAndPushLocked("Record config version "+version.ID, rels...)
+}
+
+// ListConfigVersions returns the config versions stored in the repository, newest first.
+func (s *GitTokenStore) ListConfigVersions(ctx context.Context) ([]ConfigVersion, error) {
+	history, err := s.configHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history.ListConfigVersions(ctx)
+}
+
+// GetConfigVersion returns a config version stored in the repository.
+func (s *GitTokenStore) GetConfigVersion(ctx context.Context, id string) (*ConfigVersion, []byte, error) {
+	history, err := s.configHistory()
+	if err != nil {
+		return nil, nil, err
+	}
+	return history.GetConfigVersion(ctx, id)
+}
+
 func ensureEmptyFile(path string) error {
 	if _, err := os.Stat(path); err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
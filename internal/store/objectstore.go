@@ -23,8 +23,9 @@ import (
 )
 
 const (
-	objectStoreConfigKey  = "config/config.yaml"
-	objectStoreAuthPrefix = "auths"
+	objectStoreConfigKey     = "config/config.yaml"
+	objectStoreHistoryPrefix = "config/history"
+	objectStoreAuthPrefix    = "auths"
 )
 
 // ObjectStoreConfig captures configuration for the object storage-backed token store.
@@ -48,6 +49,7 @@ type ObjectTokenStore struct {
 	spoolRoot  string
 	configPath string
 	authDir    string
+	history    *FileConfigHistory
 	mu         sync.Mutex
 }
 
@@ -115,6 +117,7 @@ func NewObjectTokenStore(cfg ObjectStoreConfig) (*ObjectTokenStore, error) {
 		spoolRoot:  absRoot,
 		configPath: filepath.Join(configDir, "config.yaml"),
 		authDir:    authDir,
+		history:    NewFileConfigHistory(filepath.Join(configDir, "history")),
 	}, nil
 }
 
@@ -149,6 +152,9 @@ func (s *ObjectTokenStore) Bootstrap(ctx context.Context, exampleConfigPath stri
 	if err := s.syncConfigFromBucket(ctx, exampleConfigPath); err != nil {
 		return err
 	}
+	if err := s.syncConfigHistoryFromBucket(ctx); err != nil {
+		return err
+	}
 	if err := s.syncAuthFromBucket(ctx); err != nil {
 		return err
 	}
@@ -324,6 +330,78 @@ func (s *ObjectTokenStore) PersistConfig(ctx context.Context) error {
 	return s.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
 }
 
+// RecordConfigVersion stores a config version in the local mirror and uploads it.
+func (s *ObjectTokenStore) RecordConfigVersion(ctx context.Context, version *ConfigVersion, content []byte, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	written, removed, err := s.history.record(version, content, keep)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return fmt.Errorf("object store: read config version: %w", errRead)
+		}
+		contentType := "application/json"
+		if strings.HasSuffix(path, ".yaml") {
+			contentType = "application/x-yaml"
+		}
+		if err = s.putObject(ctx, objectStoreHistoryPrefix+"/"+filepath.Base(path), data, contentType); err != nil {
+			return err
+		}
+	}
+	for _, path := range removed {
+		if err = s.deleteObject(ctx, objectStoreHistoryPrefix+"/"+filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListConfigVersions returns the mirrored config versions, newest first.
+func (s *ObjectTokenStore) ListConfigVersions(ctx context.Context) ([]ConfigVersion, error) {
+	return s.history.ListConfigVersions(ctx)
+}
+
+// GetConfigVersion returns a mirrored config version.
+func (s *ObjectTokenStore) GetConfigVersion(ctx context.Context, id string) (*ConfigVersion, []byte, error) {
+	return s.history.GetConfigVersion(ctx, id)
+}
+
+// syncConfigHistoryFromBucket downloads recorded config versions into the local mirror.
+func (s *ObjectTokenStore) syncConfigHistoryFromBucket(ctx context.Context) error {
+	dir := s.history.Dir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("object store: create config history directory: %w", err)
+	}
+	prefix := s.prefixedKey(objectStoreHistoryPrefix + "/")
+	objectCh := s.client.ListObjects(ctx, s.cfg.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+	for object := range objectCh {
+		if object.Err != nil {
+			return fmt.Errorf("object store: list config history: %w", object.Err)
+		}
+		name := strings.TrimPrefix(object.Key, prefix)
+		if name == "" || strings.ContainsAny(name, `/\`) || !validConfigVersionID(strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".yaml")) {
+			continue
+		}
+		reader, errGet := s.client.GetObject(ctx, s.cfg.Bucket, object.Key, minio.GetObjectOptions{})
+		if errGet != nil {
+			return fmt.Errorf("object store: download config version %s: %w", object.Key, errGet)
+		}
+		data, errRead := io.ReadAll(reader)
+		_ = reader.Close()
+		if errRead != nil {
+			return fmt.Errorf("object store: read config version %s: %w", object.Key, errRead)
+		}
+		if errWrite := os.WriteFile(filepath.Join(dir, name), data, 0o600); errWrite != nil {
+			return fmt.Errorf("object store: write config version %s: %w", name, errWrite)
+		}
+	}
+	return nil
+}
+
 func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
 	if err != nil {
@@ -594,6 +672,7 @@ func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Aut
 		LastRefreshedAt:  time.Time{},
 		NextRefreshAfter: time.Time{},
 	}
+	restoreAuthState(auth)
 	return auth, nil
 }
 
@@ -20,9 +20,10 @@ import (
 )
 
 const (
-	defaultConfigTable = "config_store"
-	defaultAuthTable   = "auth_store"
-	defaultConfigKey   = "config"
+	defaultConfigTable        = "config_store"
+	defaultAuthTable          = "auth_store"
+	defaultConfigHistoryTable = "config_history"
+	defaultConfigKey          = "config"
 )
 
 // PostgresStoreConfig captures configuration required to initialize a Postgres-backed store.
@@ -31,7 +32,9 @@ type PostgresStoreConfig struct {
 	Schema      string
 	ConfigTable string
 	AuthTable   string
-	SpoolDir    string
+	// HistoryTable stores config versions; empty uses "config_history".
+	HistoryTable string
+	SpoolDir     string
 }
 
 // PostgresStore persists configuration and authentication metadata using PostgreSQL as backend
@@ -58,6 +61,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.HistoryTable == "" {
+		cfg.HistoryTable = defaultConfigHistoryTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -140,6 +146,17 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	historyTable := s.fullTableName(s.cfg.HistoryTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			metadata JSONB NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, historyTable)); err != nil {
+		return fmt.Errorf("postgres store: create config history table: %w", err)
+	}
 	return nil
 }
 
@@ -310,6 +327,7 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 			LastRefreshedAt:  time.Time{},
 			NextRefreshAfter: time.Time{},
 		}
+		restoreAuthState(auth)
 		auths = append(auths, auth)
 	}
 	if err = rows.Err(); err != nil {
@@ -391,6 +409,72 @@ func (s *PostgresStore) PersistConfig(ctx context.Context) error {
 	return s.persistConfig(ctx, data)
 }
 
+// RecordConfigVersion stores a config version in PostgreSQL and prunes versions beyond keep.
+func (s *PostgresStore) RecordConfigVersion(ctx context.Context, version *ConfigVersion, content []byte, keep int) error {
+	if version == nil {
+		return fmt.Errorf("postgres store: config version is nil")
+	}
+	prepareConfigVersion(version, content)
+	meta, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("postgres store: encode config version: %w", err)
+	}
+	table := s.fullTableName(s.cfg.HistoryTable)
+	insert := fmt.Sprintf("INSERT INTO %s (id, metadata, content, created_at) VALUES ($1, $2, $3, $4)", table)
+	if _, err = s.db.ExecContext(ctx, insert, version.ID, string(meta), normalizeLineEndings(string(content)), version.CreatedAt); err != nil {
+		return fmt.Errorf("postgres store: insert config version: %w", err)
+	}
+	prune := fmt.Sprintf("DELETE FROM %[1]s WHERE id NOT IN (SELECT id FROM %[1]s ORDER BY id DESC LIMIT $1)", table)
+	if _, err = s.db.ExecContext(ctx, prune, historyLimit(keep)); err != nil {
+		return fmt.Errorf("postgres store: prune config history: %w", err)
+	}
+	return nil
+}
+
+// ListConfigVersions returns the recorded config versions, newest first.
+func (s *PostgresStore) ListConfigVersions(ctx context.Context) ([]ConfigVersion, error) {
+	query := fmt.Sprintf("SELECT metadata FROM %s ORDER BY id DESC", s.fullTableName(s.cfg.HistoryTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: list config history: %w", err)
+	}
+	defer rows.Close()
+	versions := make([]ConfigVersion, 0, 16)
+	for rows.Next() {
+		var meta string
+		if err = rows.Scan(&meta); err != nil {
+			return nil, fmt.Errorf("postgres store: scan config version: %w", err)
+		}
+		var version ConfigVersion
+		if errDecode := json.Unmarshal([]byte(meta), &version); errDecode != nil {
+			log.WithError(errDecode).Warn("postgres store: skipping undecodable config version")
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate config history: %w", err)
+	}
+	return versions, nil
+}
+
+// GetConfigVersion returns a recorded config version and its content.
+func (s *PostgresStore) GetConfigVersion(ctx context.Context, id string) (*ConfigVersion, []byte, error) {
+	query := fmt.Sprintf("SELECT metadata, content FROM %s WHERE id = $1", s.fullTableName(s.cfg.HistoryTable))
+	var meta, content string
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&meta, &content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrConfigVersionNotFound
+		}
+		return nil, nil, fmt.Errorf("postgres store: load config version: %w", err)
+	}
+	var version ConfigVersion
+	if err := json.Unmarshal([]byte(meta), &version); err != nil {
+		return nil, nil, fmt.Errorf("postgres store: decode config version: %w", err)
+	}
+	return &version, []byte(content), nil
+}
+
 // syncConfigFromDatabase writes the database-stored config to disk or seeds the database from template.
 func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
 	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
@@ -651,6 +735,16 @@ func labelFor(metadata map[string]any) string {
 	return ""
 }
 
+// restoreAuthState applies the disabled flag and quarantine recorded in the metadata of a
+// loaded auth, as the file store does, so such credentials stay out of rotation.
+func restoreAuthState(auth *cliproxyauth.Auth) {
+	if disabled, ok := auth.Metadata["disabled"].(bool); ok && disabled {
+		auth.Disabled = true
+		auth.Status = cliproxyauth.StatusDisabled
+	}
+	cliproxyauth.RestoreQuarantine(auth)
+}
+
 func normalizeAuthID(id string) string {
 	return filepath.ToSlash(filepath.Clean(id))
 }
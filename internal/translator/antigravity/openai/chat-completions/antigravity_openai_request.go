@@ -141,6 +141,9 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// response_format -> request.generationConfig.responseMimeType/responseSchema
+	out = common.ApplyOpenAIResponseFormat(out, rawJSON, "request.generationConfig")
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
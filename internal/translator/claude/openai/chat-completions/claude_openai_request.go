@@ -294,5 +294,8 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// Structured output emulation: response_format json_schema -> forced synthetic tool
+	out = applyStructuredOutput(out, root)
+
 	return []byte(out)
 }
@@ -27,6 +27,12 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// StructuredOutput is set when the request emulates response_format json_schema
+	StructuredOutput bool
+	// StructuredBlocks tracks content block indexes carrying the structured output tool call
+	StructuredBlocks map[int]bool
+	// ToolCallsEmitted records whether any client-visible tool call was streamed
+	ToolCallsEmitted bool
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -52,9 +58,10 @@ type ToolCallAccumulator struct {
 func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	if *param == nil {
 		*param = &ConvertAnthropicResponseToOpenAIParams{
-			CreatedAt:    0,
-			ResponseID:   "",
-			FinishReason: "",
+			CreatedAt:        0,
+			ResponseID:       "",
+			FinishReason:     "",
+			StructuredOutput: structuredOutputRequested(originalRequestRawJSON),
 		}
 	}
 
@@ -114,6 +121,15 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				toolName := contentBlock.Get("name").String()
 				index := int(root.Get("index").Int())
 
+				// The structured output tool call is streamed back as plain message content
+				if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput && toolName == structuredOutputToolName {
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks == nil {
+						(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks = make(map[int]bool)
+					}
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks[index] = true
+					return []string{}
+				}
+
 				if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
 					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
@@ -152,6 +168,13 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				// Tool use input delta - accumulate arguments for tool calls
 				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 					index := int(root.Get("index").Int())
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks[index] {
+						if partialJSON.String() == "" {
+							return []string{}
+						}
+						template, _ = sjson.Set(template, "choices.0.delta.content", partialJSON.String())
+						return []string{template}
+					}
 					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
@@ -186,6 +209,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 				// Clean up the accumulator for this index
 				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsEmitted = true
 
 				return []string{template}
 			}
@@ -197,6 +221,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				// A forced structured output call is the final answer, not a tool call
+				if len((*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredBlocks) > 0 && !(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsEmitted {
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = "stop"
+				}
 				template, _ = sjson.Set(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
 		}
@@ -269,6 +297,7 @@ func mapAnthropicStopReasonToOpenAI(anthropicReason string) string {
 //   - string: An OpenAI-compatible JSON response containing all message content and metadata
 func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
 	chunks := make([][]byte, 0)
+	structuredOutput := structuredOutputRequested(originalRequestRawJSON)
 
 	lines := bytes.Split(rawJSON, []byte("\n"))
 	for _, line := range lines {
@@ -288,6 +317,8 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var contentParts []string
 	var reasoningParts []string
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	var structuredArguments *strings.Builder
+	structuredIndex := -1
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -312,6 +343,12 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				} else if blockType == "tool_use" {
 					// Initialize tool call accumulator for this index
 					index := int(root.Get("index").Int())
+					if structuredOutput && structuredArguments == nil && contentBlock.Get("name").String() == structuredOutputToolName {
+						// The structured output tool call becomes the message content
+						structuredArguments = &strings.Builder{}
+						structuredIndex = index
+						continue
+					}
 					toolCallsAccumulator[index] = &ToolCallAccumulator{
 						ID:   contentBlock.Get("id").String(),
 						Name: contentBlock.Get("name").String(),
@@ -338,7 +375,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate tool call arguments
 					if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 						index := int(root.Get("index").Int())
-						if accumulator, exists := toolCallsAccumulator[index]; exists {
+						if index == structuredIndex {
+							structuredArguments.WriteString(partialJSON.String())
+						} else if accumulator, exists := toolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
 						}
 					}
@@ -381,6 +420,15 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 
 	// Set message content by combining all text parts
 	messageContent := strings.Join(contentParts, "")
+	if structuredArguments != nil {
+		messageContent = structuredArguments.String()
+		if messageContent == "" {
+			messageContent = "{}"
+		}
+		if stopReason == "tool_use" {
+			stopReason = "end_turn"
+		}
+	}
 	out, _ = sjson.Set(out, "choices.0.message.content", messageContent)
 
 	// Add reasoning content if available (following OpenAI reasoning format)
@@ -0,0 +1,73 @@
+package chat_completions
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// structuredOutputToolName is the synthetic tool used to emulate OpenAI
+// response_format json_schema on Claude. The model is forced to call it and the
+// tool input is surfaced back to the client as the message content. With extended thinking the
+// model is only asked to call it, since Claude rejects forced tool use while thinking.
+const structuredOutputToolName = "structured_output"
+
+// structuredOutputSchema returns the JSON schema requested through
+// response_format when the client asked for json_schema structured output.
+func structuredOutputSchema(root gjson.Result) (gjson.Result, bool) {
+	format := root.Get("response_format")
+	if format.Get("type").String() != "json_schema" {
+		return gjson.Result{}, false
+	}
+	schema := format.Get("json_schema.schema")
+	if !schema.IsObject() {
+		return gjson.Result{}, false
+	}
+	return schema, true
+}
+
+// structuredOutputRequested reports whether the original OpenAI request asked for
+// json_schema structured output, i.e. whether the synthetic tool was injected.
+func structuredOutputRequested(originalRequestRawJSON []byte) bool {
+	_, ok := structuredOutputSchema(gjson.ParseBytes(originalRequestRawJSON))
+	return ok
+}
+
+// applyStructuredOutput appends the synthetic structured output tool and selects
+// a tool_choice that makes Claude answer through it. When the client declared its
+// own tools the model may still call those instead ("any"); an explicit
+// function tool_choice from the client is preserved. When the request enables
+// thinking the choice stays "auto" and the tool description and the structured
+// output retry steer the answer instead. Thinking added later from a model
+// suffix is dropped by the Claude executor while the tool is forced.
+func applyStructuredOutput(out string, root gjson.Result) string {
+	schema, ok := structuredOutputSchema(root)
+	if !ok {
+		return out
+	}
+
+	description := "Respond with the final answer by calling this tool. The input must conform to the schema."
+	if desc := root.Get("response_format.json_schema.description").String(); desc != "" {
+		description += " " + desc
+	}
+	tool := `{"name":"","description":"","input_schema":{}}`
+	tool, _ = sjson.Set(tool, "name", structuredOutputToolName)
+	tool, _ = sjson.Set(tool, "description", description)
+	tool, _ = sjson.SetRaw(tool, "input_schema", schema.Raw)
+	out, _ = sjson.SetRaw(out, "tools.-1", tool)
+
+	toolChoice := gjson.Get(out, "tool_choice")
+	if toolChoice.Get("type").String() == "tool" {
+		return out
+	}
+	if gjson.Get(out, "thinking.type").String() == "enabled" {
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"auto"}`)
+		return out
+	}
+	hasClientTools := len(gjson.Get(out, "tools").Array()) > 1
+	if hasClientTools && root.Get("tool_choice").String() != "none" {
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
+		return out
+	}
+	out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"tool","name":"`+structuredOutputToolName+`"}`)
+	return out
+}
@@ -0,0 +1,86 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const structuredRequest = `{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"Weather in Paris?"}],"response_format":{"type":"json_schema","json_schema":{"name":"weather","schema":{"type":"object","properties":{"city":{"type":"string"},"temp":{"type":"number"}},"required":["city","temp"]}}}}`
+
+func TestConvertOpenAIRequestToClaude_StructuredOutputForcesTool(t *testing.T) {
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(structuredRequest), false)
+
+	tool := gjson.GetBytes(out, "tools.0")
+	if tool.Get("name").String() != structuredOutputToolName {
+		t.Fatalf("tool name = %q", tool.Get("name").String())
+	}
+	if tool.Get("input_schema.required.1").String() != "temp" {
+		t.Fatalf("input_schema not copied: %s", tool.Get("input_schema").Raw)
+	}
+	if got := gjson.GetBytes(out, "tool_choice").Raw; got != `{"type":"tool","name":"structured_output"}` {
+		t.Fatalf("tool_choice = %s", got)
+	}
+
+	withTools := strings.Replace(structuredRequest, `"response_format"`, `"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}],"response_format"`, 1)
+	out = ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(withTools), false)
+	if got := gjson.GetBytes(out, "tool_choice.type").String(); got != "any" {
+		t.Fatalf("tool_choice with client tools = %q, want any", got)
+	}
+	if n := len(gjson.GetBytes(out, "tools").Array()); n != 2 {
+		t.Fatalf("tools = %d, want 2", n)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_StructuredOutputWithThinking(t *testing.T) {
+	withThinking := strings.Replace(structuredRequest, `"response_format"`, `"reasoning_effort":"high","response_format"`, 1)
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4-5-20250929", []byte(withThinking), false)
+	if gjson.GetBytes(out, "thinking.type").String() != "enabled" {
+		t.Fatalf("thinking not enabled: %s", out)
+	}
+	if got := gjson.GetBytes(out, "tool_choice").Raw; got != `{"type":"auto"}` {
+		t.Fatalf("tool_choice with thinking = %s, want auto", got)
+	}
+	if gjson.GetBytes(out, "tools.0.name").String() != structuredOutputToolName {
+		t.Fatalf("structured output tool missing: %s", out)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_StructuredOutputUnwrapsTool(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Paris\","}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"temp\":21}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"input_tokens":10,"output_tokens":5}}`,
+	}
+
+	nonStream := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", []byte(structuredRequest), nil, []byte(strings.Join(events, "\n")), nil)
+	if got := gjson.Get(nonStream, "choices.0.message.content").String(); got != `{"city":"Paris","temp":21}` {
+		t.Fatalf("non-stream content = %q", got)
+	}
+	if gjson.Get(nonStream, "choices.0.message.tool_calls").Exists() || gjson.Get(nonStream, "choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("unexpected non-stream response %s", nonStream)
+	}
+
+	var param any
+	var content strings.Builder
+	finish := ""
+	for _, event := range events {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", []byte(structuredRequest), nil, []byte(event), &param) {
+			if gjson.Get(chunk, "choices.0.delta.tool_calls").Exists() {
+				t.Fatalf("structured output leaked as tool call: %s", chunk)
+			}
+			content.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+			if fr := gjson.Get(chunk, "choices.0.finish_reason"); fr.Type == gjson.String {
+				finish = fr.String()
+			}
+		}
+	}
+	if content.String() != `{"city":"Paris","temp":21}` || finish != "stop" {
+		t.Fatalf("stream content = %q, finish = %q", content.String(), finish)
+	}
+}
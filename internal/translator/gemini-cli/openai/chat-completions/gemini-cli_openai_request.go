@@ -109,6 +109,9 @@ func ConvertOpenAIRequestToGeminiCLI(modelName string, inputRawJSON []byte, _ bo
 		}
 	}
 
+	// response_format -> request.generationConfig.responseMimeType/responseSchema
+	out = common.ApplyOpenAIResponseFormat(out, rawJSON, "request.generationConfig")
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
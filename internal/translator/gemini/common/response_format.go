@@ -0,0 +1,25 @@
+package common
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ApplyOpenAIResponseFormat maps an OpenAI response_format onto a Gemini generationConfig.
+// json_object requests JSON output; json_schema additionally sets responseSchema from the
+// schema reduced by util.CleanJSONSchemaForGemini, since Gemini only accepts a subset of JSON Schema.
+// The caller must provide the generationConfig path (e.g. "generationConfig" or "request.generationConfig").
+func ApplyOpenAIResponseFormat(out, rawJSON []byte, path string) []byte {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	switch format.Get("type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, path+".responseMimeType", "application/json")
+	case "json_schema":
+		out, _ = sjson.SetBytes(out, path+".responseMimeType", "application/json")
+		if schema := format.Get("json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, path+".responseSchema", []byte(util.CleanJSONSchemaForGemini(schema.Raw)))
+		}
+	}
+	return out
+}
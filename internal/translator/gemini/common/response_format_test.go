@@ -0,0 +1,37 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyOpenAIResponseFormat(t *testing.T) {
+	rawJSON := []byte(`{"response_format":{"type":"json_schema","json_schema":{"name":"answer","strict":true,"schema":{"type":"object","additionalProperties":false,"properties":{"city":{"type":"string"},"meta":{"type":"object"}},"required":["city"]}}}}`)
+	out := ApplyOpenAIResponseFormat([]byte(`{"request":{}}`), rawJSON, "request.generationConfig")
+
+	config := gjson.GetBytes(out, "request.generationConfig")
+	if got := config.Get("responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q", got)
+	}
+	schema := config.Get("responseSchema")
+	if !schema.IsObject() || schema.Get("properties.city.type").String() != "string" || schema.Get("required.0").String() != "city" {
+		t.Fatalf("unexpected responseSchema %s", schema.Raw)
+	}
+	if schema.Get("additionalProperties").Exists() {
+		t.Fatalf("unsupported keyword kept in responseSchema %s", schema.Raw)
+	}
+	if schema.Get("properties.meta.properties").Exists() {
+		t.Fatalf("empty object schema got a placeholder %s", schema.Raw)
+	}
+
+	out = ApplyOpenAIResponseFormat([]byte(`{}`), []byte(`{"response_format":{"type":"json_object"}}`), "generationConfig")
+	if gjson.GetBytes(out, "generationConfig.responseMimeType").String() != "application/json" || gjson.GetBytes(out, "generationConfig.responseSchema").Exists() {
+		t.Fatalf("unexpected json_object mapping %s", out)
+	}
+
+	out = ApplyOpenAIResponseFormat([]byte(`{}`), []byte(`{"response_format":{"type":"text"}}`), "generationConfig")
+	if string(out) != `{}` {
+		t.Fatalf("text format changed the request: %s", out)
+	}
+}
@@ -127,6 +127,9 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// response_format -> generationConfig.responseMimeType/responseSchema
+	out = common.ApplyOpenAIResponseFormat(out, rawJSON, "generationConfig")
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -0,0 +1,269 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// BudgetStatus reports the spend of one budget, or of one client key for per-key budgets, in
+// the current period.
+type BudgetStatus struct {
+	Name        string     `json:"name"`
+	APIKey      string     `json:"api_key,omitempty"`
+	Period      string     `json:"period"`
+	Limit       float64    `json:"limit"`
+	Spent       float64    `json:"spent"`
+	Remaining   float64    `json:"remaining"`
+	Currency    string     `json:"currency"`
+	PeriodStart *time.Time `json:"period_start,omitempty"`
+	ResetsAt    *time.Time `json:"resets_at,omitempty"`
+	Exceeded    bool       `json:"exceeded"`
+}
+
+// BudgetTracker accumulates priced usage per budget period and reports exhausted budgets.
+// Spend is checked before a request runs and added once it completes, so the request that
+// crosses a limit is still served.
+type BudgetTracker struct {
+	mu       sync.Mutex
+	currency string
+	budgets  []config.SpendBudget
+	windows  map[string]*budgetWindow
+}
+
+type budgetWindow struct {
+	start time.Time
+	spent float64
+}
+
+var defaultBudgets = NewBudgetTracker()
+
+// NewBudgetTracker returns a tracker without budgets.
+func NewBudgetTracker() *BudgetTracker {
+	return &BudgetTracker{currency: DefaultCurrency, windows: make(map[string]*budgetWindow)}
+}
+
+// Configure replaces the budgets and rebuilds their current-period spend from stats.
+func (t *BudgetTracker) Configure(cfg config.PricingConfig, stats *RequestStatistics) {
+	if t == nil {
+		return
+	}
+	currency := strings.TrimSpace(cfg.Currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	budgets := make([]config.SpendBudget, 0, len(cfg.Budgets))
+	for i, budget := range cfg.Budgets {
+		if budget.Limit <= 0 {
+			continue
+		}
+		budget.Name = strings.TrimSpace(budget.Name)
+		if budget.Name == "" {
+			budget.Name = fmt.Sprintf("budget-%d", i)
+		}
+		budget.Period = strings.ToLower(strings.TrimSpace(budget.Period))
+		if budget.Period == "" {
+			budget.Period = config.BudgetPeriodMonthly
+		}
+		budgets = append(budgets, budget)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.currency = currency
+	t.budgets = budgets
+	t.windows = make(map[string]*budgetWindow)
+	if len(budgets) == 0 || stats == nil {
+		return
+	}
+	now := time.Now()
+	stats.eachDetail(func(apiKey, model string, detail RequestDetail) {
+		t.addLocked(apiKey, model, detail.Cost, detail.Timestamp, now)
+	})
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (t *BudgetTracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if t == nil || record.Cost <= 0 {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addLocked(record.APIKey, record.Model, record.Cost, at, time.Now())
+}
+
+func (t *BudgetTracker) addLocked(apiKey, model string, cost float64, at, now time.Time) {
+	if cost <= 0 {
+		return
+	}
+	for i := range t.budgets {
+		budget := &t.budgets[i]
+		if !budgetMatches(budget, apiKey, model) {
+			continue
+		}
+		start := budgetPeriodStart(budget.Period, now)
+		if at.Before(start) {
+			continue
+		}
+		key := budgetKey(i, budget, apiKey)
+		window, ok := t.windows[key]
+		if !ok || window.start.Before(start) {
+			window = &budgetWindow{start: start}
+			t.windows[key] = window
+		}
+		window.spent += cost
+	}
+}
+
+// Check returns the first budget covering apiKey and model whose limit is reached, or nil.
+func (t *BudgetTracker) Check(apiKey, model string) *BudgetStatus {
+	if t == nil {
+		return nil
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.budgets {
+		budget := &t.budgets[i]
+		if !budgetMatches(budget, apiKey, model) {
+			continue
+		}
+		key := budgetKey(i, budget, apiKey)
+		status := t.statusLocked(budget, key, apiKey, now)
+		if status.Exceeded {
+			return &status
+		}
+	}
+	return nil
+}
+
+// Statuses reports every budget, ordered by name and client key; per-key budgets get one
+// entry per client key with spend.
+func (t *BudgetTracker) Statuses() []BudgetStatus {
+	out := []BudgetStatus{}
+	if t == nil {
+		return out
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.budgets {
+		budget := &t.budgets[i]
+		if !budget.PerKey {
+			out = append(out, t.statusLocked(budget, budgetKey(i, budget, ""), "", now))
+			continue
+		}
+		prefix := fmt.Sprintf("%d\x00", i)
+		for key := range t.windows {
+			if apiKey, ok := strings.CutPrefix(key, prefix); ok {
+				out = append(out, t.statusLocked(budget, key, apiKey, now))
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].APIKey < out[j].APIKey
+	})
+	return out
+}
+
+func (t *BudgetTracker) statusLocked(budget *config.SpendBudget, key, apiKey string, now time.Time) BudgetStatus {
+	status := BudgetStatus{
+		Name:     budget.Name,
+		Period:   budget.Period,
+		Limit:    budget.Limit,
+		Currency: t.currency,
+	}
+	if budget.PerKey {
+		status.APIKey = apiKey
+	}
+	start := budgetPeriodStart(budget.Period, now)
+	if window, ok := t.windows[key]; ok && !window.start.Before(start) {
+		status.Spent = window.spent
+	}
+	if !start.IsZero() {
+		reset := budgetPeriodEnd(budget.Period, start)
+		status.PeriodStart, status.ResetsAt = &start, &reset
+	}
+	status.Remaining = max(budget.Limit-status.Spent, 0)
+	status.Exceeded = status.Spent >= budget.Limit
+	return status
+}
+
+func budgetKey(index int, budget *config.SpendBudget, apiKey string) string {
+	if !budget.PerKey {
+		apiKey = ""
+	}
+	return fmt.Sprintf("%d\x00%s", index, apiKey)
+}
+
+func budgetMatches(budget *config.SpendBudget, apiKey, model string) bool {
+	if len(budget.APIKeys) > 0 {
+		matched := false
+		for _, pattern := range budget.APIKeys {
+			if matchPattern(strings.TrimSpace(pattern), apiKey) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(budget.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range budget.Models {
+		if matchPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetPeriodStart returns the UTC start of the period containing now; the zero time for
+// budgets that never reset.
+func budgetPeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case config.BudgetPeriodDaily:
+		return day
+	case config.BudgetPeriodWeekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case config.BudgetPeriodTotal:
+		return time.Time{}
+	default:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func budgetPeriodEnd(period string, start time.Time) time.Time {
+	switch period {
+	case config.BudgetPeriodDaily:
+		return start.AddDate(0, 0, 1)
+	case config.BudgetPeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// CheckBudget returns the exhausted budget covering a request from apiKey for model, or nil.
+func CheckBudget(apiKey, model string) *BudgetStatus { return defaultBudgets.Check(apiKey, model) }
+
+// BudgetStatuses reports the spend of every configured budget.
+func BudgetStatuses() []BudgetStatus { return defaultBudgets.Statuses() }
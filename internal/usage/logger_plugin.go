@@ -33,6 +33,8 @@ var (
 func init() {
 	statisticsEnabled.Store(true)
 	coreusage.RegisterPlugin(NewLoggerPlugin())
+	coreusage.RegisterPlugin(defaultBudgets)
+	coreusage.SetDefaultPricer(tablePricer{})
 }
 
 // LoggerPlugin collects in-memory request statistics for usage analysis.
@@ -77,6 +79,8 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	totalCost     float64
+	currency      string
 
 	apis map[string]*apiStats
 
@@ -84,12 +88,17 @@ type RequestStatistics struct {
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
 	tokensByHour   map[int]int64
+	costByDay      map[string]float64
+
+	costByModel      map[string]float64
+	costByCredential map[string]float64
 }
 
 // apiStats holds aggregated metrics for a single API key.
 type apiStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Models        map[string]*modelStats
 }
 
@@ -97,6 +106,7 @@ type apiStats struct {
 type modelStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Details       []RequestDetail
 }
 
@@ -107,6 +117,8 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	// Cost is the priced cost of the request in the snapshot currency.
+	Cost float64 `json:"cost,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -124,19 +136,29 @@ type StatisticsSnapshot struct {
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
+	// TotalCost is the spend of every priced request in Currency.
+	TotalCost float64 `json:"total_cost"`
+	Currency  string  `json:"currency,omitempty"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
-	RequestsByDay  map[string]int64 `json:"requests_by_day"`
-	RequestsByHour map[string]int64 `json:"requests_by_hour"`
-	TokensByDay    map[string]int64 `json:"tokens_by_day"`
-	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
+	RequestsByDay  map[string]int64   `json:"requests_by_day"`
+	RequestsByHour map[string]int64   `json:"requests_by_hour"`
+	TokensByDay    map[string]int64   `json:"tokens_by_day"`
+	TokensByHour   map[string]int64   `json:"tokens_by_hour"`
+	CostByDay      map[string]float64 `json:"cost_by_day"`
+
+	// CostByModel and CostByCredential break the spend down by model and by credential
+	// (keyed by auth index).
+	CostByModel      map[string]float64 `json:"cost_by_model"`
+	CostByCredential map[string]float64 `json:"cost_by_credential"`
 }
 
 // APISnapshot summarises metrics for a single API key.
 type APISnapshot struct {
 	TotalRequests int64                    `json:"total_requests"`
 	TotalTokens   int64                    `json:"total_tokens"`
+	TotalCost     float64                  `json:"total_cost"`
 	Models        map[string]ModelSnapshot `json:"models"`
 }
 
@@ -144,6 +166,7 @@ type APISnapshot struct {
 type ModelSnapshot struct {
 	TotalRequests int64           `json:"total_requests"`
 	TotalTokens   int64           `json:"total_tokens"`
+	TotalCost     float64         `json:"total_cost"`
 	Details       []RequestDetail `json:"details"`
 }
 
@@ -155,11 +178,14 @@ func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics
 // NewRequestStatistics constructs an empty statistics store.
 func NewRequestStatistics() *RequestStatistics {
 	return &RequestStatistics{
-		apis:           make(map[string]*apiStats),
-		requestsByDay:  make(map[string]int64),
-		requestsByHour: make(map[int]int64),
-		tokensByDay:    make(map[string]int64),
-		tokensByHour:   make(map[int]int64),
+		apis:             make(map[string]*apiStats),
+		requestsByDay:    make(map[string]int64),
+		requestsByHour:   make(map[int]int64),
+		tokensByDay:      make(map[string]int64),
+		tokensByHour:     make(map[int]int64),
+		costByDay:        make(map[string]float64),
+		costByModel:      make(map[string]float64),
+		costByCredential: make(map[string]float64),
 	}
 }
 
@@ -203,6 +229,9 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.failureCount++
 	}
 	s.totalTokens += totalTokens
+	if record.Currency != "" {
+		s.currency = record.Currency
+	}
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
@@ -215,6 +244,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
 		Failed:    failed,
+		Cost:      record.Cost,
 	})
 
 	s.requestsByDay[dayKey]++
@@ -234,6 +264,46 @@ func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail
 	modelStatsValue.TotalRequests++
 	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
 	modelStatsValue.Details = append(modelStatsValue.Details, detail)
+	s.addCost(stats, modelStatsValue, model, detail)
+}
+
+// addCost adds the cost of detail to every spend aggregate.
+func (s *RequestStatistics) addCost(stats *apiStats, modelStatsValue *modelStats, model string, detail RequestDetail) {
+	if detail.Cost == 0 {
+		return
+	}
+	s.totalCost += detail.Cost
+	stats.TotalCost += detail.Cost
+	modelStatsValue.TotalCost += detail.Cost
+	s.costByDay[detail.Timestamp.Format("2006-01-02")] += detail.Cost
+	s.costByModel[model] += detail.Cost
+	credential := detail.AuthIndex
+	if credential == "" {
+		credential = "unknown"
+	}
+	s.costByCredential[credential] += detail.Cost
+}
+
+// eachDetail calls fn for every recorded request detail with its API key and model.
+func (s *RequestStatistics) eachDetail(fn func(apiKey, model string, detail RequestDetail)) {
+	if s == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for apiName, stats := range s.apis {
+		if stats == nil {
+			continue
+		}
+		for modelName, modelStatsValue := range stats.Models {
+			if modelStatsValue == nil {
+				continue
+			}
+			for _, detail := range modelStatsValue.Details {
+				fn(apiName, modelName, detail)
+			}
+		}
+	}
 }
 
 // Snapshot returns a copy of the aggregated metrics for external consumption.
@@ -250,12 +320,15 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.TotalCost = s.totalCost
+	result.Currency = s.currency
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
 			TotalRequests: stats.TotalRequests,
 			TotalTokens:   stats.TotalTokens,
+			TotalCost:     stats.TotalCost,
 			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
 		}
 		for modelName, modelStatsValue := range stats.Models {
@@ -264,6 +337,7 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 			apiSnapshot.Models[modelName] = ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
+				TotalCost:     modelStatsValue.TotalCost,
 				Details:       requestDetails,
 			}
 		}
@@ -292,9 +366,21 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.TokensByHour[key] = v
 	}
 
+	result.CostByDay = copyCostMap(s.costByDay)
+	result.CostByModel = copyCostMap(s.costByModel)
+	result.CostByCredential = copyCostMap(s.costByCredential)
+
 	return result
 }
 
+func copyCostMap(src map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
 type MergeResult struct {
 	Added   int64 `json:"added"`
 	Skipped int64 `json:"skipped"`
@@ -311,6 +397,9 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.currency == "" {
+		s.currency = snapshot.Currency
+	}
 	seen := make(map[string]struct{})
 	for apiName, stats := range s.apis {
 		if stats == nil {
@@ -595,18 +684,22 @@ func (s *RequestStatistics) applySnapshot(snap StatisticsSnapshot) {
 	s.successCount = snap.SuccessCount
 	s.failureCount = snap.FailureCount
 	s.totalTokens = snap.TotalTokens
+	s.totalCost = snap.TotalCost
+	s.currency = snap.Currency
 
 	s.apis = make(map[string]*apiStats, len(snap.APIs))
 	for apiKey, apiSnap := range snap.APIs {
 		as := &apiStats{
 			TotalRequests: apiSnap.TotalRequests,
 			TotalTokens:   apiSnap.TotalTokens,
+			TotalCost:     apiSnap.TotalCost,
 			Models:        make(map[string]*modelStats, len(apiSnap.Models)),
 		}
 		for model, msnap := range apiSnap.Models {
 			ms := &modelStats{
 				TotalRequests: msnap.TotalRequests,
 				TotalTokens:   msnap.TotalTokens,
+				TotalCost:     msnap.TotalCost,
 				Details:       make([]RequestDetail, len(msnap.Details)),
 			}
 			copy(ms.Details, msnap.Details)
@@ -625,6 +718,10 @@ func (s *RequestStatistics) applySnapshot(snap StatisticsSnapshot) {
 		s.tokensByDay[k] = v
 	}
 
+	s.costByDay = copyCostMap(snap.CostByDay)
+	s.costByModel = copyCostMap(snap.CostByModel)
+	s.costByCredential = copyCostMap(snap.CostByCredential)
+
 	// Hourly maps: convert "HH" keys back to int indices
 	s.requestsByHour = make(map[int]int64, len(snap.RequestsByHour))
 	for hk, v := range snap.RequestsByHour {
@@ -0,0 +1,174 @@
+package usage
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// DefaultCurrency labels costs when the config does not name a currency.
+const DefaultCurrency = "USD"
+
+// DefaultModelPrices are the built-in list prices in USD per million tokens. More specific
+// patterns come first because the first match wins. Long-context surcharges and batch
+// discounts are not modelled.
+var DefaultModelPrices = []config.ModelPrice{
+	{Model: "claude-opus-4-5*", Input: 5, Output: 25, CachedInput: 0.5},
+	{Model: "claude-opus-4*", Input: 15, Output: 75, CachedInput: 1.5},
+	{Model: "claude-3-opus*", Input: 15, Output: 75, CachedInput: 1.5},
+	{Model: "claude-sonnet-4*", Input: 3, Output: 15, CachedInput: 0.3},
+	{Model: "claude-3-7-sonnet*", Input: 3, Output: 15, CachedInput: 0.3},
+	{Model: "claude-3-5-sonnet*", Input: 3, Output: 15, CachedInput: 0.3},
+	{Model: "claude-haiku-4*", Input: 1, Output: 5, CachedInput: 0.1},
+	{Model: "claude-3-5-haiku*", Input: 0.8, Output: 4, CachedInput: 0.08},
+	{Model: "gpt-5*-nano*", Input: 0.05, Output: 0.4, CachedInput: 0.005},
+	{Model: "gpt-5*-mini*", Input: 0.25, Output: 2, CachedInput: 0.025},
+	{Model: "gpt-5*", Input: 1.25, Output: 10, CachedInput: 0.125},
+	{Model: "gpt-4.1-nano*", Input: 0.1, Output: 0.4, CachedInput: 0.025},
+	{Model: "gpt-4.1-mini*", Input: 0.4, Output: 1.6, CachedInput: 0.1},
+	{Model: "gpt-4.1*", Input: 2, Output: 8, CachedInput: 0.5},
+	{Model: "gpt-4o-mini*", Input: 0.15, Output: 0.6, CachedInput: 0.075},
+	{Model: "gpt-4o*", Input: 2.5, Output: 10, CachedInput: 1.25},
+	{Model: "o4-mini*", Input: 1.1, Output: 4.4, CachedInput: 0.275},
+	{Model: "o3-mini*", Input: 1.1, Output: 4.4, CachedInput: 0.55},
+	{Model: "o3*", Input: 2, Output: 8, CachedInput: 0.5},
+	{Model: "gemini-3-pro*", Input: 2, Output: 12, CachedInput: 0.2},
+	{Model: "gemini-2.5-pro*", Input: 1.25, Output: 10, CachedInput: 0.31},
+	{Model: "gemini-2.5-flash-lite*", Input: 0.1, Output: 0.4, CachedInput: 0.025},
+	{Model: "gemini-2.5-flash*", Input: 0.3, Output: 2.5, CachedInput: 0.075},
+	{Model: "gemini-2.0-flash-lite*", Input: 0.075, Output: 0.3},
+	{Model: "gemini-2.0-flash*", Input: 0.1, Output: 0.4, CachedInput: 0.025},
+}
+
+// PriceTable prices usage records from configured entries followed by the built-in defaults.
+type PriceTable struct {
+	currency string
+	entries  []config.ModelPrice
+}
+
+// NewPriceTable builds the price table described by cfg.
+func NewPriceTable(cfg config.PricingConfig) *PriceTable {
+	table := &PriceTable{currency: strings.TrimSpace(cfg.Currency)}
+	if table.currency == "" {
+		table.currency = DefaultCurrency
+	}
+	for _, entry := range cfg.Models {
+		if strings.TrimSpace(entry.Model) == "" {
+			continue
+		}
+		table.entries = append(table.entries, entry)
+	}
+	if !cfg.DisableDefaults {
+		table.entries = append(table.entries, DefaultModelPrices...)
+	}
+	return table
+}
+
+// Currency returns the currency every cost from this table is expressed in.
+func (t *PriceTable) Currency() string {
+	if t == nil {
+		return DefaultCurrency
+	}
+	return t.currency
+}
+
+// Lookup returns the first entry matching provider and model.
+func (t *PriceTable) Lookup(provider, model string) (config.ModelPrice, bool) {
+	if t == nil {
+		return config.ModelPrice{}, false
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	provider = strings.TrimSpace(provider)
+	for _, entry := range t.entries {
+		if p := strings.TrimSpace(entry.Provider); p != "" && !strings.EqualFold(p, provider) {
+			continue
+		}
+		if matchPattern(strings.ToLower(strings.TrimSpace(entry.Model)), model) {
+			return entry, true
+		}
+	}
+	return config.ModelPrice{}, false
+}
+
+// Cost prices detail for provider and model. Claude reports cached prompt tokens next to the
+// input tokens while the other providers count them inside it, so cached tokens are only
+// subtracted from the input for the latter. Likewise, reasoning tokens are taken out of the
+// output tokens when the total shows the provider counted them there.
+func (t *PriceTable) Cost(provider, model string, detail coreusage.Detail) (float64, bool) {
+	price, ok := t.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	input, output := detail.InputTokens, detail.OutputTokens
+	cached, reasoning := detail.CachedTokens, detail.ReasoningTokens
+	if !strings.EqualFold(provider, "claude") {
+		input -= cached
+	}
+	if reasoning > 0 && detail.TotalTokens > 0 && detail.TotalTokens < detail.InputTokens+detail.OutputTokens+reasoning {
+		output -= reasoning
+	}
+	cachedPrice, reasoningPrice := price.CachedInput, price.Reasoning
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	if reasoningPrice == 0 {
+		reasoningPrice = price.Output
+	}
+	cost := float64(max(input, 0))*price.Input +
+		float64(max(cached, 0))*cachedPrice +
+		float64(max(output, 0))*price.Output +
+		float64(max(reasoning, 0))*reasoningPrice
+	return cost / 1e6, true
+}
+
+// Price implements coreusage.Pricer.
+func (t *PriceTable) Price(record coreusage.Record) (float64, string, bool) {
+	cost, ok := t.Cost(record.Provider, record.Model, record.Detail)
+	return cost, t.Currency(), ok
+}
+
+var currentPriceTable atomic.Pointer[PriceTable]
+
+func init() {
+	currentPriceTable.Store(NewPriceTable(config.PricingConfig{}))
+}
+
+// CurrentPriceTable returns the price table installed by SetPricing.
+func CurrentPriceTable() *PriceTable { return currentPriceTable.Load() }
+
+// SetPricing installs the price table and budgets from cfg. Budget spend for the current
+// periods is rebuilt from the recorded usage statistics.
+func SetPricing(cfg config.PricingConfig) {
+	currentPriceTable.Store(NewPriceTable(cfg))
+	defaultBudgets.Configure(cfg, defaultRequestStatistics)
+}
+
+// tablePricer resolves the current table on every call so SetPricing takes effect at once.
+type tablePricer struct{}
+
+func (tablePricer) Price(record coreusage.Record) (float64, string, bool) {
+	return CurrentPriceTable().Price(record)
+}
+
+// matchPattern reports whether value matches a glob-style pattern supporting only '*'.
+func matchPattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
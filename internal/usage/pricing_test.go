@@ -0,0 +1,125 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func almostEqual(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestPriceTableCost(t *testing.T) {
+	table := NewPriceTable(config.PricingConfig{
+		Currency: "EUR",
+		Models: []config.ModelPrice{
+			{Model: "claude-sonnet-4*", Provider: "claude", Input: 2, Output: 10, CachedInput: 0.2},
+			{Model: "local-*", Input: 1, Output: 1},
+		},
+	})
+
+	// Claude reports cache reads next to the input tokens.
+	cost, ok := table.Cost("claude", "claude-sonnet-4-5-20250929", coreusage.Detail{InputTokens: 1000, OutputTokens: 500, CachedTokens: 2000})
+	if !ok || !almostEqual(cost, (1000*2+2000*0.2+500*10)/1e6) {
+		t.Fatalf("claude cost = %v, %t", cost, ok)
+	}
+	// The provider-scoped override does not apply elsewhere, so the default price is used.
+	cost, ok = table.Cost("antigravity", "claude-sonnet-4-5", coreusage.Detail{InputTokens: 1000})
+	if !ok || !almostEqual(cost, 1000*3/1e6) {
+		t.Fatalf("default claude cost = %v, %t", cost, ok)
+	}
+	// OpenAI counts cached and reasoning tokens inside input and output.
+	cost, ok = table.Cost("codex", "gpt-5-codex", coreusage.Detail{InputTokens: 1000, CachedTokens: 400, OutputTokens: 300, ReasoningTokens: 100, TotalTokens: 1300})
+	if !ok || !almostEqual(cost, (600*1.25+400*0.125+200*10+100*10)/1e6) {
+		t.Fatalf("codex cost = %v, %t", cost, ok)
+	}
+	// Gemini reports reasoning tokens separately from the output.
+	cost, ok = table.Cost("gemini", "gemini-2.5-flash", coreusage.Detail{InputTokens: 1000, OutputTokens: 300, ReasoningTokens: 100, TotalTokens: 1400})
+	if !ok || !almostEqual(cost, (1000*0.3+300*2.5+100*2.5)/1e6) {
+		t.Fatalf("gemini cost = %v, %t", cost, ok)
+	}
+	if _, ok = table.Cost("x", "unknown-model", coreusage.Detail{InputTokens: 1}); ok {
+		t.Fatalf("unknown model should not be priced")
+	}
+	if _, currency, _ := table.Price(coreusage.Record{Model: "local-llm"}); currency != "EUR" {
+		t.Fatalf("currency = %q", currency)
+	}
+
+	noDefaults := NewPriceTable(config.PricingConfig{DisableDefaults: true})
+	if _, ok = noDefaults.Lookup("codex", "gpt-5"); ok {
+		t.Fatalf("defaults should be disabled")
+	}
+}
+
+func TestBudgetTrackerPerKeyAndRebuild(t *testing.T) {
+	pricing := config.PricingConfig{Budgets: []config.SpendBudget{
+		{Name: "team", APIKeys: []string{"team-*"}, PerKey: true, Period: config.BudgetPeriodDaily, Limit: 1},
+		{Name: "opus", Models: []string{"claude-opus-*"}, Limit: 5},
+	}}
+	tracker := NewBudgetTracker()
+	tracker.Configure(pricing, nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	tracker.HandleUsage(ctx, coreusage.Record{APIKey: "team-a", Model: "gpt-5", Cost: 0.6, RequestedAt: now})
+	if status := tracker.Check("team-a", "gpt-5"); status != nil {
+		t.Fatalf("budget should not be exhausted yet: %+v", status)
+	}
+	tracker.HandleUsage(ctx, coreusage.Record{APIKey: "team-a", Model: "gpt-5", Cost: 0.5, RequestedAt: now})
+	status := tracker.Check("team-a", "gpt-5")
+	if status == nil || status.Name != "team" || status.APIKey != "team-a" || status.ResetsAt == nil {
+		t.Fatalf("expected exhausted team budget for team-a, got %+v", status)
+	}
+	if status := tracker.Check("team-b", "gpt-5"); status != nil {
+		t.Fatalf("per-key budget should not affect team-b: %+v", status)
+	}
+	// Spend from an earlier period does not count.
+	tracker.HandleUsage(ctx, coreusage.Record{APIKey: "team-b", Model: "gpt-5", Cost: 3, RequestedAt: now.AddDate(0, 0, -2)})
+	if status := tracker.Check("team-b", "gpt-5"); status != nil {
+		t.Fatalf("old spend counted: %+v", status)
+	}
+
+	stats := NewRequestStatistics()
+	stats.Record(ctx, coreusage.Record{APIKey: "ops", Model: "claude-opus-4-1", Cost: 6, RequestedAt: now, Detail: coreusage.Detail{InputTokens: 1}})
+	tracker.Configure(pricing, stats)
+	if status := tracker.Check("ops", "claude-opus-4-1"); status == nil || status.Name != "opus" {
+		t.Fatalf("spend should be rebuilt from statistics, got %+v", status)
+	}
+	if status := tracker.Check("ops", "gpt-5"); status != nil {
+		t.Fatalf("model-scoped budget applied to another model: %+v", status)
+	}
+
+	snapshot := stats.Snapshot()
+	if !almostEqual(snapshot.TotalCost, 6) || !almostEqual(snapshot.APIs["ops"].TotalCost, 6) || !almostEqual(snapshot.CostByModel["claude-opus-4-1"], 6) {
+		t.Fatalf("unexpected spend aggregates: %+v", snapshot)
+	}
+}
+
+func TestBudgetTrackerStatusesAreSorted(t *testing.T) {
+	tracker := NewBudgetTracker()
+	tracker.Configure(config.PricingConfig{Budgets: []config.SpendBudget{
+		{Name: "zeta", Limit: 5},
+		{Name: "team", APIKeys: []string{"team-*"}, PerKey: true, Period: config.BudgetPeriodDaily, Limit: 1},
+	}}, nil)
+	ctx := context.Background()
+	now := time.Now()
+	for _, key := range []string{"team-c", "team-a", "team-d", "team-b"} {
+		tracker.HandleUsage(ctx, coreusage.Record{APIKey: key, Model: "gpt-5", Cost: 0.1, RequestedAt: now})
+	}
+
+	want := []string{"team/team-a", "team/team-b", "team/team-c", "team/team-d", "zeta/"}
+	for attempt := 0; attempt < 5; attempt++ {
+		statuses := tracker.Statuses()
+		if len(statuses) != len(want) {
+			t.Fatalf("statuses = %+v, want %d entries", statuses, len(want))
+		}
+		for i, status := range statuses {
+			if got := status.Name + "/" + status.APIKey; got != want[i] {
+				t.Fatalf("status %d = %s, want %s", i, got, want[i])
+			}
+		}
+	}
+}
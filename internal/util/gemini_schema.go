@@ -16,6 +16,19 @@ var gjsonPathKeyReplacer = strings.NewReplacer(".", "\\.", "*", "\\*", "?", "\\?
 // It handles unsupported keywords, type flattening, and schema simplification while preserving
 // semantic information as description hints.
 func CleanJSONSchemaForAntigravity(jsonStr string) string {
+	jsonStr = CleanJSONSchemaForGemini(jsonStr)
+
+	// Phase 4: Add placeholder for empty object schemas (Claude VALIDATED mode requirement)
+	jsonStr = addEmptySchemaPlaceholder(jsonStr)
+
+	return jsonStr
+}
+
+// CleanJSONSchemaForGemini reduces a JSON schema to the subset accepted by Gemini
+// schema fields such as generationConfig.responseSchema. It applies the same keyword
+// conversion and flattening as CleanJSONSchemaForAntigravity but leaves empty
+// object schemas untouched.
+func CleanJSONSchemaForGemini(jsonStr string) string {
 	// Phase 1: Convert and add hints
 	jsonStr = convertRefsToHints(jsonStr)
 	jsonStr = convertConstToEnum(jsonStr)
@@ -32,9 +45,6 @@ func CleanJSONSchemaForAntigravity(jsonStr string) string {
 	jsonStr = removeUnsupportedKeywords(jsonStr)
 	jsonStr = cleanupRequiredFields(jsonStr)
 
-	// Phase 4: Add placeholder for empty object schemas (Claude VALIDATED mode requirement)
-	jsonStr = addEmptySchemaPlaceholder(jsonStr)
-
 	return jsonStr
 }
 
@@ -0,0 +1,456 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultMediaFetchMaxBytes = 20 << 20
+	defaultMediaFetchTimeout  = 15 * time.Second
+	defaultMediaFetchCacheTTL = 10 * time.Minute
+	// mediaCacheMaxBytes bounds the total size of cached downloads.
+	mediaCacheMaxBytes     = 128 << 20
+	mediaFetchMaxRedirects = 3
+)
+
+// ErrMediaFetchBlocked is returned when a URL is rejected by the SSRF policy.
+var ErrMediaFetchBlocked = errors.New("media fetch blocked")
+
+// FetchedMedia is a downloaded remote media object.
+type FetchedMedia struct {
+	MimeType string
+	Data     []byte
+}
+
+// DataURL renders the media as a base64 data URL.
+func (m *FetchedMedia) DataURL() string {
+	return "data:" + m.MimeType + ";base64," + base64.StdEncoding.EncodeToString(m.Data)
+}
+
+type mediaCacheEntry struct {
+	media   *FetchedMedia
+	expires time.Time
+	stored  time.Time
+}
+
+// MediaFetcher downloads remote media referenced by client requests with size and time
+// limits, refuses private network destinations, and caches results by URL hash.
+type MediaFetcher struct {
+	client *http.Client
+
+	mu         sync.Mutex
+	cache      map[string]*mediaCacheEntry
+	cacheBytes int64
+}
+
+type mediaFetchPolicyKey struct{}
+
+var (
+	defaultMediaFetcherOnce sync.Once
+	defaultMediaFetcher     *MediaFetcher
+)
+
+// DefaultMediaFetcher returns the process-wide media fetcher.
+func DefaultMediaFetcher() *MediaFetcher {
+	defaultMediaFetcherOnce.Do(func() { defaultMediaFetcher = NewMediaFetcher() })
+	return defaultMediaFetcher
+}
+
+// NewMediaFetcher constructs a fetcher with its own connection pool and cache.
+func NewMediaFetcher() *MediaFetcher {
+	f := &MediaFetcher{cache: make(map[string]*mediaCacheEntry)}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			cfg, _ := ctx.Value(mediaFetchPolicyKey{}).(config.MediaFetchConfig)
+			dialer := &net.Dialer{Timeout: 10 * time.Second}
+			if !cfg.AllowPrivateNetworks {
+				dialer.Control = blockPrivateDestinations
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: defaultMediaFetchTimeout,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	f.client = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= mediaFetchMaxRedirects {
+				return fmt.Errorf("%w: too many redirects", ErrMediaFetchBlocked)
+			}
+			cfg, _ := req.Context().Value(mediaFetchPolicyKey{}).(config.MediaFetchConfig)
+			return checkMediaURL(cfg, req.URL)
+		},
+	}
+	return f
+}
+
+// blockPrivateDestinations runs after DNS resolution and rejects non-public addresses,
+// which also covers DNS rebinding and redirects to internal hosts.
+func blockPrivateDestinations(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: destination %s is not a public address", ErrMediaFetchBlocked, host)
+	}
+	return nil
+}
+
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (cgnatRange.Contains(ip4) || ip4[0] == 0 || ip4.Equal(net.IPv4bcast)) {
+		return false
+	}
+	return true
+}
+
+// checkMediaURL validates scheme and host allow-list before any connection is made.
+func checkMediaURL(cfg config.MediaFetchConfig, u *url.URL) error {
+	if u == nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%w: only http and https URLs are fetched", ErrMediaFetchBlocked)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrMediaFetchBlocked)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URLs with credentials are not fetched", ErrMediaFetchBlocked)
+	}
+	if len(cfg.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, pattern := range cfg.AllowedHosts {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == host {
+			return nil
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: host %s is not in media-fetch.allowed-hosts", ErrMediaFetchBlocked, host)
+}
+
+// Fetch downloads rawURL according to cfg, serving repeated URLs from the cache.
+func (f *MediaFetcher) Fetch(ctx context.Context, cfg config.MediaFetchConfig, rawURL string) (*FetchedMedia, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid media URL: %w", err)
+	}
+	if err = checkMediaURL(cfg, u); err != nil {
+		return nil, err
+	}
+	key := mediaCacheKey(u.String())
+	if media := f.cached(key); media != nil {
+		return media, nil
+	}
+
+	timeout := defaultMediaFetchTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	maxBytes := int64(defaultMediaFetchMaxBytes)
+	if cfg.MaxBytes > 0 {
+		maxBytes = cfg.MaxBytes
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fetchCtx, cancel := context.WithTimeout(context.WithValue(ctx, mediaFetchPolicyKey{}, cfg), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("media fetch %s: unexpected status %d", u.Redacted(), resp.StatusCode)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("media fetch %s: %d bytes exceeds limit of %d", u.Redacted(), resp.ContentLength, maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("media fetch %s: body exceeds limit of %d bytes", u.Redacted(), maxBytes)
+	}
+	media := &FetchedMedia{MimeType: sniffMediaType(resp.Header.Get("Content-Type"), u.Path, data), Data: data}
+	if cfg.CacheTTLSeconds >= 0 {
+		ttl := defaultMediaFetchCacheTTL
+		if cfg.CacheTTLSeconds > 0 {
+			ttl = time.Duration(cfg.CacheTTLSeconds) * time.Second
+		}
+		f.store(key, media, ttl)
+	}
+	return media, nil
+}
+
+// sniffMediaType prefers a specific Content-Type header, then the URL extension from the
+// misc MIME table, then content sniffing.
+func sniffMediaType(header, urlPath string, data []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(header); err == nil {
+		if mediaType != "application/octet-stream" && mediaType != "binary/octet-stream" && mediaType != "text/plain" {
+			return mediaType
+		}
+	}
+	if ext := strings.TrimPrefix(strings.ToLower(path.Ext(urlPath)), "."); ext != "" {
+		if mediaType, ok := misc.MimeTypes[ext]; ok {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return mediaType
+}
+
+func mediaCacheKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *MediaFetcher) cached(key string) *FetchedMedia {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		f.cacheBytes -= int64(len(entry.media.Data))
+		delete(f.cache, key)
+		return nil
+	}
+	return entry.media
+}
+
+func (f *MediaFetcher) store(key string, media *FetchedMedia, ttl time.Duration) {
+	size := int64(len(media.Data))
+	if size > mediaCacheMaxBytes/4 {
+		return
+	}
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if old, ok := f.cache[key]; ok {
+		f.cacheBytes -= int64(len(old.media.Data))
+	}
+	f.cache[key] = &mediaCacheEntry{media: media, expires: now.Add(ttl), stored: now}
+	f.cacheBytes += size
+	for f.cacheBytes > mediaCacheMaxBytes {
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range f.cache {
+			if now.After(e.expires) {
+				oldestKey = k
+				break
+			}
+			if oldestKey == "" || e.stored.Before(oldest) {
+				oldestKey, oldest = k, e.stored
+			}
+		}
+		f.cacheBytes -= int64(len(f.cache[oldestKey].media.Data))
+		delete(f.cache, oldestKey)
+	}
+}
+
+// InlineRemoteMedia rewrites http(s) media references in an OpenAI chat, OpenAI responses,
+// Claude or Gemini request body into inline base64 data. It reports whether the body changed.
+// Only the rewritten fields are spliced into the body, so numbers and key order elsewhere
+// are preserved. Handled shapes:
+//   - "image_url": "<url>" and "image_url": {"url": "<url>"}
+//   - "file_url": "<url>" (responses input_file) -> "file_data" data URL
+//   - Claude "source": {"type": "url", "url": "<url>"} -> base64 source
+//   - Gemini "fileData": {"fileUri": "<url>"} -> "inlineData"
+func (f *MediaFetcher) InlineRemoteMedia(ctx context.Context, cfg config.MediaFetchConfig, raw []byte) ([]byte, bool, error) {
+	if len(raw) == 0 || !strings.Contains(string(raw), "http") || !gjson.ValidBytes(raw) {
+		return raw, false, nil
+	}
+	var edits []mediaEdit
+	if err := f.collectMediaEdits(ctx, cfg, gjson.ParseBytes(raw), "", &edits); err != nil || len(edits) == 0 {
+		return raw, false, err
+	}
+	out := raw
+	var err error
+	for _, edit := range edits {
+		if edit.remove {
+			out, err = sjson.DeleteBytes(out, edit.path)
+		} else {
+			out, err = sjson.SetBytes(out, edit.path, edit.value)
+		}
+		if err != nil {
+			return raw, false, err
+		}
+	}
+	return out, true, nil
+}
+
+// mediaEdit is one change to a request body: setting the value at path or removing it.
+type mediaEdit struct {
+	path   string
+	value  any
+	remove bool
+}
+
+func (f *MediaFetcher) collectMediaEdits(ctx context.Context, cfg config.MediaFetchConfig, node gjson.Result, prefix string, edits *[]mediaEdit) error {
+	if node.IsObject() {
+		if err := f.objectMediaEdits(ctx, cfg, node, prefix, edits); err != nil {
+			return err
+		}
+	} else if !node.IsArray() {
+		return nil
+	}
+	var err error
+	index := 0
+	node.ForEach(func(key, child gjson.Result) bool {
+		segment := strconv.Itoa(index)
+		if node.IsObject() {
+			segment = gjson.Escape(key.String())
+		}
+		index++
+		if child.IsObject() || child.IsArray() {
+			err = f.collectMediaEdits(ctx, cfg, child, joinMediaPath(prefix, segment), edits)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func (f *MediaFetcher) objectMediaEdits(ctx context.Context, cfg config.MediaFetchConfig, obj gjson.Result, prefix string, edits *[]mediaEdit) error {
+	set := func(key string, value any) {
+		*edits = append(*edits, mediaEdit{path: joinMediaPath(prefix, key), value: value})
+	}
+	remove := func(key string) {
+		*edits = append(*edits, mediaEdit{path: joinMediaPath(prefix, key), remove: true})
+	}
+
+	imageURL := obj.Get("image_url")
+	if imageURL.IsObject() {
+		if u := imageURL.Get("url"); u.Type == gjson.String && isRemoteURL(u.String()) {
+			media, err := f.fetchKind(ctx, cfg, u.String(), "image/")
+			if err != nil {
+				return err
+			}
+			set("image_url.url", media.DataURL())
+		}
+	} else if imageURL.Type == gjson.String && isRemoteURL(imageURL.String()) {
+		media, err := f.fetchKind(ctx, cfg, imageURL.String(), "image/")
+		if err != nil {
+			return err
+		}
+		set("image_url", media.DataURL())
+	}
+	if fileURL := obj.Get("file_url"); fileURL.Type == gjson.String && isRemoteURL(fileURL.String()) {
+		u := fileURL.String()
+		media, err := f.fetchKind(ctx, cfg, u, "")
+		if err != nil {
+			return err
+		}
+		remove("file_url")
+		set("file_data", media.DataURL())
+		if !obj.Get("filename").Exists() {
+			set("filename", path.Base(strings.SplitN(u, "?", 2)[0]))
+		}
+	}
+	if source := obj.Get("source"); source.IsObject() && strings.TrimSpace(source.Get("type").String()) == "url" {
+		if u := strings.TrimSpace(source.Get("url").String()); isRemoteURL(u) {
+			media, err := f.fetchKind(ctx, cfg, u, "")
+			if err != nil {
+				return err
+			}
+			remove("source.url")
+			set("source.type", "base64")
+			set("source.media_type", media.MimeType)
+			set("source.data", base64.StdEncoding.EncodeToString(media.Data))
+		}
+	}
+	for _, key := range []string{"fileData", "file_data"} {
+		fileData := obj.Get(key)
+		if !fileData.IsObject() {
+			continue
+		}
+		uri := strings.TrimSpace(fileData.Get("fileUri").String())
+		if uri == "" {
+			uri = strings.TrimSpace(fileData.Get("file_uri").String())
+		}
+		if !isRemoteURL(uri) {
+			continue
+		}
+		media, err := f.fetchKind(ctx, cfg, uri, "")
+		if err != nil {
+			return err
+		}
+		remove(key)
+		set("inlineData.mimeType", media.MimeType)
+		set("inlineData.data", base64.StdEncoding.EncodeToString(media.Data))
+	}
+	return nil
+}
+
+func joinMediaPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// fetchKind downloads u and, when wantPrefix is set, requires the sniffed MIME type to match it.
+func (f *MediaFetcher) fetchKind(ctx context.Context, cfg config.MediaFetchConfig, u, wantPrefix string) (*FetchedMedia, error) {
+	media, err := f.Fetch(ctx, cfg, u)
+	if err != nil {
+		return nil, err
+	}
+	if wantPrefix != "" && !strings.HasPrefix(media.MimeType, wantPrefix) {
+		return nil, fmt.Errorf("media fetch %s: content type %s is not %s*", redactURL(u), media.MimeType, wantPrefix)
+	}
+	return media, nil
+}
+
+func isRemoteURL(u string) bool {
+	lower := strings.ToLower(strings.TrimSpace(u))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+func redactURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		return u.Redacted()
+	}
+	return raw
+}
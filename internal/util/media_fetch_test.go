@@ -0,0 +1,98 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestMediaFetcherBlocksPrivateDestinations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+
+	f := NewMediaFetcher()
+	if _, err := f.Fetch(context.Background(), config.MediaFetchConfig{}, srv.URL+"/a.png"); !errors.Is(err, ErrMediaFetchBlocked) {
+		t.Fatalf("Fetch() error = %v, want ErrMediaFetchBlocked", err)
+	}
+	if _, err := f.Fetch(context.Background(), config.MediaFetchConfig{AllowPrivateNetworks: true, AllowedHosts: []string{"*.example.com"}}, srv.URL+"/a.png"); !errors.Is(err, ErrMediaFetchBlocked) {
+		t.Fatalf("Fetch() with allow-list error = %v, want ErrMediaFetchBlocked", err)
+	}
+	if _, err := f.Fetch(context.Background(), config.MediaFetchConfig{}, "file:///etc/passwd"); !errors.Is(err, ErrMediaFetchBlocked) {
+		t.Fatalf("Fetch(file://) error = %v, want ErrMediaFetchBlocked", err)
+	}
+}
+
+func TestMediaFetcherInlinesAndCaches(t *testing.T) {
+	var hits atomic.Int32
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if strings.HasSuffix(r.URL.Path, "big.png") {
+			_, _ = w.Write(make([]byte, 64))
+			return
+		}
+		_, _ = w.Write(png)
+	}))
+	defer srv.Close()
+
+	cfg := config.MediaFetchConfig{Enabled: true, AllowPrivateNetworks: true, MaxBytes: 32}
+	f := NewMediaFetcher()
+	body := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"` + srv.URL + `/cat.png"}},{"type":"text","text":"hi"}]}]}`)
+	for i := 0; i < 2; i++ {
+		out, changed, err := f.InlineRemoteMedia(context.Background(), cfg, body)
+		if err != nil || !changed {
+			t.Fatalf("InlineRemoteMedia() = changed %v, err %v", changed, err)
+		}
+		if got := gjson.GetBytes(out, "messages.0.content.0.image_url.url").String(); got != "data:image/png;base64,iVBORw0KGgowMDAw" {
+			t.Fatalf("inlined url = %q", got)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("server hits = %d, want 1 (cached)", hits.Load())
+	}
+
+	claude := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"` + srv.URL + `/dog.png"}}]}]}`)
+	out, _, err := f.InlineRemoteMedia(context.Background(), cfg, claude)
+	if err != nil {
+		t.Fatalf("InlineRemoteMedia(claude) error = %v", err)
+	}
+	if gjson.GetBytes(out, "messages.0.content.0.source.type").String() != "base64" || gjson.GetBytes(out, "messages.0.content.0.source.media_type").String() != "image/png" {
+		t.Fatalf("unexpected claude source %s", gjson.GetBytes(out, "messages.0.content.0.source").Raw)
+	}
+
+	if _, err = f.Fetch(context.Background(), cfg, srv.URL+"/big.png"); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Fatalf("Fetch(big) error = %v, want size limit", err)
+	}
+}
+
+func TestInlineRemoteMediaPreservesNumbersAndOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	defer srv.Close()
+
+	cfg := config.MediaFetchConfig{Enabled: true, AllowPrivateNetworks: true}
+	body := []byte(`{"seed":12345678901234567890,"contents":[{"parts":[{"fileData":{"mimeType":"image/png","fileUri":"` + srv.URL + `/a.png"}},{"text":"x.y"}]}],"a.b":1}`)
+	out, changed, err := NewMediaFetcher().InlineRemoteMedia(context.Background(), cfg, body)
+	if err != nil || !changed {
+		t.Fatalf("InlineRemoteMedia() = changed %v, err %v", changed, err)
+	}
+	if !strings.HasPrefix(string(out), `{"seed":12345678901234567890,"contents":`) || !strings.HasSuffix(string(out), `"a.b":1}`) {
+		t.Fatalf("body outside the media part changed: %s", out)
+	}
+	part := gjson.GetBytes(out, "contents.0.parts.0")
+	if part.Get("fileData").Exists() || part.Get("inlineData.mimeType").String() != "image/png" || part.Get("inlineData.data").String() == "" {
+		t.Fatalf("unexpected gemini part %s", part.Raw)
+	}
+}
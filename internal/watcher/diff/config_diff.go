@@ -54,6 +54,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
+	if oldCfg.StructuredOutputRetry != newCfg.StructuredOutputRetry {
+		changes = append(changes, fmt.Sprintf("structured-output-retry: %t -> %t", oldCfg.StructuredOutputRetry, newCfg.StructuredOutputRetry))
+	}
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
@@ -65,6 +68,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.QuotaExceeded.SwitchPreviewModel != newCfg.QuotaExceeded.SwitchPreviewModel {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
+	if oldCfg.Quarantine.FailureThreshold != newCfg.Quarantine.FailureThreshold {
+		changes = append(changes, fmt.Sprintf("quarantine.failure-threshold: %d -> %d", oldCfg.Quarantine.FailureThreshold, newCfg.Quarantine.FailureThreshold))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
@@ -103,6 +109,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if oldExcluded.hash != newExcluded.hash {
 				changes = append(changes, fmt.Sprintf("gemini[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
 			}
+			if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].schedule: updated", i))
+			}
 		}
 	}
 
@@ -138,6 +147,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if oldExcluded.hash != newExcluded.hash {
 				changes = append(changes, fmt.Sprintf("claude[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
 			}
+			if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+				changes = append(changes, fmt.Sprintf("claude[%d].schedule: updated", i))
+			}
 		}
 	}
 
@@ -173,6 +185,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if oldExcluded.hash != newExcluded.hash {
 				changes = append(changes, fmt.Sprintf("codex[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
 			}
+			if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+				changes = append(changes, fmt.Sprintf("codex[%d].schedule: updated", i))
+			}
 		}
 	}
 
@@ -238,6 +253,13 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	changes = append(changes, diffManagementKeys(oldCfg.RemoteManagement.Keys, newCfg.RemoteManagement.Keys)...)
+	if !reflect.DeepEqual(oldCfg.Notifications, newCfg.Notifications) {
+		changes = append(changes, fmt.Sprintf("notifications.webhooks: updated (%d -> %d)", len(oldCfg.Notifications.Webhooks), len(newCfg.Notifications.Webhooks)))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing, newCfg.Pricing) {
+		changes = append(changes, fmt.Sprintf("pricing: updated (%d -> %d model price(s), %d -> %d budget(s))", len(oldCfg.Pricing.Models), len(newCfg.Pricing.Models), len(oldCfg.Pricing.Budgets), len(newCfg.Pricing.Budgets)))
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
@@ -274,6 +296,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].headers: updated", i))
 			}
+			if !reflect.DeepEqual(o.Schedule, n.Schedule) {
+				changes = append(changes, fmt.Sprintf("vertex[%d].schedule: updated", i))
+			}
 		}
 	}
 
@@ -367,3 +392,33 @@ func equalUpstreamAPIKeys(a, b []config.AmpUpstreamAPIKeyEntry) bool {
 	}
 	return true
 }
+
+// diffManagementKeys summarizes named management key changes without revealing key values.
+func diffManagementKeys(oldKeys, newKeys []config.ManagementKey) []string {
+	oldByName := make(map[string]config.ManagementKey, len(oldKeys))
+	for _, key := range oldKeys {
+		oldByName[key.Name] = key
+	}
+	var changes []string
+	seen := make(map[string]bool, len(newKeys))
+	for _, key := range newKeys {
+		seen[key.Name] = true
+		previous, ok := oldByName[key.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("remote-management.keys[%s]: created (scopes: %s)", key.Name, strings.Join(key.Scopes, ", ")))
+			continue
+		}
+		if previous.Key != key.Key {
+			changes = append(changes, fmt.Sprintf("remote-management.keys[%s].key: updated", key.Name))
+		}
+		if strings.Join(previous.Scopes, ",") != strings.Join(key.Scopes, ",") {
+			changes = append(changes, fmt.Sprintf("remote-management.keys[%s].scopes: [%s] -> [%s]", key.Name, strings.Join(previous.Scopes, ", "), strings.Join(key.Scopes, ", ")))
+		}
+	}
+	for _, key := range oldKeys {
+		if !seen[key.Name] {
+			changes = append(changes, fmt.Sprintf("remote-management.keys[%s]: deleted", key.Name))
+		}
+	}
+	return changes
+}
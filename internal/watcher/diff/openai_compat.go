@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -75,6 +76,16 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if oldEntry.Discovery.Enabled != newEntry.Discovery.Enabled {
+		details = append(details, fmt.Sprintf("discovery %t -> %t", oldEntry.Discovery.Enabled, newEntry.Discovery.Enabled))
+	} else if oldEntry.Discovery.IntervalSeconds != newEntry.Discovery.IntervalSeconds ||
+		strings.Join(oldEntry.Discovery.Include, ",") != strings.Join(newEntry.Discovery.Include, ",") ||
+		strings.Join(oldEntry.Discovery.Exclude, ",") != strings.Join(newEntry.Discovery.Exclude, ",") {
+		details = append(details, "discovery updated")
+	}
+	if oldKeyCount == newKeyCount && !sameKeySchedules(oldEntry.APIKeyEntries, newEntry.APIKeyEntries) {
+		details = append(details, "schedules updated")
+	}
 	if len(details) == 0 {
 		return ""
 	}
@@ -91,6 +102,18 @@ func countAPIKeys(entry config.OpenAICompatibility) int {
 	return count
 }
 
+func sameKeySchedules(a, b []config.OpenAICompatibilityAPIKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !reflect.DeepEqual(a[i].Schedule, b[i].Schedule) {
+			return false
+		}
+	}
+	return true
+}
+
 func countOpenAIModels(models []config.OpenAICompatibilityModel) int {
 	count := 0
 	for _, model := range models {
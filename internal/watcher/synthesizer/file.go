@@ -102,6 +102,18 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			UpdatedAt: now,
 		}
 		ApplyAuthExcludedModelsMeta(a, cfg, nil, "oauth")
+		if disabled, ok := metadata["disabled"].(bool); ok && disabled {
+			// Credentials disabled via the management API stay disabled across reloads.
+			a.Disabled = true
+			a.Status = coreauth.StatusDisabled
+			out = append(out, a)
+			continue
+		}
+		if coreauth.RestoreQuarantine(a) {
+			// Quarantined credentials wait for a re-enable or a new login.
+			out = append(out, a)
+			continue
+		}
 		if provider == "gemini-cli" {
 			if virtuals := SynthesizeGeminiVirtualAuths(a, metadata, now); len(virtuals) > 0 {
 				for _, v := range virtuals {
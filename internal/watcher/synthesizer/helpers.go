@@ -3,6 +3,7 @@ package synthesizer
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -108,3 +109,16 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addScheduleToAttrs stores a key entry's availability schedule as JSON under the
+// "schedule" attribute, where the auth manager picks it up.
+func addScheduleToAttrs(schedule *config.CredentialSchedule, attrs map[string]string) {
+	if schedule == nil || attrs == nil {
+		return
+	}
+	raw, err := json.Marshal(schedule)
+	if err != nil {
+		return
+	}
+	attrs[coreauth.ScheduleKey] = string(raw)
+}
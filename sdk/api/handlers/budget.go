@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// checkBudget rejects requests from client keys whose spend budget for the model is
+// exhausted. Like the context limit check it runs before credential selection.
+func (h *BaseAPIHandler) checkBudget(ctx context.Context, handlerType, modelName string) *interfaces.ErrorMessage {
+	status := usage.CheckBudget(clientAPIKey(ctx), modelName)
+	if status == nil {
+		return nil
+	}
+	message := fmt.Sprintf("budget %q exhausted: spent %.4f of %.4f %s", status.Name, status.Spent, status.Limit, status.Currency)
+	var addon http.Header
+	if status.ResetsAt != nil {
+		message += " (resets at " + status.ResetsAt.Format(time.RFC3339) + ")"
+		addon = http.Header{}
+		addon.Set("Retry-After", strconv.Itoa(int(time.Until(*status.ResetsAt).Seconds())+1))
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      errors.New(budgetErrorBody(handlerType, message)),
+		Addon:      addon,
+	}
+}
+
+// budgetErrorBody renders the rejection in the client protocol's native quota error shape.
+func budgetErrorBody(handlerType, message string) string {
+	quoted := strconv.Quote(message)
+	switch handlerType {
+	case constant.Claude:
+		return `{"type":"error","error":{"type":"rate_limit_error","message":` + quoted + `}}`
+	case constant.Gemini, constant.GeminiCLI:
+		return `{"error":{"code":429,"message":` + quoted + `,"status":"RESOURCE_EXHAUSTED"}}`
+	default:
+		return `{"error":{"message":` + quoted + `,"type":"insufficient_quota","code":"budget_exceeded"}}`
+	}
+}
+
+func clientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	key, _ := ginCtx.Get("apiKey")
+	value, _ := key.(string)
+	return value
+}
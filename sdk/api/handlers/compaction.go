@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// CompactionHeader opts a request into context compaction (value "middle-out").
+	CompactionHeader = "X-Context-Compaction"
+	// CompactedMessagesHeader reports how many messages compaction removed.
+	CompactedMessagesHeader = "X-Context-Compacted-Messages"
+
+	compactionMiddleOut   = "middle-out"
+	compactionModelSuffix = ":" + compactionMiddleOut
+
+	// compactionKeepRecent is the number of trailing conversation units never removed.
+	compactionKeepRecent = 4
+)
+
+// compactionRequested reports whether the request opted into middle-out compaction via the
+// model suffix (e.g. "claude-sonnet-4-5:middle-out") or the X-Context-Compaction header.
+// The returned model name has the suffix stripped.
+func compactionRequested(ctx context.Context, modelName string) (string, bool) {
+	if trimmed, ok := strings.CutSuffix(modelName, compactionModelSuffix); ok && trimmed != "" {
+		return trimmed, true
+	}
+	if ctx == nil {
+		return modelName, false
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if strings.EqualFold(strings.TrimSpace(ginCtx.Request.Header.Get(CompactionHeader)), compactionMiddleOut) {
+			return modelName, true
+		}
+	}
+	return modelName, false
+}
+
+// compactContext trims the middle of an over-long conversation so it fits the model's input
+// window and reports the number of removed messages through a response header.
+func (h *BaseAPIHandler) compactContext(ctx context.Context, modelName string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil {
+		return rawJSON
+	}
+	limit := h.contextLimitFor(modelName)
+	if limit <= 0 {
+		return rawJSON
+	}
+	out, removed := compactMiddleOut(modelName, rawJSON, limit)
+	if removed == 0 {
+		return rawJSON
+	}
+	log.Debugf("context compaction removed %d messages for %s", removed, modelName)
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(CompactedMessagesHeader, strconv.Itoa(removed))
+	}
+	return out
+}
+
+// conversationUnit is a run of messages that must be kept or removed together, such as an
+// assistant tool call and the tool results answering it.
+type conversationUnit struct {
+	start, end int
+	tokens     int64
+	protected  bool
+}
+
+// compactMiddleOut removes whole conversation units, starting from the middle and working
+// outwards, until the estimated prompt fits limit. System messages, the first user turn and
+// the most recent units are always kept. It works on the client formats (OpenAI chat,
+// Responses, Claude messages, Gemini contents) before translation.
+func compactMiddleOut(modelName string, rawJSON []byte, limit int64) ([]byte, int) {
+	path := conversationPath(rawJSON)
+	if path == "" {
+		return rawJSON, 0
+	}
+	total, err := executor.EstimateInputTokens(modelName, rawJSON)
+	if err != nil || total <= limit {
+		return rawJSON, 0
+	}
+
+	messages := gjson.GetBytes(rawJSON, path).Array()
+	units := groupConversationUnits(messages)
+	for i := range units {
+		for _, msg := range messages[units[i].start:units[i].end] {
+			tokens, _ := executor.EstimateInputTokens(modelName, []byte(msg.Raw))
+			units[i].tokens += tokens
+		}
+	}
+
+	// The first unit opened by a user message anchors the task; recent units hold the live state.
+	firstUser := -1
+	for i, unit := range units {
+		if !unit.protected && messageRole(messages[unit.start]) == "user" {
+			firstUser = i
+			units[i].protected = true
+			break
+		}
+	}
+	for i := len(units) - 1; i >= 0 && i >= len(units)-compactionKeepRecent; i-- {
+		units[i].protected = true
+	}
+
+	// Candidates are visited from the middle outwards, alternating sides.
+	lo, hi := firstUser+1, len(units)-compactionKeepRecent
+	if hi <= lo {
+		return rawJSON, 0
+	}
+	mid := lo + (hi-lo)/2
+	order := make([]int, 0, hi-lo)
+	for step := 0; len(order) < hi-lo; step++ {
+		if i := mid + step; i < hi {
+			order = append(order, i)
+		}
+		if i := mid - step - 1; i >= lo {
+			order = append(order, i)
+		}
+	}
+
+	dropped := make(map[int]bool)
+	removed := 0
+	for _, idx := range order {
+		if total <= limit {
+			break
+		}
+		if units[idx].protected {
+			continue
+		}
+		dropped[idx] = true
+		total -= units[idx].tokens
+		removed += units[idx].end - units[idx].start
+	}
+	if removed == 0 {
+		return rawJSON, 0
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	first := true
+	for i, unit := range units {
+		if dropped[i] {
+			continue
+		}
+		for _, msg := range messages[unit.start:unit.end] {
+			if !first {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(msg.Raw)
+			first = false
+		}
+	}
+	buf.WriteByte(']')
+	out, errSet := sjson.SetRawBytes(rawJSON, path, buf.Bytes())
+	if errSet != nil {
+		return rawJSON, 0
+	}
+	return out, removed
+}
+
+// conversationPath locates the message list across client request formats.
+func conversationPath(rawJSON []byte) string {
+	for _, path := range []string{"messages", "contents", "request.contents", "input"} {
+		if gjson.GetBytes(rawJSON, path).IsArray() {
+			return path
+		}
+	}
+	return ""
+}
+
+// groupConversationUnits splits messages into removable units. Tool results join the unit of
+// the call they answer, and system messages become protected single-message units.
+func groupConversationUnits(messages []gjson.Result) []conversationUnit {
+	units := make([]conversationUnit, 0, len(messages))
+	for i, msg := range messages {
+		role := messageRole(msg)
+		if role == "system" || role == "developer" {
+			units = append(units, conversationUnit{start: i, end: i + 1, protected: true})
+			continue
+		}
+		if len(units) > 0 && !units[len(units)-1].protected && continuesUnit(messages[i-1], msg) {
+			units[len(units)-1].end = i + 1
+			continue
+		}
+		units = append(units, conversationUnit{start: i, end: i + 1})
+	}
+	return units
+}
+
+// continuesUnit reports whether msg belongs to the same unit as prev because it answers or
+// extends a tool call.
+func continuesUnit(prev, msg gjson.Result) bool {
+	if msg.Get("role").String() == "tool" {
+		return true
+	}
+	for _, block := range msg.Get("content").Array() {
+		if block.Get("type").String() == "tool_result" {
+			return true
+		}
+	}
+	for _, part := range msg.Get("parts").Array() {
+		if part.Get("functionResponse").Exists() {
+			return true
+		}
+	}
+	// Responses items: reasoning, calls and outputs chain until the next message item.
+	itemType := msg.Get("type").String()
+	if itemType == "function_call_output" || itemType == "custom_tool_call_output" {
+		return true
+	}
+	prevType := prev.Get("type").String()
+	return itemType != "" && itemType != "message" && prevType != "" && prevType != "message"
+}
+
+func messageRole(msg gjson.Result) string {
+	return msg.Get("role").String()
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestCompactMiddleOut_KeepsAnchorsAndToolPairs(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 60)
+	msgs := []string{
+		`{"role":"system","content":"You are an agent."}`,
+		`{"role":"user","content":"Original task description"}`,
+	}
+	for i := 0; i < 8; i++ {
+		msgs = append(msgs,
+			fmt.Sprintf(`{"role":"assistant","content":null,"tool_calls":[{"id":"call_%d","type":"function","function":{"name":"read","arguments":"{}"}}]}`, i),
+			fmt.Sprintf(`{"role":"tool","tool_call_id":"call_%d","content":"%s"}`, i, filler),
+		)
+	}
+	msgs = append(msgs, `{"role":"user","content":"What next?"}`)
+	raw := []byte(`{"model":"gpt-5","messages":[` + strings.Join(msgs, ",") + `]}`)
+
+	out, removed := compactMiddleOut("gpt-5", raw, 1500)
+	if removed == 0 || removed%2 != 0 {
+		t.Fatalf("removed = %d, want an even number of messages (whole tool pairs)", removed)
+	}
+	kept := gjson.GetBytes(out, "messages").Array()
+	if len(kept) != len(msgs)-removed {
+		t.Fatalf("kept %d messages, want %d", len(kept), len(msgs)-removed)
+	}
+	if kept[0].Get("role").String() != "system" || kept[1].Get("content").String() != "Original task description" {
+		t.Fatalf("anchors not preserved: %s", gjson.GetBytes(out, "messages").Raw)
+	}
+	if kept[len(kept)-1].Get("content").String() != "What next?" {
+		t.Fatalf("latest turn not preserved")
+	}
+	calls := map[string]bool{}
+	for _, msg := range kept {
+		if id := msg.Get("tool_calls.0.id").String(); id != "" {
+			calls[id] = true
+		}
+		if id := msg.Get("tool_call_id").String(); id != "" && !calls[id] {
+			t.Fatalf("tool result %s kept without its call", id)
+		}
+	}
+	if _, again := compactMiddleOut("gpt-5", raw, 1_000_000); again != 0 {
+		t.Fatalf("fitting conversation must not be compacted")
+	}
+}
+
+func TestCompactionRequested_SuffixAndHeader(t *testing.T) {
+	if model, ok := compactionRequested(context.Background(), "claude-sonnet-4-5:middle-out"); !ok || model != "claude-sonnet-4-5" {
+		t.Fatalf("suffix not recognised: %q %v", model, ok)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	ginCtx.Request.Header.Set(CompactionHeader, "middle-out")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	if model, ok := compactionRequested(ctx, "gemini-2.5-pro"); !ok || model != "gemini-2.5-pro" {
+		t.Fatalf("header not recognised: %q %v", model, ok)
+	}
+
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ContextLimits: sdkconfig.ContextLimitsConfig{
+		Models: []sdkconfig.ContextLimitModel{{Name: "claude-*", InputTokens: 400}},
+	}}}
+	turns := make([]string, 0, 12)
+	for i := 0; i < 12; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		turns = append(turns, fmt.Sprintf(`{"role":"%s","content":"turn %d %s"}`, role, i, strings.Repeat("words ", 40)))
+	}
+	raw := []byte(`{"system":"be brief","messages":[` + strings.Join(turns, ",") + `]}`)
+	out := h.compactContext(ctx, "claude-sonnet-4-5", raw)
+	if got := recorder.Header().Get(CompactedMessagesHeader); got == "" || got == "0" {
+		t.Fatalf("compacted header = %q", got)
+	}
+	if n := len(gjson.GetBytes(out, "messages").Array()); n >= len(turns) {
+		t.Fatalf("messages not compacted: %d", n)
+	}
+}
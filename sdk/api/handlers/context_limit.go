@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkContextLimit estimates the prompt size and rejects requests that cannot fit the
+// model's input window. It runs before credential selection so oversized prompts never
+// reach upstream providers or count against credential health.
+func (h *BaseAPIHandler) checkContextLimit(handlerType, modelName string, rawJSON []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || !h.Cfg.ContextLimits.Enabled {
+		return nil
+	}
+	limit := h.contextLimitFor(modelName)
+	if limit <= 0 {
+		return nil
+	}
+	// Every token spans at least one byte and long base64 media is costed below two tokens per
+	// byte, so small payloads cannot exceed the limit and skip tokenization. Data URLs are
+	// costed per image whatever their size, so payloads carrying them are always estimated.
+	if int64(len(rawJSON))*2 <= limit && !bytes.Contains(rawJSON, []byte(`"data:`)) {
+		return nil
+	}
+	estimate, err := executor.EstimateInputTokens(modelName, rawJSON)
+	if err != nil {
+		log.Debugf("context limit: token estimate failed for %s: %v", modelName, err)
+		return nil
+	}
+	if estimate <= limit {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      errors.New(contextLimitErrorBody(handlerType, estimate, limit)),
+	}
+}
+
+// contextLimitFor resolves the input token limit for a model from configuration overrides,
+// falling back to registry metadata. It returns 0 when no limit applies.
+func (h *BaseAPIHandler) contextLimitFor(modelName string) int64 {
+	for _, entry := range h.Cfg.ContextLimits.Models {
+		if !executor.MatchModelPattern(strings.TrimSpace(entry.Name), modelName) {
+			continue
+		}
+		if entry.InputTokens < 0 {
+			return 0
+		}
+		if entry.InputTokens > 0 {
+			return int64(entry.InputTokens)
+		}
+		break
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(modelName)
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return int64(info.InputTokenLimit)
+	}
+	return int64(info.ContextLength)
+}
+
+// contextLimitErrorBody renders the rejection in the client protocol's native error shape,
+// mirroring the messages providers return so clients can detect context overflows.
+func contextLimitErrorBody(handlerType string, estimate, limit int64) string {
+	switch handlerType {
+	case constant.Claude:
+		return fmt.Sprintf(`{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: %d tokens > %d maximum"}}`, estimate, limit)
+	case constant.Gemini, constant.GeminiCLI:
+		return fmt.Sprintf(`{"error":{"code":400,"message":"The input token count (%d) exceeds the maximum number of tokens allowed (%d).","status":"INVALID_ARGUMENT"}}`, estimate, limit)
+	default:
+		param := "messages"
+		if handlerType == constant.OpenaiResponse {
+			param = "input"
+		}
+		return fmt.Sprintf(`{"error":{"message":"This model's maximum context length is %d tokens. However, your request was estimated at %d tokens. Please reduce the length of the %s.","type":"invalid_request_error","param":"%s","code":"context_length_exceeded"}}`, limit, estimate, param, param)
+	}
+}
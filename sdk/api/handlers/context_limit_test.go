@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestCheckContextLimit_RejectsWithProviderShapedError(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{ContextLimits: sdkconfig.ContextLimitsConfig{
+		Enabled: true,
+		Models: []sdkconfig.ContextLimitModel{
+			{Name: "claude-*", InputTokens: 50},
+			{Name: "gpt-*", InputTokens: -1},
+			{Name: "gemini-test", InputTokens: 50},
+		},
+	}}
+	h := &BaseAPIHandler{Cfg: cfg}
+	long := strings.Repeat("the quick brown fox jumps over the lazy dog ", 40)
+
+	claudeReq := []byte(`{"system":"be brief","messages":[{"role":"user","content":"` + long + `"}]}`)
+	errMsg := h.checkContextLimit("claude", "claude-sonnet-4-5", claudeReq)
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for oversized claude prompt, got %+v", errMsg)
+	}
+	body := BuildErrorResponseBody(errMsg.StatusCode, errMsg.Error.Error())
+	if gjson.GetBytes(body, "type").String() != "error" || !strings.HasPrefix(gjson.GetBytes(body, "error.message").String(), "prompt is too long") {
+		t.Fatalf("unexpected claude error body %s", body)
+	}
+
+	geminiReq := []byte(`{"contents":[{"role":"user","parts":[{"text":"` + long + `"}]}]}`)
+	errMsg = h.checkContextLimit("gemini", "gemini-test", geminiReq)
+	if errMsg == nil || gjson.Get(errMsg.Error.Error(), "error.status").String() != "INVALID_ARGUMENT" {
+		t.Fatalf("unexpected gemini rejection %+v", errMsg)
+	}
+
+	openaiReq := []byte(`{"messages":[{"role":"user","content":"` + long + `"}]}`)
+	if errMsg = h.checkContextLimit("openai", "gpt-5", openaiReq); errMsg != nil {
+		t.Fatalf("negative override must disable the check, got %v", errMsg.Error)
+	}
+	if errMsg = h.checkContextLimit("claude", "claude-sonnet-4-5", []byte(`{"messages":[{"role":"user","content":"hi"}]}`)); errMsg != nil {
+		t.Fatalf("small prompt rejected: %v", errMsg.Error)
+	}
+}
+
+func TestCheckContextLimit_CountsSmallInlineImages(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{ContextLimits: sdkconfig.ContextLimitsConfig{
+		Enabled: true,
+		Models:  []sdkconfig.ContextLimitModel{{Name: "gpt-*", InputTokens: 2000}},
+	}}
+	h := &BaseAPIHandler{Cfg: cfg}
+	image := `{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}}`
+	parts := strings.TrimSuffix(strings.Repeat(image+",", 4), ",")
+	req := []byte(`{"messages":[{"role":"user","content":[` + parts + `]}]}`)
+	if int64(len(req))*2 > 2000 {
+		t.Fatalf("test payload too large to exercise the size shortcut: %d bytes", len(req))
+	}
+	if errMsg := h.checkContextLimit("openai", "gpt-5", req); errMsg == nil {
+		t.Fatal("expected small inline images to be counted against the limit")
+	}
+}
@@ -54,6 +54,7 @@ const idempotencyKeyMetadataKey = "idempotency_key"
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
+	defaultStreamingMidstreamRetries = 0
 )
 
 // BuildErrorResponseBody builds an OpenAI-compatible JSON error response body.
@@ -144,19 +145,41 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingMidstreamRetries returns how many times a streaming request that fails after bytes were
+// sent may be resumed with the partial output as prefill.
+func StreamingMidstreamRetries(cfg *config.SDKConfig) int {
+	retries := defaultStreamingMidstreamRetries
+	if cfg != nil {
+		retries = cfg.Streaming.MidstreamRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	return retries
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
 	key := ""
+	clientKey := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			// The client API key lets the auth manager queue requests fairly across clients.
+			if v, exists := ginCtx.Get("apiKey"); exists {
+				clientKey, _ = v.(string)
+			}
 		}
 	}
 	if key == "" {
 		key = uuid.NewString()
 	}
-	return map[string]any{idempotencyKeyMetadataKey: key}
+	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if clientKey != "" {
+		meta[coreauth.ClientKeyMetadataKey] = clientKey
+	}
+	return meta
 }
 
 func mergeMetadata(base, overlay map[string]any) map[string]any {
@@ -384,10 +407,23 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
+	modelName, compact := compactionRequested(ctx, modelName)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if compact {
+		rawJSON = h.compactContext(ctx, normalizedModel, rawJSON)
+	}
+	if errMsg = h.checkContextLimit(handlerType, normalizedModel, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
+	if errMsg = h.checkBudget(ctx, handlerType, normalizedModel); errMsg != nil {
+		return nil, errMsg
+	}
+	if rawJSON, errMsg = h.inlineRemoteMedia(ctx, providers, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -419,6 +455,10 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if rw := newResponseRewriter(ctx, h.Cfg, handlerType, modelName); rw != nil {
+		rw.applyHeaders(ctx)
+		return rw.rewriteBody(cloneBytes(resp.Payload)), nil
+	}
 	return cloneBytes(resp.Payload), nil
 }
 
@@ -429,6 +469,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if rawJSON, errMsg = h.inlineRemoteMedia(ctx, providers, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -466,6 +509,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	modelName, compact := compactionRequested(ctx, modelName)
 	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -473,6 +517,27 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	if compact {
+		rawJSON = h.compactContext(ctx, normalizedModel, rawJSON)
+	}
+	if errMsg = h.checkContextLimit(handlerType, normalizedModel, rawJSON); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	if errMsg = h.checkBudget(ctx, handlerType, normalizedModel); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	if rawJSON, errMsg = h.inlineRemoteMedia(ctx, providers, rawJSON); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -488,7 +553,13 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
-	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	recovery := h.newStreamRecovery(handlerType, alt, providers)
+	execCtx := ctx
+	var streamAuth *coreauth.StreamAuthRecorder
+	if recovery != nil {
+		execCtx, streamAuth = coreauth.WithStreamAuthRecorder(ctx)
+	}
+	chunks, err := h.AuthManager.ExecuteStream(execCtx, providers, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -507,6 +578,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	rewriter := newResponseRewriter(ctx, h.Cfg, handlerType, modelName)
+	rewriter.applyHeaders(ctx)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
@@ -515,6 +588,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		midstreamRetries := 0
+		maxMidstreamRetries := StreamingMidstreamRetries(h.Cfg)
 
 		bootstrapEligible := func(err error) bool {
 			status := statusFromError(err)
@@ -554,13 +629,21 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryChunks, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryChunks, retryErr := h.AuthManager.ExecuteStream(execCtx, providers, req, opts)
 							if retryErr == nil {
 								chunks = retryChunks
 								continue outer
 							}
 							streamErr = retryErr
 						}
+					} else if recovery != nil && midstreamRetries < maxMidstreamRetries && bootstrapEligible(streamErr) {
+						// Mid-stream recovery: continue the partial answer on another credential and
+						// splice the continuation into the same client stream.
+						midstreamRetries++
+						if retryChunks, ok := h.resumeStream(execCtx, recovery, streamAuth, providers, req, opts, streamErr); ok {
+							chunks = retryChunks
+							continue outer
+						}
 					}
 
 					status := http.StatusInternalServerError
@@ -579,8 +662,19 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					payload := chunk.Payload
+					if recovery != nil {
+						var errSplice error
+						if payload, errSplice = recovery.forward(payload); errSplice != nil {
+							errChan <- recovery.abandon(errSplice)
+							return
+						}
+						if len(payload) == 0 {
+							continue
+						}
+					}
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					dataChan <- rewriter.rewriteChunk(cloneBytes(payload))
 				}
 			}
 		}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// inlineRemoteMedia downloads http(s) media referenced by the request and inlines it as
+// base64 when media-fetch is enabled for at least one of the resolved providers.
+// Fetch failures are reported to the client as invalid requests.
+func (h *BaseAPIHandler) inlineRemoteMedia(ctx context.Context, providers []string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || !h.Cfg.MediaFetch.Enabled {
+		return rawJSON, nil
+	}
+	cfg := h.Cfg.MediaFetch
+	if len(cfg.Providers) > 0 && !anyProviderListed(providers, cfg.Providers) {
+		return rawJSON, nil
+	}
+	out, _, err := util.DefaultMediaFetcher().InlineRemoteMedia(ctx, cfg, rawJSON)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, context.Canceled) {
+			status = http.StatusRequestTimeout
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: fmt.Errorf("failed to fetch remote media: %w", err)}
+	}
+	return out, nil
+}
+
+func anyProviderListed(providers, allowed []string) bool {
+	for _, provider := range providers {
+		for _, candidate := range allowed {
+			if strings.EqualFold(strings.TrimSpace(candidate), provider) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,400 @@
+// Package openai provides HTTP handlers for the OpenAI Audio API endpoints.
+// Transcription and translation uploads are sent to a Gemini model as inline audio together
+// with an instruction to return timed segments, which are rendered in the requested format.
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// defaultAudioModel receives audio when neither the request nor the config names a served model.
+	defaultAudioModel = "gemini-2.5-flash"
+	// defaultAudioUploadBytes mirrors the OpenAI Audio API upload limit.
+	defaultAudioUploadBytes = 25 << 20
+)
+
+// audioMimeTypes maps the file extensions accepted by the OpenAI Audio API to Gemini audio MIME types.
+var audioMimeTypes = map[string]string{
+	".aac":  "audio/aac",
+	".aiff": "audio/aiff",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mp3",
+	".mp4":  "audio/mp4",
+	".mpeg": "audio/mpeg",
+	".mpga": "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+}
+
+// audioProviders lists the providers whose Gemini models accept inline audio.
+var audioProviders = map[string]struct{}{
+	"aistudio":   {},
+	"gemini":     {},
+	"gemini-cli": {},
+	"vertex":     {},
+}
+
+// audioTranscriptSchema constrains the Gemini response to timed segments.
+const audioTranscriptSchema = `{"type":"OBJECT","properties":{"language":{"type":"STRING"},"segments":{"type":"ARRAY","items":{"type":"OBJECT","properties":{"start":{"type":"NUMBER"},"end":{"type":"NUMBER"},"text":{"type":"STRING"}},"required":["start","end","text"]}}},"required":["segments"]}`
+
+// OpenAIAudioAPIHandler contains the handlers for the OpenAI Audio API endpoints.
+type OpenAIAudioAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIAudioAPIHandler creates a new OpenAI Audio API handlers instance.
+func NewOpenAIAudioAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIAudioAPIHandler {
+	return &OpenAIAudioAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIAudioAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIAudioAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// audioRequest is the normalized form of a transcription or translation request.
+type audioRequest struct {
+	model          string
+	prompt         string
+	language       string
+	responseFormat string
+	temperature    *float64
+	translate      bool
+	mimeType       string
+	data           string
+}
+
+// transcriptSegment is one timed piece of a transcript, in seconds from the start of the audio.
+type transcriptSegment struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+// transcript is the parsed model output.
+type transcript struct {
+	language string
+	segments []transcriptSegment
+	text     string
+}
+
+// Transcriptions handles the /v1/audio/transcriptions endpoint.
+func (h *OpenAIAudioAPIHandler) Transcriptions(c *gin.Context) {
+	h.handleAudio(c, false)
+}
+
+// Translations handles the /v1/audio/translations endpoint, which always produces English text.
+func (h *OpenAIAudioAPIHandler) Translations(c *gin.Context) {
+	h.handleAudio(c, true)
+}
+
+func (h *OpenAIAudioAPIHandler) handleAudio(c *gin.Context, translate bool) {
+	req, param, err := h.parseAudioRequest(c, translate)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeAudioError(c, status, fmt.Sprintf("Invalid request: %v", err), param)
+		return
+	}
+
+	payload := buildGeminiAudioRequest(req)
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, req.model, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, text, usage := extractGeminiImages(resp)
+	result := parseTranscript(text)
+	if result.text == "" && len(result.segments) == 0 {
+		errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("upstream returned no transcript for model %s", req.model)}
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if usage.total == 0 {
+		usage.total = usage.input + usage.output
+	}
+	writeTranscript(c, req, result, usage)
+	cliCancel()
+}
+
+func (h *OpenAIAudioAPIHandler) parseAudioRequest(c *gin.Context, translate bool) (audioRequest, string, error) {
+	req := audioRequest{translate: translate}
+	if !strings.HasPrefix(strings.ToLower(c.GetHeader("Content-Type")), "multipart/") {
+		return req, "", errors.New("body must be multipart/form-data")
+	}
+	maxBytes := int64(defaultAudioUploadBytes)
+	if h.Cfg != nil && h.Cfg.Audio.MaxUploadBytes > 0 {
+		maxBytes = h.Cfg.Audio.MaxUploadBytes
+	}
+	// Allow room for the other form fields on top of the audio itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		return req, "file", err
+	}
+	form := c.Request.MultipartForm
+	field := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	if strings.EqualFold(field("stream"), "true") {
+		return req, "stream", errors.New("streaming transcriptions are not supported")
+	}
+	req.model = h.audioModel(field("model"))
+	req.prompt = field("prompt")
+	if !translate {
+		req.language = field("language")
+	}
+	req.responseFormat = strings.ToLower(field("response_format"))
+	switch req.responseFormat {
+	case "":
+		req.responseFormat = "json"
+	case "json", "text", "srt", "vtt", "verbose_json":
+	default:
+		return req, "response_format", fmt.Errorf("unsupported response_format %q", req.responseFormat)
+	}
+	if raw := field("temperature"); raw != "" {
+		temperature, err := strconv.ParseFloat(raw, 64)
+		if err != nil || temperature < 0 || temperature > 1 {
+			return req, "temperature", errors.New("temperature must be between 0 and 1")
+		}
+		req.temperature = &temperature
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		return req, "file", errors.New("file is required")
+	}
+	fh := files[0]
+	if fh.Size > maxBytes {
+		return req, "file", fmt.Errorf("file exceeds %d bytes", maxBytes)
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return req, "file", err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return req, "file", err
+	}
+	mimeType, ok := audioMimeType(fh.Filename, fh.Header.Get("Content-Type"), data)
+	if !ok {
+		return req, "file", fmt.Errorf("%s is not a supported audio file (%s)", fh.Filename, mimeType)
+	}
+	req.mimeType = mimeType
+	req.data = base64.StdEncoding.EncodeToString(data)
+	return req, "", nil
+}
+
+// audioModel keeps a requested model that a Gemini provider serves and otherwise falls back to
+// the configured audio model, so OpenAI model names such as "whisper-1" work unchanged.
+func (h *OpenAIAudioAPIHandler) audioModel(requested string) string {
+	if requested != "" {
+		for _, provider := range util.GetProviderName(requested) {
+			if _, ok := audioProviders[provider]; ok {
+				return requested
+			}
+		}
+	}
+	if h.Cfg != nil {
+		if model := strings.TrimSpace(h.Cfg.Audio.Model); model != "" {
+			return model
+		}
+	}
+	return defaultAudioModel
+}
+
+func writeAudioError(c *gin.Context, status int, message, param string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Param:   param,
+		},
+	})
+}
+
+// audioMimeType resolves the MIME type of an upload from its extension, declared type or content.
+func audioMimeType(filename, declared string, data []byte) (string, bool) {
+	if mimeType, ok := audioMimeTypes[strings.ToLower(filepath.Ext(filename))]; ok {
+		return mimeType, true
+	}
+	mimeType := strings.TrimSpace(declared)
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if mimeType, _, _ = strings.Cut(mimeType, ";"); strings.HasPrefix(mimeType, "audio/") {
+		return mimeType, true
+	}
+	if mimeType == "video/webm" || mimeType == "video/mp4" || mimeType == "application/ogg" {
+		return mimeType, true
+	}
+	return mimeType, false
+}
+
+// buildGeminiAudioRequest renders a Gemini generateContent request returning timed segments.
+func buildGeminiAudioRequest(req audioRequest) []byte {
+	var instruction strings.Builder
+	if req.translate {
+		instruction.WriteString("You are a speech translation engine. Translate the speech in the audio into English.")
+	} else {
+		instruction.WriteString("You are a speech-to-text engine. Transcribe the speech in the audio verbatim in the language it is spoken.")
+	}
+	instruction.WriteString(" Split the result into segments of about one sentence and give each segment's start and end time in seconds from the beginning of the audio.")
+	instruction.WriteString(" Set language to the ISO-639-1 code of the spoken language. Return only the requested JSON, without commentary.")
+
+	out := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseMimeType":"application/json"}}`)
+	out, _ = sjson.SetBytes(out, "systemInstruction.parts.0.text", instruction.String())
+	part := []byte(`{"inlineData":{}}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mimeType", req.mimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", req.data)
+	out, _ = sjson.SetRawBytes(out, "contents.0.parts.-1", part)
+
+	var hints []string
+	if req.language != "" {
+		hints = append(hints, "The audio is in language "+req.language+".")
+	}
+	if req.prompt != "" {
+		hints = append(hints, "Context and spelling hints: "+req.prompt)
+	}
+	if len(hints) > 0 {
+		out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]string{"text": strings.Join(hints, "\n")})
+	}
+	out, _ = sjson.SetRawBytes(out, "generationConfig.responseSchema", []byte(audioTranscriptSchema))
+	if req.temperature != nil {
+		out, _ = sjson.SetBytes(out, "generationConfig.temperature", *req.temperature)
+	}
+	return out
+}
+
+// parseTranscript reads the segments returned by the model. Output that is not the requested
+// JSON is kept as a single untimed transcript.
+func parseTranscript(text string) transcript {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```"), "```")
+	root := gjson.Parse(strings.TrimSpace(text))
+	if !root.IsObject() || !root.Get("segments").IsArray() {
+		return transcript{text: strings.TrimSpace(text)}
+	}
+	result := transcript{language: root.Get("language").String()}
+	var parts []string
+	root.Get("segments").ForEach(func(_, item gjson.Result) bool {
+		segmentText := strings.TrimSpace(item.Get("text").String())
+		if segmentText == "" {
+			return true
+		}
+		segment := transcriptSegment{Start: item.Get("start").Float(), End: item.Get("end").Float(), Text: segmentText}
+		if segment.End < segment.Start {
+			segment.End = segment.Start
+		}
+		result.segments = append(result.segments, segment)
+		parts = append(parts, segmentText)
+		return true
+	})
+	result.text = strings.Join(parts, " ")
+	return result
+}
+
+func writeTranscript(c *gin.Context, req audioRequest, result transcript, usage imageUsage) {
+	segments := result.segments
+	if len(segments) == 0 {
+		segments = []transcriptSegment{{Text: result.text}}
+	}
+	usageBody := gin.H{
+		"type":          "tokens",
+		"input_tokens":  usage.input,
+		"output_tokens": usage.output,
+		"total_tokens":  usage.total,
+	}
+	switch req.responseFormat {
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(result.text+"\n"))
+	case "srt":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(formatSubtitles(segments, false)))
+	case "vtt":
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(formatSubtitles(segments, true)))
+	case "verbose_json":
+		task, language := "transcribe", result.language
+		if req.translate {
+			task, language = "translate", "en"
+		}
+		items := make([]gin.H, 0, len(segments))
+		for i, segment := range segments {
+			items = append(items, gin.H{"id": i, "start": segment.Start, "end": segment.End, "text": segment.Text})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"task":     task,
+			"language": language,
+			"duration": segments[len(segments)-1].End,
+			"text":     result.text,
+			"segments": items,
+			"usage":    usageBody,
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{"text": result.text, "usage": usageBody})
+	}
+}
+
+// formatSubtitles renders segments as SubRip, or WebVTT when vtt is set.
+func formatSubtitles(segments []transcriptSegment, vtt bool) string {
+	var b strings.Builder
+	if vtt {
+		b.WriteString("WEBVTT\n\n")
+	}
+	for i, segment := range segments {
+		if !vtt {
+			fmt.Fprintf(&b, "%d\n", i+1)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", subtitleTimestamp(segment.Start, vtt), subtitleTimestamp(segment.End, vtt), segment.Text)
+	}
+	return b.String()
+}
+
+func subtitleTimestamp(seconds float64, vtt bool) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(seconds*1000 + 0.5)
+	separator := ","
+	if vtt {
+		separator = "."
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
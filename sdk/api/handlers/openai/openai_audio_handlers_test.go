@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildGeminiAudioRequest(t *testing.T) {
+	temperature := 0.2
+	out := buildGeminiAudioRequest(audioRequest{
+		mimeType:    "audio/mp3",
+		data:        "SUQz",
+		language:    "de",
+		prompt:      "CLIProxyAPI",
+		temperature: &temperature,
+	})
+	if got := gjson.GetBytes(out, "contents.0.parts.0.inlineData.mimeType").String(); got != "audio/mp3" {
+		t.Fatalf("inline audio mime = %q", got)
+	}
+	if got := gjson.GetBytes(out, "contents.0.parts.1.text").String(); got == "" {
+		t.Fatalf("expected hint part, got %s", out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseSchema.required.0").String(); got != "segments" {
+		t.Fatalf("response schema missing: %s", out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.temperature").Float(); got != 0.2 {
+		t.Fatalf("temperature = %v", got)
+	}
+
+	translated := buildGeminiAudioRequest(audioRequest{mimeType: "audio/wav", data: "UklG", translate: true})
+	if gjson.GetBytes(translated, "contents.0.parts.1").Exists() {
+		t.Fatalf("unexpected hint part without language or prompt: %s", translated)
+	}
+}
+
+func TestParseTranscript(t *testing.T) {
+	result := parseTranscript("```json\n{\"language\":\"en\",\"segments\":[{\"start\":0,\"end\":1.5,\"text\":\"Hello there.\"},{\"start\":1.5,\"end\":3.25,\"text\":\"General Kenobi.\"}]}\n```")
+	if result.language != "en" || len(result.segments) != 2 {
+		t.Fatalf("unexpected transcript %+v", result)
+	}
+	if result.text != "Hello there. General Kenobi." {
+		t.Fatalf("text = %q", result.text)
+	}
+
+	plain := parseTranscript("just words")
+	if plain.text != "just words" || len(plain.segments) != 0 {
+		t.Fatalf("unexpected plain transcript %+v", plain)
+	}
+}
+
+func TestFormatSubtitles(t *testing.T) {
+	segments := []transcriptSegment{{Start: 0, End: 1.5, Text: "Hello."}, {Start: 3661.25, End: 3662, Text: "Bye."}}
+	srt := formatSubtitles(segments, false)
+	want := "1\n00:00:00,000 --> 00:00:01,500\nHello.\n\n2\n01:01:01,250 --> 01:01:02,000\nBye.\n\n"
+	if srt != want {
+		t.Fatalf("srt = %q, want %q", srt, want)
+	}
+	vtt := formatSubtitles(segments[:1], true)
+	if vtt != "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nHello.\n\n" {
+		t.Fatalf("vtt = %q", vtt)
+	}
+}
+
+func TestAudioMimeType(t *testing.T) {
+	if got, ok := audioMimeType("clip.M4A", "", nil); !ok || got != "audio/mp4" {
+		t.Fatalf("m4a = %q, %t", got, ok)
+	}
+	if got, ok := audioMimeType("blob", "audio/ogg; codecs=opus", nil); !ok || got != "audio/ogg" {
+		t.Fatalf("declared ogg = %q, %t", got, ok)
+	}
+	if _, ok := audioMimeType("notes.txt", "text/plain", []byte("hello")); ok {
+		t.Fatal("text upload accepted as audio")
+	}
+}
+
+func TestAudioModelRequiresGeminiProvider(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("audio-test-vertex", "vertex", []*registry.ModelInfo{{ID: "audio-test-gemini"}})
+	reg.RegisterClient("audio-test-claude", "claude", []*registry.ModelInfo{{ID: "audio-test-claude"}})
+	defer reg.UnregisterClient("audio-test-vertex")
+	defer reg.UnregisterClient("audio-test-claude")
+
+	cfg := &config.SDKConfig{}
+	cfg.Audio.Model = "configured-audio"
+	h := NewOpenAIAudioAPIHandler(handlers.NewBaseAPIHandlers(cfg, nil))
+	for requested, want := range map[string]string{
+		"audio-test-gemini": "audio-test-gemini",
+		"audio-test-claude": "configured-audio",
+		"whisper-1":         "configured-audio",
+		"":                  "configured-audio",
+	} {
+		if got := h.audioModel(requested); got != want {
+			t.Fatalf("audioModel(%q) = %q, want %q", requested, got, want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	responsesconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/responses"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -444,6 +445,16 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 		cliCancel(errMsg.Error)
 		return
 	}
+	// Providers without native structured output may drift from the requested schema; retry once
+	// when the operator opted in.
+	if h.Cfg != nil && h.Cfg.StructuredOutputRetry {
+		if violation := structuredOutputViolation(rawJSON, resp); violation != "" {
+			log.Debugf("structured output violates response_format schema (%s), retrying once", violation)
+			if retryResp, retryErr := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c)); retryErr == nil {
+				resp = retryResp
+			}
+		}
+	}
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
 }
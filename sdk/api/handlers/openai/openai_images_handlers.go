@@ -0,0 +1,422 @@
+// Package openai provides HTTP handlers for the OpenAI Images API endpoints.
+// Image requests are translated to Gemini generateContent calls with image response
+// modalities so they can be served by Gemini, Vertex, Gemini CLI and Antigravity credentials.
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// defaultImageModel is used when an Images API request omits the model.
+	defaultImageModel = "gemini-2.5-flash-image"
+	// maxImagesPerRequest mirrors the OpenAI Images API upper bound for n.
+	maxImagesPerRequest = 10
+	// maxImageEditUpload bounds the multipart body accepted by /v1/images/edits.
+	maxImageEditUpload = 32 << 20
+)
+
+// geminiAspectRatios lists the aspect ratios accepted by Gemini image models.
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// OpenAIImagesAPIHandler contains the handlers for the OpenAI Images API endpoints.
+type OpenAIImagesAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIImagesAPIHandler creates a new OpenAI Images API handlers instance.
+func NewOpenAIImagesAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIImagesAPIHandler {
+	return &OpenAIImagesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIImagesAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIImagesAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// imageRequest is the normalized form of an Images API generation or edit request.
+type imageRequest struct {
+	model          string
+	prompt         string
+	n              int
+	aspectRatio    string
+	responseFormat string // always b64_json; url is rejected
+	images         []inlineImage
+}
+
+// inlineImage is an input image forwarded to Gemini as inlineData.
+type inlineImage struct {
+	mimeType string
+	data     string
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+func (h *OpenAIImagesAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeImageError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), "")
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		writeImageError(c, http.StatusBadRequest, "Invalid request: body must be JSON", "")
+		return
+	}
+	req, param, err := parseImageRequest(func(key string) string { return gjson.GetBytes(rawJSON, key).String() })
+	if err != nil {
+		writeImageError(c, http.StatusBadRequest, err.Error(), param)
+		return
+	}
+	h.handleImages(c, req)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint. Both multipart uploads ("image" or
+// "image[]" files) and JSON bodies carrying data URLs in "images[].image_url" are accepted.
+func (h *OpenAIImagesAPIHandler) ImageEdits(c *gin.Context) {
+	contentType := c.GetHeader("Content-Type")
+	var (
+		req   imageRequest
+		param string
+		err   error
+	)
+	if strings.HasPrefix(strings.ToLower(contentType), "multipart/") {
+		req, param, err = parseMultipartImageEdit(c)
+	} else {
+		var rawJSON []byte
+		rawJSON, err = c.GetRawData()
+		if err == nil && !gjson.ValidBytes(rawJSON) {
+			err = errors.New("body must be JSON or multipart/form-data")
+		}
+		if err == nil {
+			req, param, err = parseJSONImageEdit(rawJSON)
+		}
+	}
+	if err != nil {
+		writeImageError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err), param)
+		return
+	}
+	if len(req.images) == 0 {
+		writeImageError(c, http.StatusBadRequest, "Invalid request: at least one image is required", "image")
+		return
+	}
+	h.handleImages(c, req)
+}
+
+func parseImageRequest(field func(string) string) (imageRequest, string, error) {
+	req := imageRequest{
+		model:          strings.TrimSpace(field("model")),
+		prompt:         strings.TrimSpace(field("prompt")),
+		responseFormat: strings.ToLower(strings.TrimSpace(field("response_format"))),
+		n:              1,
+	}
+	if req.model == "" {
+		req.model = defaultImageModel
+	}
+	if req.prompt == "" {
+		return req, "prompt", errors.New("prompt is required")
+	}
+	if raw := strings.TrimSpace(field("n")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxImagesPerRequest {
+			return req, "n", fmt.Errorf("n must be between 1 and %d", maxImagesPerRequest)
+		}
+		req.n = n
+	}
+	switch req.responseFormat {
+	case "", "b64_json":
+		req.responseFormat = "b64_json"
+	case "url":
+		// Generated images are never stored, so there is no URL to hand out.
+		return req, "response_format", errors.New("response_format url is not supported, use b64_json")
+	default:
+		return req, "response_format", fmt.Errorf("unsupported response_format %q", req.responseFormat)
+	}
+	if field("mask") != "" {
+		return req, "mask", errors.New("mask is not supported by Gemini image models")
+	}
+	aspectRatio, err := imageAspectRatio(field("size"))
+	if err != nil {
+		return req, "size", err
+	}
+	req.aspectRatio = aspectRatio
+	return req, "", nil
+}
+
+func parseMultipartImageEdit(c *gin.Context) (imageRequest, string, error) {
+	if err := c.Request.ParseMultipartForm(maxImageEditUpload); err != nil {
+		return imageRequest{}, "", err
+	}
+	form := c.Request.MultipartForm
+	if len(form.File["mask"]) > 0 {
+		return imageRequest{}, "mask", errors.New("mask is not supported by Gemini image models")
+	}
+	req, param, err := parseImageRequest(func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+	if err != nil {
+		return req, param, err
+	}
+	files := append(append([]*multipart.FileHeader{}, form.File["image"]...), form.File["image[]"]...)
+	for _, fh := range files {
+		img, errRead := readMultipartImage(fh)
+		if errRead != nil {
+			return req, "image", errRead
+		}
+		req.images = append(req.images, img)
+	}
+	return req, "", nil
+}
+
+func readMultipartImage(fh *multipart.FileHeader) (inlineImage, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return inlineImage{}, err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return inlineImage{}, err
+	}
+	mimeType := strings.TrimSpace(fh.Header.Get("Content-Type"))
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return inlineImage{}, fmt.Errorf("%s is not an image (%s)", fh.Filename, mimeType)
+	}
+	return inlineImage{mimeType: mimeType, data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func parseJSONImageEdit(rawJSON []byte) (imageRequest, string, error) {
+	req, param, err := parseImageRequest(func(key string) string {
+		value := gjson.GetBytes(rawJSON, key)
+		if key == "mask" && value.IsObject() {
+			return value.Raw
+		}
+		return value.String()
+	})
+	if err != nil {
+		return req, param, err
+	}
+	var urls []string
+	if image := gjson.GetBytes(rawJSON, "image"); image.Type == gjson.String {
+		urls = append(urls, image.String())
+	}
+	gjson.GetBytes(rawJSON, "images").ForEach(func(_, item gjson.Result) bool {
+		if item.Type == gjson.String {
+			urls = append(urls, item.String())
+		} else if u := item.Get("image_url"); u.Type == gjson.String {
+			urls = append(urls, u.String())
+		} else if u = item.Get("image_url.url"); u.Type == gjson.String {
+			urls = append(urls, u.String())
+		}
+		return true
+	})
+	for _, u := range urls {
+		img, ok := parseImageDataURL(u)
+		if !ok {
+			return req, "images", errors.New("images must be base64 data URLs (data:image/...;base64,...)")
+		}
+		req.images = append(req.images, img)
+	}
+	return req, "", nil
+}
+
+func parseImageDataURL(value string) (inlineImage, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "data:") {
+		return inlineImage{}, false
+	}
+	meta, data, ok := strings.Cut(value[len("data:"):], ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return inlineImage{}, false
+	}
+	mimeType := strings.TrimSuffix(meta, ";base64")
+	if !strings.HasPrefix(mimeType, "image/") || data == "" {
+		return inlineImage{}, false
+	}
+	return inlineImage{mimeType: mimeType, data: data}, true
+}
+
+// imageAspectRatio maps an OpenAI size ("1024x1536", "auto") or an explicit ratio ("16:9")
+// to the closest aspect ratio supported by Gemini image models. Empty means model default.
+func imageAspectRatio(size string) (string, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" || size == "auto" {
+		return "", nil
+	}
+	for _, ratio := range geminiAspectRatios {
+		if size == ratio {
+			return ratio, nil
+		}
+	}
+	w, hgt, ok := strings.Cut(size, "x")
+	width, errW := strconv.Atoi(strings.TrimSpace(w))
+	height, errH := strconv.Atoi(strings.TrimSpace(hgt))
+	if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return "", fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT, auto, or one of %s", size, strings.Join(geminiAspectRatios, ", "))
+	}
+	target := math.Log(float64(width) / float64(height))
+	best, bestDiff := "1:1", math.MaxFloat64
+	for _, ratio := range geminiAspectRatios {
+		a, b, _ := strings.Cut(ratio, ":")
+		num, _ := strconv.Atoi(a)
+		den, _ := strconv.Atoi(b)
+		if diff := math.Abs(math.Log(float64(num)/float64(den)) - target); diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best, nil
+}
+
+// buildGeminiImageRequest renders a Gemini generateContent request producing one image.
+func buildGeminiImageRequest(req imageRequest) []byte {
+	out := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+	for _, img := range req.images {
+		part := []byte(`{"inlineData":{}}`)
+		part, _ = sjson.SetBytes(part, "inlineData.mimeType", img.mimeType)
+		part, _ = sjson.SetBytes(part, "inlineData.data", img.data)
+		out, _ = sjson.SetRawBytes(out, "contents.0.parts.-1", part)
+	}
+	out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]string{"text": req.prompt})
+	if req.aspectRatio != "" {
+		out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.aspectRatio", req.aspectRatio)
+	}
+	return out
+}
+
+// imageUsage accumulates Gemini usage metadata across the calls of one Images API request.
+type imageUsage struct {
+	input  int64
+	output int64
+	total  int64
+}
+
+// extractGeminiImages returns the images, any accompanying text and usage from a Gemini response.
+func extractGeminiImages(resp []byte) ([]inlineImage, string, imageUsage) {
+	root := gjson.ParseBytes(resp)
+	if r := root.Get("response"); r.Exists() && !root.Get("candidates").Exists() {
+		root = r
+	}
+	var images []inlineImage
+	var text strings.Builder
+	root.Get("candidates").ForEach(func(_, candidate gjson.Result) bool {
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			if data := inline.Get("data").String(); data != "" {
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				images = append(images, inlineImage{mimeType: mimeType, data: data})
+			} else if t := part.Get("text").String(); t != "" && !part.Get("thought").Bool() {
+				text.WriteString(t)
+			}
+			return true
+		})
+		return true
+	})
+	usage := imageUsage{
+		input:  root.Get("usageMetadata.promptTokenCount").Int(),
+		output: root.Get("usageMetadata.candidatesTokenCount").Int(),
+		total:  root.Get("usageMetadata.totalTokenCount").Int(),
+	}
+	return images, strings.TrimSpace(text.String()), usage
+}
+
+// handleImages runs one Gemini call per requested image and writes an OpenAI Images response.
+// Each call is executed and accounted separately, so usage statistics record one entry per image.
+func (h *OpenAIImagesAPIHandler) handleImages(c *gin.Context, req imageRequest) {
+	payload := buildGeminiImageRequest(req)
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	data := make([]map[string]any, 0, req.n)
+	var usage imageUsage
+	// A call can return several images; stop once n have been collected.
+	for len(data) < req.n {
+		resp, errMsg := h.ExecuteWithAuthManager(cliCtx, Gemini, req.model, payload, "")
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		images, text, callUsage := extractGeminiImages(resp)
+		usage.input += callUsage.input
+		usage.output += callUsage.output
+		usage.total += callUsage.total
+		if len(images) == 0 {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("upstream returned no image for model %s: %s", req.model, text)}
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		data = appendImageEntries(data, images, text, req.n)
+	}
+	if usage.total == 0 {
+		usage.total = usage.input + usage.output
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"created": time.Now().Unix(),
+		"data":    data,
+		"usage": gin.H{
+			"input_tokens":  usage.input,
+			"output_tokens": usage.output,
+			"total_tokens":  usage.total,
+		},
+	})
+	cliCancel()
+}
+
+// appendImageEntries adds b64_json entries for images to data without exceeding n entries.
+func appendImageEntries(data []map[string]any, images []inlineImage, text string, n int) []map[string]any {
+	for _, img := range images {
+		if len(data) >= n {
+			break
+		}
+		entry := map[string]any{"b64_json": img.data}
+		if text != "" {
+			entry["revised_prompt"] = text
+		}
+		data = append(data, entry)
+	}
+	return data
+}
+
+func writeImageError(c *gin.Context, status int, message, param string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Param:   param,
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestImageAspectRatio(t *testing.T) {
+	cases := map[string]string{
+		"":          "",
+		"auto":      "",
+		"1024x1024": "1:1",
+		"1536x1024": "3:2",
+		"1024x1536": "2:3",
+		"1792x1024": "16:9",
+		"1024x1792": "9:16",
+		"16:9":      "16:9",
+	}
+	for size, want := range cases {
+		got, err := imageAspectRatio(size)
+		if err != nil {
+			t.Fatalf("imageAspectRatio(%q) error = %v", size, err)
+		}
+		if got != want {
+			t.Fatalf("imageAspectRatio(%q) = %q, want %q", size, got, want)
+		}
+	}
+	if _, err := imageAspectRatio("large"); err == nil {
+		t.Fatalf("imageAspectRatio(large) expected error")
+	}
+}
+
+func TestBuildGeminiImageRequestAndExtract(t *testing.T) {
+	req := imageRequest{
+		prompt:      "a red fox",
+		aspectRatio: "16:9",
+		images:      []inlineImage{{mimeType: "image/png", data: "AAAA"}},
+	}
+	out := buildGeminiImageRequest(req)
+	if got := gjson.GetBytes(out, "contents.0.parts.0.inlineData.mimeType").String(); got != "image/png" {
+		t.Fatalf("inline image mime = %q", got)
+	}
+	if got := gjson.GetBytes(out, "contents.0.parts.1.text").String(); got != "a red fox" {
+		t.Fatalf("prompt part = %q", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspect ratio = %q", got)
+	}
+
+	resp := []byte(`{"response":{"candidates":[{"content":{"parts":[{"text":"Here you go"},{"inlineData":{"mimeType":"image/png","data":"iVBOR"}}]}}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":1290,"totalTokenCount":1297}}}`)
+	images, text, usage := extractGeminiImages(resp)
+	if len(images) != 1 || images[0].data != "iVBOR" || images[0].mimeType != "image/png" {
+		t.Fatalf("unexpected images %+v", images)
+	}
+	if text != "Here you go" {
+		t.Fatalf("text = %q", text)
+	}
+	if usage.input != 7 || usage.output != 1290 || usage.total != 1297 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestParseJSONImageEdit(t *testing.T) {
+	req, _, err := parseJSONImageEdit([]byte(`{"prompt":"make it blue","n":2,"images":[{"image_url":"data:image/jpeg;base64,/9j/"}]}`))
+	if err != nil {
+		t.Fatalf("parseJSONImageEdit error = %v", err)
+	}
+	if req.model != defaultImageModel || req.n != 2 || len(req.images) != 1 || req.images[0].mimeType != "image/jpeg" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if _, param, err := parseJSONImageEdit([]byte(`{"prompt":"x","images":[{"image_url":"https://example.com/a.png"}]}`)); err == nil || param != "images" {
+		t.Fatalf("expected data URL error, got %v (%s)", err, param)
+	}
+}
+
+func TestParseImageRequestRejectsURLFormat(t *testing.T) {
+	field := func(values map[string]string) func(string) string {
+		return func(key string) string { return values[key] }
+	}
+	if _, param, err := parseImageRequest(field(map[string]string{"prompt": "x", "response_format": "url"})); err == nil || param != "response_format" {
+		t.Fatalf("expected response_format error, got %v (%s)", err, param)
+	}
+	req, _, err := parseImageRequest(field(map[string]string{"prompt": "x"}))
+	if err != nil || req.responseFormat != "b64_json" {
+		t.Fatalf("default response_format = %q, %v", req.responseFormat, err)
+	}
+}
+
+func TestAppendImageEntriesCapsAtN(t *testing.T) {
+	images := []inlineImage{{data: "a"}, {data: "b"}, {data: "c"}}
+	data := appendImageEntries(nil, images[:1], "", 2)
+	data = appendImageEntries(data, images, "revised", 2)
+	if len(data) != 2 || data[0]["b64_json"] != "a" || data[1]["b64_json"] != "a" || data[1]["revised_prompt"] != "revised" {
+		t.Fatalf("unexpected entries %+v", data)
+	}
+}
@@ -0,0 +1,126 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// structuredOutputViolation checks a non-streaming chat completion against the
+// json_schema requested through response_format. It returns an empty string when
+// the request did not ask for structured output or the content conforms, and a
+// short description of the first violation otherwise. Tool call responses are
+// not checked because the model has not produced its final answer yet.
+func structuredOutputViolation(rawJSON, resp []byte) string {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	if format.Get("type").String() != "json_schema" {
+		return ""
+	}
+	schema := format.Get("json_schema.schema")
+	if !schema.IsObject() {
+		return ""
+	}
+	choice := gjson.GetBytes(resp, "choices.0")
+	if !choice.Exists() || choice.Get("finish_reason").String() == "tool_calls" {
+		return ""
+	}
+	content := strings.TrimSpace(choice.Get("message.content").String())
+	if !json.Valid([]byte(content)) {
+		return "content is not valid JSON"
+	}
+	return validateJSONSchema(schema, gjson.Parse(content), "$")
+}
+
+// validateJSONSchema performs a lightweight structural check covering type,
+// enum, required, properties and items. Keywords it does not understand are
+// ignored so unusual schemas never cause false positives.
+func validateJSONSchema(schema, value gjson.Result, path string) string {
+	if types := schema.Get("type"); types.Exists() {
+		matched := false
+		if types.IsArray() {
+			for _, t := range types.Array() {
+				if jsonSchemaTypeMatches(t.String(), value) {
+					matched = true
+					break
+				}
+			}
+		} else {
+			matched = jsonSchemaTypeMatches(types.String(), value)
+		}
+		if !matched {
+			return fmt.Sprintf("%s: expected type %s", path, types.Raw)
+		}
+	}
+
+	if enum := schema.Get("enum"); enum.IsArray() {
+		found := false
+		for _, candidate := range enum.Array() {
+			if candidate.Raw == value.Raw || (candidate.Type == gjson.String && value.Type == gjson.String && candidate.Str == value.Str) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s: value not in enum", path)
+		}
+	}
+
+	if value.IsObject() {
+		for _, key := range schema.Get("required").Array() {
+			if !value.Get(gjsonEscape(key.String())).Exists() {
+				return fmt.Sprintf("%s: missing required property %q", path, key.String())
+			}
+		}
+		var violation string
+		schema.Get("properties").ForEach(func(key, propSchema gjson.Result) bool {
+			if propValue := value.Get(gjsonEscape(key.String())); propValue.Exists() {
+				violation = validateJSONSchema(propSchema, propValue, path+"."+key.String())
+			}
+			return violation == ""
+		})
+		if violation != "" {
+			return violation
+		}
+	}
+
+	if value.IsArray() {
+		if items := schema.Get("items"); items.IsObject() {
+			for i, item := range value.Array() {
+				if violation := validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); violation != "" {
+					return violation
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func jsonSchemaTypeMatches(schemaType string, value gjson.Result) bool {
+	switch schemaType {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Num == math.Trunc(value.Num)
+	case "boolean":
+		return value.IsBool()
+	case "null":
+		return value.Type == gjson.Null
+	default:
+		return true
+	}
+}
+
+var gjsonKeyEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`)
+
+func gjsonEscape(key string) string {
+	return gjsonKeyEscaper.Replace(key)
+}
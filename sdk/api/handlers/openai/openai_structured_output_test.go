@@ -0,0 +1,29 @@
+package openai
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestStructuredOutputViolation(t *testing.T) {
+	req := []byte(`{"response_format":{"type":"json_schema","json_schema":{"name":"w","schema":{"type":"object","properties":{"city":{"type":"string"},"tags":{"type":"array","items":{"enum":["a","b"]}},"n":{"type":"integer"}},"required":["city"]}}}}`)
+
+	cases := map[string]string{
+		`{"city":"Paris","tags":["a"],"n":3}`: "",
+		`{"tags":["a"]}`:                      `$: missing required property "city"`,
+		`{"city":1}`:                          `$.city: expected type "string"`,
+		`{"city":"x","tags":["c"]}`:           "$.tags[0]: value not in enum",
+		`{"city":"x","n":1.5}`:                `$.n: expected type "integer"`,
+		`Sure! {"city":"x"}`:                  "content is not valid JSON",
+	}
+	for content, want := range cases {
+		resp := []byte(`{"choices":[{"message":{"content":` + strconv.Quote(content) + `},"finish_reason":"stop"}]}`)
+		if got := structuredOutputViolation(req, resp); got != want {
+			t.Fatalf("structuredOutputViolation(%s) = %q, want %q", content, got, want)
+		}
+	}
+
+	if got := structuredOutputViolation([]byte(`{"response_format":{"type":"text"}}`), []byte(`{"choices":[{"message":{"content":"hi"}}]}`)); got != "" {
+		t.Fatalf("plain text request should not be validated, got %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responseModelPaths lists the locations of the model name across client response formats.
+var responseModelPaths = []string{"model", "message.model", "response.model", "modelVersion", "response.modelVersion"}
+
+// responseFinishReasonPaths lists scalar finish/stop reason locations across client response formats.
+var responseFinishReasonPaths = []string{"stop_reason", "delta.stop_reason", "message.stop_reason"}
+
+// responseFinishReasonArrays lists array-scoped finish reason locations as (array path, field) pairs.
+var responseFinishReasonArrays = [][2]string{
+	{"choices", "finish_reason"},
+	{"candidates", "finishReason"},
+	{"response.candidates", "finishReason"},
+}
+
+// responseRewriter applies the response rules matched for one request.
+type responseRewriter struct {
+	rules []config.ResponseRule
+	model string
+}
+
+// newResponseRewriter selects the response rules matching the request carried by ctx.
+// It returns nil when no rule applies so callers can skip rewriting entirely.
+func newResponseRewriter(ctx context.Context, cfg *config.SDKConfig, handlerType, modelName string) *responseRewriter {
+	if cfg == nil || len(cfg.ResponseRules) == 0 {
+		return nil
+	}
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	principal := ""
+	var headers func(string) string
+	if ginCtx != nil {
+		if v, ok := ginCtx.Get("apiKey"); ok {
+			principal, _ = v.(string)
+		}
+		if ginCtx.Request != nil {
+			headers = ginCtx.Request.Header.Get
+		}
+	}
+	var matched []config.ResponseRule
+	for i := range cfg.ResponseRules {
+		rule := cfg.ResponseRules[i]
+		if responseRuleMatches(rule, handlerType, modelName, principal, headers) {
+			matched = append(matched, rule)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	return &responseRewriter{rules: matched, model: modelName}
+}
+
+func responseRuleMatches(rule config.ResponseRule, handlerType, modelName, principal string, headers func(string) string) bool {
+	if len(rule.Models) > 0 {
+		ok := false
+		for _, entry := range rule.Models {
+			name := strings.TrimSpace(entry.Name)
+			protocol := strings.TrimSpace(entry.Protocol)
+			if name != "" && !executor.MatchModelPattern(name, modelName) {
+				continue
+			}
+			if protocol != "" && !strings.EqualFold(protocol, handlerType) {
+				continue
+			}
+			ok = true
+			break
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(rule.APIKeys) > 0 {
+		ok := false
+		for _, pattern := range rule.APIKeys {
+			if principal != "" && executor.MatchModelPattern(strings.TrimSpace(pattern), principal) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for name, pattern := range rule.MatchHeaders {
+		if headers == nil {
+			return false
+		}
+		value := headers(name)
+		if value == "" || !executor.MatchModelPattern(strings.TrimSpace(pattern), value) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyHeaders adds the configured response headers to the client response.
+func (rw *responseRewriter) applyHeaders(ctx context.Context) {
+	if rw == nil || ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	for _, rule := range rw.rules {
+		for key, value := range rule.Headers {
+			if strings.TrimSpace(key) != "" {
+				ginCtx.Header(key, value)
+			}
+		}
+	}
+}
+
+// rewriteChunk rewrites a streaming chunk. Chunks are either bare JSON objects or SSE
+// frames; only the JSON payload of "data:" lines is touched.
+func (rw *responseRewriter) rewriteChunk(chunk []byte) []byte {
+	if rw == nil || len(chunk) == 0 {
+		return chunk
+	}
+	trimmed := bytes.TrimSpace(chunk)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return rw.rewriteBody(chunk)
+	}
+	lines := bytes.Split(chunk, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		payload := bytes.TrimSpace(line[len("data:"):])
+		if len(payload) == 0 || payload[0] != '{' {
+			continue
+		}
+		rewritten := rw.rewriteBody(payload)
+		if !bytes.Equal(rewritten, payload) {
+			lines[i] = append([]byte("data: "), rewritten...)
+			changed = true
+		}
+	}
+	if !changed {
+		return chunk
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// rewriteBody applies every matched rule to a JSON response body.
+func (rw *responseRewriter) rewriteBody(body []byte) []byte {
+	if rw == nil || len(body) == 0 || !gjson.ValidBytes(body) {
+		return body
+	}
+	out := body
+	for _, rule := range rw.rules {
+		if rule.RewriteModel && rw.model != "" {
+			for _, path := range responseModelPaths {
+				if gjson.GetBytes(out, path).Type == gjson.String {
+					out, _ = sjson.SetBytes(out, path, rw.model)
+				}
+			}
+		}
+		if len(rule.FinishReasons) > 0 {
+			out = rewriteFinishReasons(out, rule.FinishReasons)
+		}
+		for _, path := range rule.Remove {
+			if path = strings.TrimSpace(path); path != "" {
+				out, _ = sjson.DeleteBytes(out, path)
+			}
+		}
+		for path, value := range rule.Set {
+			if path = strings.TrimSpace(path); path != "" {
+				out, _ = sjson.SetBytes(out, path, value)
+			}
+		}
+	}
+	return out
+}
+
+func rewriteFinishReasons(body []byte, mapping map[string]string) []byte {
+	remap := func(path string) {
+		value := gjson.GetBytes(body, path)
+		if value.Type != gjson.String {
+			return
+		}
+		if mapped, ok := mapping[value.String()]; ok {
+			body, _ = sjson.SetBytes(body, path, mapped)
+		}
+	}
+	for _, path := range responseFinishReasonPaths {
+		remap(path)
+	}
+	for _, pair := range responseFinishReasonArrays {
+		items := gjson.GetBytes(body, pair[0])
+		if !items.IsArray() {
+			continue
+		}
+		for idx := range items.Array() {
+			remap(pair[0] + "." + strconv.Itoa(idx) + "." + pair[1])
+		}
+	}
+	return body
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestResponseRewriter_RewritesBodyAndChunks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set("X-Client", "ci-nightly")
+	ginCtx.Set("apiKey", "team-a-123")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	cfg := &sdkconfig.SDKConfig{ResponseRules: []sdkconfig.ResponseRule{
+		{
+			Models:        []sdkconfig.PayloadModelRule{{Name: "alias-*", Protocol: "openai"}},
+			APIKeys:       []string{"team-a-*"},
+			MatchHeaders:  map[string]string{"X-Client": "ci-*"},
+			RewriteModel:  true,
+			FinishReasons: map[string]string{"length": "max_tokens"},
+			Remove:        []string{"system_fingerprint"},
+			Set:           map[string]any{"provider": "proxy"},
+			Headers:       map[string]string{"X-Served-By": "proxy"},
+		},
+		{APIKeys: []string{"team-b-*"}, Set: map[string]any{"unexpected": true}},
+	}}
+
+	rw := newResponseRewriter(ctx, cfg, "openai", "alias-fast")
+	if rw == nil || len(rw.rules) != 1 {
+		t.Fatalf("expected exactly one matching rule, got %+v", rw)
+	}
+	rw.applyHeaders(ctx)
+	if got := recorder.Header().Get("X-Served-By"); got != "proxy" {
+		t.Fatalf("expected response header to be set, got %q", got)
+	}
+
+	body := rw.rewriteBody([]byte(`{"model":"upstream-model","system_fingerprint":"fp","choices":[{"finish_reason":"length"}]}`))
+	if got := gjson.GetBytes(body, "model").String(); got != "alias-fast" {
+		t.Fatalf("model = %q, want alias-fast", got)
+	}
+	if gjson.GetBytes(body, "system_fingerprint").Exists() {
+		t.Fatalf("expected system_fingerprint to be removed: %s", body)
+	}
+	if got := gjson.GetBytes(body, "choices.0.finish_reason").String(); got != "max_tokens" {
+		t.Fatalf("finish_reason = %q, want max_tokens", got)
+	}
+	if got := gjson.GetBytes(body, "provider").String(); got != "proxy" {
+		t.Fatalf("provider = %q, want proxy", got)
+	}
+
+	chunk := rw.rewriteChunk([]byte("event: message_start\ndata: {\"message\":{\"model\":\"upstream-model\"}}\n\n"))
+	if !strings.Contains(string(chunk), `"model":"alias-fast"`) || !strings.HasPrefix(string(chunk), "event: message_start\n") {
+		t.Fatalf("unexpected SSE chunk rewrite: %q", chunk)
+	}
+
+	if newResponseRewriter(ctx, cfg, "claude", "alias-fast") != nil {
+		t.Fatalf("expected protocol mismatch to disable the rule")
+	}
+}
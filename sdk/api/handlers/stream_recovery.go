@@ -0,0 +1,653 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// prefillProviders lists backends that continue a trailing assistant turn instead of starting a
+// new reply. Mid-stream recovery relies on this, so it is only offered when every provider that
+// can serve the model is listed here.
+var prefillProviders = map[string]struct{}{
+	"claude":      {},
+	"gemini":      {},
+	"gemini-cli":  {},
+	"vertex":      {},
+	"aistudio":    {},
+	"antigravity": {},
+}
+
+// errContinuationDiverged reports that a continuation stream did not reproduce the tool call
+// output already sent to the client, so it cannot be spliced in.
+var errContinuationDiverged = errors.New("continuation diverged from the partial output already sent")
+
+// recoveredTool is a tool call already streamed to the client.
+type recoveredTool struct {
+	index  int
+	name   string
+	args   string
+	closed bool
+}
+
+// streamRecovery records the client-format output of a stream so that a stream failing
+// mid-way can be re-issued with the partial answer as prefill and spliced back together.
+type streamRecovery struct {
+	format string
+
+	text     strings.Builder
+	tools    []recoveredTool
+	finished bool
+	// broken marks output that cannot be expressed as a prefill (multiple choices, text after tool calls).
+	broken bool
+
+	// Claude content block bookkeeping.
+	blocks   int
+	openText int
+
+	// Identifiers of the first attempt, reused so clients see a single response.
+	chunkID    string
+	created    int64
+	responseID string
+
+	failedAuths []string
+	cause       error
+	splice      *streamSplice
+}
+
+// streamSplice tracks a continuation stream while it is merged into the client stream.
+type streamSplice struct {
+	prior      []recoveredTool
+	textReplay string
+	toolSeen   int
+	replayed   map[int]int
+
+	// Claude continuation block index -> client block index (-1 drops the block) and prior tool slot.
+	blockMap   map[int]int
+	blockTool  map[int]int
+	textMapped bool
+	nextBlock  int
+}
+
+// newStreamRecovery returns a recorder for the stream, or nil when mid-stream recovery is
+// disabled or unsupported for the client format or the model's providers.
+func (h *BaseAPIHandler) newStreamRecovery(handlerType, alt string, providers []string) *streamRecovery {
+	if StreamingMidstreamRetries(h.Cfg) <= 0 {
+		return nil
+	}
+	switch handlerType {
+	case constant.Claude, constant.OpenAI:
+	case constant.Gemini:
+		if alt != "" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	for _, provider := range providers {
+		if _, ok := prefillProviders[strings.ToLower(strings.TrimSpace(provider))]; !ok {
+			return nil
+		}
+	}
+	return &streamRecovery{format: handlerType, openText: -1}
+}
+
+// resumable reports whether the output sent so far can be continued.
+func (r *streamRecovery) resumable() bool {
+	return r != nil && !r.broken && !r.finished
+}
+
+// forward passes a chunk on its way to the client: continuation chunks are spliced first, and
+// everything that is sent is recorded. A nil result means the chunk carries nothing new.
+func (r *streamRecovery) forward(payload []byte) ([]byte, error) {
+	out := payload
+	if r.splice != nil {
+		var err error
+		switch r.format {
+		case constant.Claude:
+			out, err = r.spliceClaude(payload)
+		case constant.OpenAI:
+			out, err = r.spliceOpenAI(payload)
+		case constant.Gemini:
+			out, err = r.spliceGemini(payload)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(out) > 0 {
+		r.observe(out)
+	}
+	return out, nil
+}
+
+// resumeStream re-issues the request with the partial answer as prefill, preferring a
+// credential other than the ones whose streams already failed.
+func (h *BaseAPIHandler) resumeStream(ctx context.Context, r *streamRecovery, recorder *coreauth.StreamAuthRecorder, providers []string, req coreexecutor.Request, opts coreexecutor.Options, cause error) (<-chan coreexecutor.StreamChunk, bool) {
+	if !r.resumable() {
+		return nil, false
+	}
+	payload, ok := r.continuationRequest(opts.OriginalRequest)
+	if !ok {
+		return nil, false
+	}
+	if authID := recorder.AuthID(); authID != "" {
+		r.failedAuths = append(r.failedAuths, authID)
+	}
+	contReq := req
+	contReq.Payload = cloneBytes(payload)
+	contOpts := opts
+	contOpts.OriginalRequest = cloneBytes(payload)
+	contOpts.Metadata = cloneMetadata(opts.Metadata)
+	if contOpts.Metadata == nil {
+		contOpts.Metadata = make(map[string]any)
+	}
+	contOpts.Metadata[coreauth.ExcludedAuthsMetadataKey] = append([]string(nil), r.failedAuths...)
+
+	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, contReq, contOpts)
+	if err != nil && len(r.failedAuths) > 0 {
+		// No other credential is available; a transient failure may still recover on the same one.
+		delete(contOpts.Metadata, coreauth.ExcludedAuthsMetadataKey)
+		chunks, err = h.AuthManager.ExecuteStream(ctx, providers, contReq, contOpts)
+	}
+	if err != nil {
+		log.Debugf("mid-stream recovery for %s failed to start: %v", req.Model, err)
+		return nil, false
+	}
+	log.Debugf("resuming %s stream after mid-stream failure: %v", req.Model, cause)
+	r.beginContinuation(cause)
+	return chunks, true
+}
+
+// abandon gives up on a continuation that cannot be spliced and reports the original failure.
+func (r *streamRecovery) abandon(err error) *interfaces.ErrorMessage {
+	log.Debugf("mid-stream recovery abandoned: %v", err)
+	status := statusFromError(r.cause)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return &interfaces.ErrorMessage{StatusCode: status, Error: r.cause}
+}
+
+func (r *streamRecovery) beginContinuation(cause error) {
+	text := r.text.String()
+	r.cause = cause
+	r.splice = &streamSplice{
+		prior:      append([]recoveredTool(nil), r.tools...),
+		textReplay: text[len(strings.TrimRight(text, " \t\r\n")):],
+		replayed:   make(map[int]int),
+		blockMap:   make(map[int]int),
+		blockTool:  make(map[int]int),
+		nextBlock:  r.blocks,
+	}
+}
+
+// continuationRequest appends the text streamed so far to the client request as a trailing
+// assistant turn. Trailing whitespace is left out because Claude rejects it in prefills; the
+// continuation's leading whitespace is matched against it instead.
+func (r *streamRecovery) continuationRequest(rawJSON []byte) ([]byte, bool) {
+	prefill := strings.TrimRight(r.text.String(), " \t\r\n")
+	if prefill == "" {
+		return rawJSON, true
+	}
+	var (
+		path, role, partsField, part string
+		err                          error
+	)
+	switch r.format {
+	case constant.Claude, constant.OpenAI:
+		path, role, partsField = "messages", "assistant", "content"
+		part, err = sjson.Set(`{"type":"text","text":""}`, "text", prefill)
+	case constant.Gemini:
+		path, role, partsField = "contents", "model", "parts"
+		part, err = sjson.Set(`{"text":""}`, "text", prefill)
+	default:
+		return nil, false
+	}
+	messages := gjson.GetBytes(rawJSON, path)
+	if err != nil || !messages.IsArray() {
+		return nil, false
+	}
+	items := messages.Array()
+	// A client prefill is extended rather than followed by a second assistant turn.
+	if n := len(items); n > 0 && items[n-1].Get("role").String() == role {
+		fieldPath := path + "." + strconv.Itoa(n-1) + "." + partsField
+		existing := items[n-1].Get(partsField)
+		switch {
+		case existing.Type == gjson.String:
+			out, errSet := sjson.SetBytes(rawJSON, fieldPath, existing.String()+prefill)
+			return out, errSet == nil
+		case existing.IsArray():
+			out, errSet := sjson.SetRawBytes(rawJSON, fieldPath+".-1", []byte(part))
+			return out, errSet == nil
+		}
+	}
+	turn, errTurn := sjson.SetRaw(`{"role":""}`, partsField, "["+part+"]")
+	if errTurn != nil {
+		return nil, false
+	}
+	turn, _ = sjson.Set(turn, "role", role)
+	out, errSet := sjson.SetRawBytes(rawJSON, path+".-1", []byte(turn))
+	return out, errSet == nil
+}
+
+func (r *streamRecovery) appendText(text string) {
+	if text == "" {
+		return
+	}
+	if len(r.tools) > 0 {
+		r.broken = true
+	}
+	r.text.WriteString(text)
+}
+
+func (r *streamRecovery) toolAt(index int) *recoveredTool {
+	for i := range r.tools {
+		if r.tools[i].index == index {
+			return &r.tools[i]
+		}
+	}
+	return nil
+}
+
+// observe records text and tool call deltas of a chunk sent to the client.
+func (r *streamRecovery) observe(payload []byte) {
+	switch r.format {
+	case constant.Claude:
+		for _, line := range strings.Split(string(payload), "\n") {
+			if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:"); ok {
+				r.observeClaude(gjson.Parse(strings.TrimSpace(data)))
+			}
+		}
+	case constant.OpenAI:
+		if gjson.ValidBytes(payload) {
+			r.observeOpenAI(gjson.ParseBytes(payload))
+		}
+	case constant.Gemini:
+		if gjson.ValidBytes(payload) {
+			r.observeGemini(gjson.ParseBytes(payload))
+		}
+	}
+}
+
+func (r *streamRecovery) observeClaude(event gjson.Result) {
+	index := int(event.Get("index").Int())
+	switch event.Get("type").String() {
+	case "content_block_start":
+		if index+1 > r.blocks {
+			r.blocks = index + 1
+		}
+		r.openText = -1
+		switch event.Get("content_block.type").String() {
+		case "text":
+			r.openText = index
+			r.appendText(event.Get("content_block.text").String())
+		case "tool_use":
+			r.tools = append(r.tools, recoveredTool{index: index, name: event.Get("content_block.name").String()})
+		}
+	case "content_block_delta":
+		switch event.Get("delta.type").String() {
+		case "text_delta":
+			r.appendText(event.Get("delta.text").String())
+		case "input_json_delta":
+			if tool := r.toolAt(index); tool != nil {
+				tool.args += event.Get("delta.partial_json").String()
+			}
+		}
+	case "content_block_stop":
+		if index == r.openText {
+			r.openText = -1
+		}
+		if tool := r.toolAt(index); tool != nil {
+			tool.closed = true
+		}
+	case "message_delta":
+		if event.Get("delta.stop_reason").String() != "" {
+			r.finished = true
+		}
+	case "message_stop", "error":
+		r.finished = true
+	}
+}
+
+func (r *streamRecovery) observeOpenAI(root gjson.Result) {
+	if r.chunkID == "" {
+		r.chunkID = root.Get("id").String()
+		r.created = root.Get("created").Int()
+	}
+	choices := root.Get("choices").Array()
+	if len(choices) > 1 {
+		r.broken = true
+	}
+	if len(choices) == 0 {
+		return
+	}
+	choice := choices[0]
+	r.appendText(choice.Get("delta.content").String())
+	for _, call := range choice.Get("delta.tool_calls").Array() {
+		index := int(call.Get("index").Int())
+		tool := r.toolAt(index)
+		if tool == nil {
+			r.tools = append(r.tools, recoveredTool{index: index})
+			tool = &r.tools[len(r.tools)-1]
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			tool.name = name
+		}
+		tool.args += call.Get("function.arguments").String()
+	}
+	if choice.Get("finish_reason").String() != "" {
+		r.finished = true
+	}
+}
+
+func (r *streamRecovery) observeGemini(root gjson.Result) {
+	if r.responseID == "" {
+		r.responseID = root.Get("responseId").String()
+	}
+	candidates := root.Get("candidates").Array()
+	if len(candidates) > 1 {
+		r.broken = true
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	for _, part := range candidates[0].Get("content.parts").Array() {
+		if part.Get("thought").Bool() {
+			continue
+		}
+		if call := part.Get("functionCall"); call.Exists() {
+			r.tools = append(r.tools, recoveredTool{index: len(r.tools), name: call.Get("name").String(), args: compactJSON(call.Get("args").Raw), closed: true})
+			continue
+		}
+		r.appendText(part.Get("text").String())
+	}
+	if candidates[0].Get("finishReason").String() != "" {
+		r.finished = true
+	}
+}
+
+// skipReplayedText drops the leading whitespace that was already sent but left out of the prefill.
+func (s *streamSplice) skipReplayedText(text string) string {
+	for s.textReplay != "" && text != "" && text[0] == s.textReplay[0] {
+		text = text[1:]
+		s.textReplay = s.textReplay[1:]
+	}
+	if text != "" {
+		s.textReplay = ""
+	}
+	return text
+}
+
+// replayArgs matches regenerated tool call arguments against the ones already sent and returns
+// the unsent remainder. It fails when the continuation produces different arguments.
+func (s *streamSplice) replayArgs(slot int, delta string) (string, bool) {
+	sent := s.prior[slot].args
+	done := s.replayed[slot]
+	if done >= len(sent) {
+		return delta, true
+	}
+	n := min(len(delta), len(sent)-done)
+	if delta[:n] != sent[done:done+n] {
+		return "", false
+	}
+	s.replayed[slot] = done + n
+	return delta[n:], true
+}
+
+// caughtUp reports whether the continuation has reproduced every tool call already sent.
+func (s *streamSplice) caughtUp() bool {
+	if s.toolSeen < len(s.prior) {
+		return false
+	}
+	for slot, tool := range s.prior {
+		if s.replayed[slot] < len(tool.args) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *streamRecovery) spliceClaude(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(string(payload), "\n") {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		if !ok {
+			continue
+		}
+		event, keep, err := r.spliceClaudeEvent(strings.TrimSpace(data))
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
+			continue
+		}
+		buf.WriteString("event: " + gjson.Get(event, "type").String() + "\ndata: " + event + "\n\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *streamRecovery) spliceClaudeEvent(data string) (string, bool, error) {
+	s := r.splice
+	event := gjson.Parse(data)
+	index := int(event.Get("index").Int())
+	switch event.Get("type").String() {
+	case "message_start", "ping":
+		return "", false, nil
+	case "content_block_start":
+		switch event.Get("content_block.type").String() {
+		case "thinking", "redacted_thinking":
+			// Reasoning for the part already answered has been streamed; a second pass is dropped.
+			s.blockMap[index] = -1
+			return "", false, nil
+		case "text":
+			if r.openText >= 0 && !s.textMapped {
+				s.textMapped = true
+				s.blockMap[index] = r.openText
+				return "", false, nil
+			}
+		case "tool_use":
+			slot := s.toolSeen
+			s.toolSeen++
+			if slot < len(s.prior) {
+				if event.Get("content_block.name").String() != s.prior[slot].name {
+					return "", false, errContinuationDiverged
+				}
+				s.blockMap[index] = s.prior[slot].index
+				s.blockTool[index] = slot
+				return "", false, nil
+			}
+		}
+		s.blockMap[index] = s.nextBlock
+		s.nextBlock++
+		out, _ := sjson.Set(data, "index", s.blockMap[index])
+		return out, true, nil
+	case "content_block_delta":
+		target, ok := s.blockMap[index]
+		if !ok || target < 0 {
+			return "", false, nil
+		}
+		out := data
+		switch event.Get("delta.type").String() {
+		case "text_delta":
+			text := s.skipReplayedText(event.Get("delta.text").String())
+			if text == "" {
+				return "", false, nil
+			}
+			out, _ = sjson.Set(out, "delta.text", text)
+		case "input_json_delta":
+			if slot, replaying := s.blockTool[index]; replaying {
+				rest, matched := s.replayArgs(slot, event.Get("delta.partial_json").String())
+				if !matched {
+					return "", false, errContinuationDiverged
+				}
+				if rest == "" {
+					return "", false, nil
+				}
+				out, _ = sjson.Set(out, "delta.partial_json", rest)
+			}
+		}
+		out, _ = sjson.Set(out, "index", target)
+		return out, true, nil
+	case "content_block_stop":
+		target, ok := s.blockMap[index]
+		if !ok || target < 0 {
+			return "", false, nil
+		}
+		if slot, replaying := s.blockTool[index]; replaying {
+			if s.replayed[slot] < len(s.prior[slot].args) {
+				return "", false, errContinuationDiverged
+			}
+			if s.prior[slot].closed {
+				return "", false, nil
+			}
+		}
+		out, _ := sjson.Set(data, "index", target)
+		return out, true, nil
+	case "message_delta":
+		if event.Get("delta.stop_reason").String() != "" && !s.caughtUp() {
+			return "", false, errContinuationDiverged
+		}
+	}
+	return data, true, nil
+}
+
+func (r *streamRecovery) spliceOpenAI(payload []byte) ([]byte, error) {
+	if !gjson.ValidBytes(payload) {
+		return payload, nil
+	}
+	s := r.splice
+	root := gjson.ParseBytes(payload)
+	out := string(payload)
+	if r.chunkID != "" && root.Get("id").Exists() {
+		out, _ = sjson.Set(out, "id", r.chunkID)
+	}
+	if r.created != 0 && root.Get("created").Exists() {
+		out, _ = sjson.Set(out, "created", r.created)
+	}
+	choice := root.Get("choices.0")
+	if !choice.Exists() {
+		return []byte(out), nil
+	}
+	out, _ = sjson.Delete(out, "choices.0.delta.role")
+	out, _ = sjson.Delete(out, "choices.0.delta.reasoning_content")
+	if content := choice.Get("delta.content"); content.Type == gjson.String {
+		if text := s.skipReplayedText(content.String()); text != "" {
+			out, _ = sjson.Set(out, "choices.0.delta.content", text)
+		} else {
+			out, _ = sjson.Delete(out, "choices.0.delta.content")
+		}
+	}
+	if calls := choice.Get("delta.tool_calls"); calls.IsArray() {
+		kept := "[]"
+		for _, call := range calls.Array() {
+			item := call.Raw
+			slot := int(call.Get("index").Int())
+			if slot < len(s.prior) {
+				if name := call.Get("function.name").String(); name != "" && name != s.prior[slot].name {
+					return nil, errContinuationDiverged
+				}
+				if slot >= s.toolSeen {
+					s.toolSeen = slot + 1
+				}
+				rest, matched := s.replayArgs(slot, call.Get("function.arguments").String())
+				if !matched {
+					return nil, errContinuationDiverged
+				}
+				if rest == "" {
+					continue
+				}
+				item, _ = sjson.Delete(item, "id")
+				item, _ = sjson.Delete(item, "type")
+				item, _ = sjson.Delete(item, "function.name")
+				item, _ = sjson.Set(item, "function.arguments", rest)
+			}
+			kept, _ = sjson.SetRaw(kept, "-1", item)
+		}
+		if kept == "[]" {
+			out, _ = sjson.Delete(out, "choices.0.delta.tool_calls")
+		} else {
+			out, _ = sjson.SetRaw(out, "choices.0.delta.tool_calls", kept)
+		}
+	}
+	finish := choice.Get("finish_reason").String()
+	if finish != "" && !s.caughtUp() {
+		return nil, errContinuationDiverged
+	}
+	if finish == "" && len(gjson.Get(out, "choices.0.delta").Map()) == 0 && !root.Get("usage").Exists() {
+		return nil, nil
+	}
+	return []byte(out), nil
+}
+
+func (r *streamRecovery) spliceGemini(payload []byte) ([]byte, error) {
+	if !gjson.ValidBytes(payload) {
+		return payload, nil
+	}
+	s := r.splice
+	root := gjson.ParseBytes(payload)
+	out := string(payload)
+	if r.responseID != "" && root.Get("responseId").Exists() {
+		out, _ = sjson.Set(out, "responseId", r.responseID)
+	}
+	candidate := root.Get("candidates.0")
+	if !candidate.Exists() {
+		return []byte(out), nil
+	}
+	kept := "[]"
+	for _, part := range candidate.Get("content.parts").Array() {
+		if part.Get("thought").Bool() {
+			continue
+		}
+		item := part.Raw
+		if call := part.Get("functionCall"); call.Exists() {
+			slot := s.toolSeen
+			s.toolSeen++
+			if slot < len(s.prior) {
+				if call.Get("name").String() != s.prior[slot].name || compactJSON(call.Get("args").Raw) != s.prior[slot].args {
+					return nil, errContinuationDiverged
+				}
+				s.replayed[slot] = len(s.prior[slot].args)
+				continue
+			}
+		} else if text := part.Get("text"); text.Exists() {
+			trimmed := s.skipReplayedText(text.String())
+			if trimmed == "" {
+				continue
+			}
+			item, _ = sjson.Set(item, "text", trimmed)
+		}
+		kept, _ = sjson.SetRaw(kept, "-1", item)
+	}
+	if candidate.Get("content.parts").Exists() {
+		out, _ = sjson.SetRaw(out, "candidates.0.content.parts", kept)
+	}
+	finish := candidate.Get("finishReason").String()
+	if finish != "" && !s.caughtUp() {
+		return nil, errContinuationDiverged
+	}
+	if kept == "[]" && finish == "" && !root.Get("usageMetadata").Exists() {
+		return nil, nil
+	}
+	return []byte(out), nil
+}
+
+func compactJSON(raw string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		return raw
+	}
+	return buf.String()
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type midstreamFailExecutor struct {
+	mu       sync.Mutex
+	auths    []string
+	payloads [][]byte
+}
+
+func (e *midstreamFailExecutor) Identifier() string { return "claude" }
+
+func (e *midstreamFailExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *midstreamFailExecutor) ExecuteStream(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.auths = append(e.auths, auth.ID)
+	e.payloads = append(e.payloads, req.Payload)
+	call := len(e.auths)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 4)
+	if call == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chunk-1","created":1,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hello, "}}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chunk-1","created":1,"choices":[{"index":0,"delta":{"content":"wor"}}]}`)}
+		ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream_reset", Message: "connection reset", HTTPStatus: http.StatusBadGateway}}
+	} else {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chunk-2","created":2,"choices":[{"index":0,"delta":{"role":"assistant","content":"ld!"}}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chunk-2","created":2,"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (e *midstreamFailExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *midstreamFailExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *midstreamFailExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_ResumesMidStream(t *testing.T) {
+	executor := &midstreamFailExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"resume-a", "resume-b"} {
+		auth := &coreauth.Auth{ID: id, Provider: "claude", Status: coreauth.StatusActive, Metadata: map[string]any{"email": id + "@example.com"}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "resume-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{MidstreamRetries: 1}}, manager)
+	request := []byte(`{"model":"resume-model","stream":true,"messages":[{"role":"user","content":"greet"}]}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "resume-model", request, "")
+
+	var text strings.Builder
+	for chunk := range dataChan {
+		if id := gjson.GetBytes(chunk, "id").String(); id != "chunk-1" {
+			t.Fatalf("continuation chunk id = %q, want the original id", id)
+		}
+		if gjson.GetBytes(chunk, "choices.0.delta.role").Exists() && text.Len() > 0 {
+			t.Fatalf("continuation repeated the role delta: %s", chunk)
+		}
+		text.WriteString(gjson.GetBytes(chunk, "choices.0.delta.content").String())
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if text.String() != "Hello, world!" {
+		t.Fatalf("spliced text = %q", text.String())
+	}
+	if len(executor.auths) != 2 || executor.auths[0] == executor.auths[1] {
+		t.Fatalf("continuation must use another credential, got %v", executor.auths)
+	}
+	last := gjson.GetBytes(executor.payloads[1], "messages.@reverse.0")
+	if last.Get("role").String() != "assistant" || last.Get("content.0.text").String() != "Hello, wor" {
+		t.Fatalf("continuation prefill = %s", last.Raw)
+	}
+}
+
+func TestStreamRecovery_ClaudeReplaysToolCall(t *testing.T) {
+	r := &streamRecovery{format: "claude", openText: -1}
+	sent := []string{
+		`event: message_start` + "\n" + `data: {"type":"message_start","message":{"id":"msg_1"}}` + "\n\n",
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n",
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking. "}}` + "\n",
+		`data: {"type":"content_block_stop","index":0}` + "\n",
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"read","input":{}}}` + "\n",
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\":\"a"}}` + "\n",
+	}
+	for _, chunk := range sent {
+		if _, err := r.forward([]byte(chunk)); err != nil {
+			t.Fatalf("forward: %v", err)
+		}
+	}
+	if !r.resumable() {
+		t.Fatalf("stream interrupted inside a tool call should be resumable")
+	}
+	body, ok := r.continuationRequest([]byte(`{"messages":[{"role":"user","content":"go"}]}`))
+	if !ok || gjson.GetBytes(body, "messages.1.content.0.text").String() != "Checking." {
+		t.Fatalf("unexpected continuation request %s", body)
+	}
+	r.beginContinuation(&coreauth.Error{Message: "reset", HTTPStatus: http.StatusBadGateway})
+
+	continuation := strings.Join([]string{
+		`data: {"type":"message_start","message":{"id":"msg_2"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_2","name":"read","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"path\":\"ab.txt\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+	}, "\n")
+	out, err := r.forward([]byte(continuation))
+	if err != nil {
+		t.Fatalf("splice: %v", err)
+	}
+	got := string(out)
+	if strings.Contains(got, "message_start") || strings.Contains(got, "toolu_2") {
+		t.Fatalf("duplicate preamble spliced into stream: %s", got)
+	}
+	if !strings.Contains(got, `"index":1,"delta":{"type":"input_json_delta","partial_json":"b.txt\"}"}`) {
+		t.Fatalf("tool arguments not trimmed to the unsent remainder: %s", got)
+	}
+	if r.tools[0].args != `{"path":"ab.txt"}` || !r.tools[0].closed {
+		t.Fatalf("recorded tool = %+v", r.tools[0])
+	}
+
+	r.beginContinuation(nil)
+	diverged := `data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_3","name":"write","input":{}}}`
+	if _, err = r.forward([]byte(diverged)); err != errContinuationDiverged {
+		t.Fatalf("expected divergence error, got %v", err)
+	}
+}
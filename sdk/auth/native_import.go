@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	baseauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
+)
+
+// NativeImportOptions supplies details the native credential files do not contain.
+type NativeImportOptions struct {
+	// Email is the account email used when the credential file and profile carry none, so one
+	// value can be passed for several providers without renaming their accounts. Qwen Code
+	// credentials never include one, so it is required there.
+	Email string
+	// ProjectID is the Google Cloud project used with imported Gemini CLI credentials.
+	ProjectID string
+	// DiscoverGeminiProject resolves the project of Gemini CLI credentials imported without
+	// one, the way the login flow onboards the account. It must set storage.ProjectID.
+	DiscoverGeminiProject func(storage *gemini.GeminiTokenStorage) error
+	// Profile is the optional account file written next to the credentials
+	// (~/.claude.json for Claude Code, ~/.gemini/google_accounts.json for Gemini CLI),
+	// used to discover the account email.
+	Profile []byte
+}
+
+// NativeImportProviders lists the providers whose CLI credential files can be imported.
+var NativeImportProviders = []string{"claude", "codex", "gemini", "qwen"}
+
+// NormalizeNativeProvider maps CLI tool names ("claude-code", "gemini-cli", "qwen-code") to provider keys.
+func NormalizeNativeProvider(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "claude", "claude-code", "anthropic":
+		return "claude"
+	case "codex", "codex-cli", "openai":
+		return "codex"
+	case "gemini", "gemini-cli", "google":
+		return "gemini"
+	case "qwen", "qwen-code":
+		return "qwen"
+	default:
+		return ""
+	}
+}
+
+// DetectNativeProvider guesses the provider from the shape of a native credential file.
+func DetectNativeProvider(data []byte) string {
+	root := gjson.ParseBytes(data)
+	switch {
+	case root.Get("claudeAiOauth").Exists():
+		return "claude"
+	case root.Get("tokens.refresh_token").Exists():
+		return "codex"
+	case root.Get("resource_url").Exists():
+		return "qwen"
+	case root.Get("refresh_token").Exists() && (root.Get("id_token").Exists() || strings.Contains(root.Get("scope").String(), "googleapis.com")):
+		return "gemini"
+	default:
+		return ""
+	}
+}
+
+// NativeCredentialPaths returns the default credential file and optional profile file the
+// provider's CLI writes under home. CODEX_HOME is honoured for Codex.
+func NativeCredentialPaths(provider, home string) (credentials, profile string) {
+	switch NormalizeNativeProvider(provider) {
+	case "claude":
+		return filepath.Join(home, ".claude", ".credentials.json"), filepath.Join(home, ".claude.json")
+	case "codex":
+		if codexHome := strings.TrimSpace(os.Getenv("CODEX_HOME")); codexHome != "" {
+			return filepath.Join(codexHome, "auth.json"), ""
+		}
+		return filepath.Join(home, ".codex", "auth.json"), ""
+	case "gemini":
+		return filepath.Join(home, ".gemini", "oauth_creds.json"), filepath.Join(home, ".gemini", "google_accounts.json")
+	case "qwen":
+		return filepath.Join(home, ".qwen", "oauth_creds.json"), ""
+	default:
+		return "", ""
+	}
+}
+
+// ImportNativeCredential converts a credential file written by a provider's own CLI into an
+// auth record using the same token storage, file name and metadata as the login flows.
+func ImportNativeCredential(provider string, data []byte, opts NativeImportOptions) (*coreauth.Auth, error) {
+	if !gjson.ValidBytes(data) {
+		return nil, fmt.Errorf("credential file is not valid JSON")
+	}
+	if provider = NormalizeNativeProvider(provider); provider == "" {
+		provider = DetectNativeProvider(data)
+	}
+	root := gjson.ParseBytes(data)
+	email := strings.TrimSpace(opts.Email)
+	switch provider {
+	case "claude":
+		return importClaudeCredential(root, email, opts.Profile)
+	case "codex":
+		return importCodexCredential(root, email)
+	case "gemini":
+		return importGeminiCredential(root, email, strings.TrimSpace(opts.ProjectID), opts.Profile, opts.DiscoverGeminiProject)
+	case "qwen":
+		return importQwenCredential(root, email)
+	default:
+		return nil, fmt.Errorf("unrecognized credential file; supported providers: %s", strings.Join(NativeImportProviders, ", "))
+	}
+}
+
+func importClaudeCredential(root gjson.Result, email string, profile []byte) (*coreauth.Auth, error) {
+	oauth := root.Get("claudeAiOauth")
+	if !oauth.Exists() {
+		oauth = root
+	}
+	refreshToken := oauth.Get("refreshToken").String()
+	if refreshToken == "" {
+		return nil, fmt.Errorf("claude credentials missing refreshToken")
+	}
+	fallbackEmail := email
+	email = ""
+	if len(profile) > 0 {
+		email = gjson.GetBytes(profile, "oauthAccount.emailAddress").String()
+	}
+	if email == "" {
+		email = fallbackEmail
+	}
+	if email == "" {
+		return nil, &EmailRequiredError{Prompt: "Claude Code credentials do not include the account email; provide it or the ~/.claude.json profile."}
+	}
+	storage := &claude.ClaudeTokenStorage{
+		AccessToken:  oauth.Get("accessToken").String(),
+		RefreshToken: refreshToken,
+		LastRefresh:  time.Now().Format(time.RFC3339),
+		Email:        email,
+		Type:         "claude",
+		Expire:       millisToRFC3339(oauth.Get("expiresAt").Int()),
+	}
+	return nativeAuthRecord("claude", fmt.Sprintf("claude-%s.json", email), storage, map[string]any{"email": email}), nil
+}
+
+func importCodexCredential(root gjson.Result, email string) (*coreauth.Auth, error) {
+	tokens := root.Get("tokens")
+	refreshToken := tokens.Get("refresh_token").String()
+	if refreshToken == "" {
+		if root.Get("OPENAI_API_KEY").String() != "" {
+			return nil, fmt.Errorf("codex auth.json holds an API key login; add it under codex-api-key instead")
+		}
+		return nil, fmt.Errorf("codex auth.json missing tokens.refresh_token")
+	}
+	idToken := tokens.Get("id_token").String()
+	accountID := tokens.Get("account_id").String()
+	fallbackEmail := email
+	email = ""
+	if claims, err := codex.ParseJWTToken(idToken); err == nil {
+		email = claims.GetUserEmail()
+		if accountID == "" {
+			accountID = claims.GetAccountID()
+		}
+	}
+	if email == "" {
+		email = fallbackEmail
+	}
+	if email == "" {
+		return nil, &EmailRequiredError{Prompt: "Codex id_token does not include the account email; provide it explicitly."}
+	}
+	lastRefresh := root.Get("last_refresh").String()
+	if lastRefresh == "" {
+		lastRefresh = time.Now().Format(time.RFC3339)
+	}
+	accessToken := tokens.Get("access_token").String()
+	storage := &codex.CodexTokenStorage{
+		IDToken:      idToken,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		AccountID:    accountID,
+		LastRefresh:  lastRefresh,
+		Email:        email,
+		Type:         "codex",
+		Expire:       jwtExpiry(accessToken),
+	}
+	return nativeAuthRecord("codex", fmt.Sprintf("codex-%s.json", email), storage, map[string]any{"email": email}), nil
+}
+
+func importGeminiCredential(root gjson.Result, email, projectID string, profile []byte, discover func(*gemini.GeminiTokenStorage) error) (*coreauth.Auth, error) {
+	refreshToken := root.Get("refresh_token").String()
+	if refreshToken == "" {
+		return nil, fmt.Errorf("gemini oauth_creds.json missing refresh_token")
+	}
+	fallbackEmail := email
+	email = jwtClaim(root.Get("id_token").String(), "email")
+	if email == "" && len(profile) > 0 {
+		email = gjson.GetBytes(profile, "active").String()
+	}
+	if email == "" {
+		email = fallbackEmail
+	}
+	if email == "" {
+		return nil, &EmailRequiredError{Prompt: "Gemini CLI credentials do not include the account email; provide it or google_accounts.json."}
+	}
+	if projectID == "" {
+		projectID = strings.TrimSpace(os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	}
+	if projectID == "" && discover == nil {
+		return nil, fmt.Errorf("gemini credentials need a Google Cloud project id")
+	}
+	token := &oauth2.Token{
+		AccessToken:  root.Get("access_token").String(),
+		TokenType:    root.Get("token_type").String(),
+		RefreshToken: refreshToken,
+	}
+	if expiry := root.Get("expiry_date").Int(); expiry > 0 {
+		token.Expiry = time.UnixMilli(expiry)
+	}
+	storage, err := gemini.NewTokenStorage(token, email, projectID)
+	if err != nil {
+		return nil, err
+	}
+	storage.Type = "gemini"
+	if projectID == "" {
+		if err = discover(storage); err != nil {
+			return nil, fmt.Errorf("discover gemini project: %w", err)
+		}
+		if projectID = strings.TrimSpace(storage.ProjectID); projectID == "" {
+			return nil, fmt.Errorf("gemini credentials need a Google Cloud project id")
+		}
+	}
+	fileName := fmt.Sprintf("%s-%s.json", email, projectID)
+	return nativeAuthRecord("gemini", fileName, storage, map[string]any{"email": email, "project_id": projectID}), nil
+}
+
+func importQwenCredential(root gjson.Result, email string) (*coreauth.Auth, error) {
+	refreshToken := root.Get("refresh_token").String()
+	if refreshToken == "" {
+		return nil, fmt.Errorf("qwen oauth_creds.json missing refresh_token")
+	}
+	if email == "" {
+		return nil, &EmailRequiredError{Prompt: "Qwen Code credentials do not include an account email; provide an email address or alias."}
+	}
+	storage := &qwen.QwenTokenStorage{
+		AccessToken:  root.Get("access_token").String(),
+		RefreshToken: refreshToken,
+		LastRefresh:  time.Now().Format(time.RFC3339),
+		ResourceURL:  root.Get("resource_url").String(),
+		Email:        email,
+		Type:         "qwen",
+		Expire:       millisToRFC3339(root.Get("expiry_date").Int()),
+	}
+	return nativeAuthRecord("qwen", fmt.Sprintf("qwen-%s.json", email), storage, map[string]any{"email": email}), nil
+}
+
+func nativeAuthRecord(provider, fileName string, storage baseauth.TokenStorage, metadata map[string]any) *coreauth.Auth {
+	metadata["type"] = provider
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: provider,
+		FileName: fileName,
+		Storage:  storage,
+		Metadata: metadata,
+	}
+}
+
+// SaveImportedAuth persists an imported record through store. When a credential for the same
+// provider and account email already exists (and, for Gemini, the same project) its tokens are
+// replaced in place, keeping its prefix, label, proxy and disabled state, rather than duplicated.
+func SaveImportedAuth(ctx context.Context, store coreauth.Store, record *coreauth.Auth) (string, bool, error) {
+	if store == nil {
+		return "", false, fmt.Errorf("no token store configured")
+	}
+	replaced := false
+	if existing, err := store.List(ctx); err == nil {
+		for _, item := range existing {
+			if item == nil || !strings.EqualFold(item.Provider, record.Provider) || !sameImportedAccount(item, record) {
+				continue
+			}
+			if err = mergeImportedAuth(item, record); err != nil {
+				return "", false, err
+			}
+			replaced = true
+			break
+		}
+	}
+	path, err := store.Save(ctx, record)
+	return path, replaced, err
+}
+
+// mergeImportedAuth turns record into an update of existing: the imported token fields are
+// written over the existing metadata, so settings stored next to the tokens survive.
+func mergeImportedAuth(existing, record *coreauth.Auth) error {
+	fields := make(map[string]any)
+	if record.Storage != nil {
+		raw, err := json.Marshal(record.Storage)
+		if err != nil {
+			return fmt.Errorf("marshal imported token failed: %w", err)
+		}
+		if err = json.Unmarshal(raw, &fields); err != nil {
+			return fmt.Errorf("parse imported token failed: %w", err)
+		}
+	}
+	for key, value := range record.Metadata {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	merged := make(map[string]any, len(existing.Metadata)+len(fields))
+	for key, value := range existing.Metadata {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	record.ID = existing.ID
+	if existing.FileName != "" {
+		record.FileName = existing.FileName
+	}
+	record.Prefix = existing.Prefix
+	record.Label = existing.Label
+	record.ProxyURL = existing.ProxyURL
+	record.Disabled = existing.Disabled
+	record.Attributes = make(map[string]string, len(existing.Attributes))
+	for key, value := range existing.Attributes {
+		record.Attributes[key] = value
+	}
+	record.Storage = nil
+	record.Metadata = merged
+	return nil
+}
+
+func sameImportedAccount(existing, record *coreauth.Auth) bool {
+	email := strings.TrimSpace(metadataString(record.Metadata, "email"))
+	if email == "" || !strings.EqualFold(strings.TrimSpace(metadataString(existing.Metadata, "email")), email) {
+		return false
+	}
+	if record.Provider == "gemini" {
+		return strings.TrimSpace(metadataString(existing.Metadata, "project_id")) == strings.TrimSpace(metadataString(record.Metadata, "project_id"))
+	}
+	return true
+}
+
+func metadataString(metadata map[string]any, key string) string {
+	if metadata == nil {
+		return ""
+	}
+	value, _ := metadata[key].(string)
+	return value
+}
+
+func millisToRFC3339(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).Format(time.RFC3339)
+}
+
+// jwtExpiry returns the exp claim of an unverified JWT as RFC3339, or "" when unavailable.
+func jwtExpiry(token string) string {
+	payload := jwtPayload(token)
+	if exp := gjson.GetBytes(payload, "exp").Int(); exp > 0 {
+		return time.Unix(exp, 0).Format(time.RFC3339)
+	}
+	return ""
+}
+
+func jwtClaim(token, claim string) string {
+	return gjson.GetBytes(jwtPayload(token), claim).String()
+}
+
+func jwtPayload(token string) []byte {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil || !json.Valid(payload) {
+		return nil
+	}
+	return payload
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/claude"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/gemini"
+	"github.com/tidwall/gjson"
+)
+
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestImportNativeCodexCredential(t *testing.T) {
+	idToken := testJWT(`{"email":"dev@example.com","https://api.openai.com/auth":{"chatgpt_account_id":"acct-1"}}`)
+	accessToken := testJWT(`{"exp":1900000000}`)
+	data := []byte(`{"OPENAI_API_KEY":null,"tokens":{"id_token":"` + idToken + `","access_token":"` + accessToken + `","refresh_token":"rt"},"last_refresh":"2025-01-01T00:00:00Z"}`)
+
+	record, err := ImportNativeCredential("", data, NativeImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportNativeCredential error = %v", err)
+	}
+	if record.Provider != "codex" || record.FileName != "codex-dev@example.com.json" {
+		t.Fatalf("unexpected record %s / %s", record.Provider, record.FileName)
+	}
+	storage, ok := record.Storage.(*codex.CodexTokenStorage)
+	if !ok {
+		t.Fatalf("storage type = %T", record.Storage)
+	}
+	if storage.RefreshToken != "rt" || storage.Email != "dev@example.com" || storage.Expire == "" {
+		t.Fatalf("unexpected storage %+v", storage)
+	}
+}
+
+func TestImportNativeClaudeCredentialUsesProfileEmail(t *testing.T) {
+	data := []byte(`{"claudeAiOauth":{"accessToken":"at","refreshToken":"rt","expiresAt":1900000000000,"scopes":["user:inference"]}}`)
+	if _, err := ImportNativeCredential("claude-code", data, NativeImportOptions{}); err == nil {
+		t.Fatal("expected email error without profile")
+	}
+	record, err := ImportNativeCredential("claude-code", data, NativeImportOptions{Profile: []byte(`{"oauthAccount":{"emailAddress":"me@example.com"}}`)})
+	if err != nil {
+		t.Fatalf("ImportNativeCredential error = %v", err)
+	}
+	storage := record.Storage.(*claude.ClaudeTokenStorage)
+	if record.FileName != "claude-me@example.com.json" || storage.AccessToken != "at" || storage.Expire == "" {
+		t.Fatalf("unexpected record %s %+v", record.FileName, storage)
+	}
+}
+
+func TestImportNativeCredentialEmailIsFallback(t *testing.T) {
+	idToken := testJWT(`{"email":"dev@example.com"}`)
+	codexData := []byte(`{"tokens":{"id_token":"` + idToken + `","access_token":"at","refresh_token":"rt"}}`)
+	record, err := ImportNativeCredential("codex", codexData, NativeImportOptions{Email: "alias"})
+	if err != nil || record.FileName != "codex-dev@example.com.json" {
+		t.Fatalf("codex import = %v, %v; want the id_token email", record, err)
+	}
+
+	claudeData := []byte(`{"claudeAiOauth":{"accessToken":"at","refreshToken":"rt"}}`)
+	profile := []byte(`{"oauthAccount":{"emailAddress":"me@example.com"}}`)
+	record, err = ImportNativeCredential("claude", claudeData, NativeImportOptions{Email: "alias", Profile: profile})
+	if err != nil || record.FileName != "claude-me@example.com.json" {
+		t.Fatalf("claude import = %v, %v; want the profile email", record, err)
+	}
+	record, err = ImportNativeCredential("claude", claudeData, NativeImportOptions{Email: "alias"})
+	if err != nil || record.FileName != "claude-alias.json" {
+		t.Fatalf("claude import without profile = %v, %v; want the given email", record, err)
+	}
+}
+
+func TestImportNativeGeminiCredential(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	data := []byte(`{"access_token":"ya29","refresh_token":"1//rt","token_type":"Bearer","id_token":"` + testJWT(`{"email":"g@example.com"}`) + `","expiry_date":1900000000000}`)
+	if _, err := ImportNativeCredential("gemini-cli", data, NativeImportOptions{}); err == nil {
+		t.Fatal("expected project id error")
+	}
+	record, err := ImportNativeCredential("", data, NativeImportOptions{ProjectID: "proj-1"})
+	if err != nil {
+		t.Fatalf("ImportNativeCredential error = %v", err)
+	}
+	storage := record.Storage.(*gemini.GeminiTokenStorage)
+	token, _ := storage.Token.(map[string]any)
+	if record.FileName != "g@example.com-proj-1.json" || token["refresh_token"] != "1//rt" || token["client_id"] == nil {
+		t.Fatalf("unexpected record %s %+v", record.FileName, storage)
+	}
+}
+
+func TestImportNativeGeminiCredentialDiscoversProject(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	data := []byte(`{"access_token":"ya29","refresh_token":"1//rt","id_token":"` + testJWT(`{"email":"g@example.com"}`) + `"}`)
+	record, err := ImportNativeCredential("gemini", data, NativeImportOptions{
+		DiscoverGeminiProject: func(storage *gemini.GeminiTokenStorage) error {
+			if storage.Email != "g@example.com" || storage.Token == nil {
+				t.Fatalf("discovery got incomplete storage %+v", storage)
+			}
+			storage.ProjectID = "found-1"
+			storage.Auto = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportNativeCredential error = %v", err)
+	}
+	if record.FileName != "g@example.com-found-1.json" || record.Metadata["project_id"] != "found-1" {
+		t.Fatalf("unexpected record %s %+v", record.FileName, record.Metadata)
+	}
+
+	_, err = ImportNativeCredential("gemini", data, NativeImportOptions{
+		DiscoverGeminiProject: func(*gemini.GeminiTokenStorage) error { return errors.New("no projects") },
+	})
+	if err == nil || !strings.Contains(err.Error(), "no projects") {
+		t.Fatalf("discovery failure error = %v", err)
+	}
+}
+
+func TestSaveImportedAuthKeepsExistingSettings(t *testing.T) {
+	dir := t.TempDir()
+	existing := `{"type":"claude","email":"me@example.com","access_token":"old","refresh_token":"old-rt","prefix":"team","label":"work","proxy_url":"http://proxy:8080","disabled":true,"priority":3}`
+	if err := os.WriteFile(filepath.Join(dir, "claude-old.json"), []byte(existing), 0o600); err != nil {
+		t.Fatalf("write existing: %v", err)
+	}
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+
+	data := []byte(`{"claudeAiOauth":{"accessToken":"new","refreshToken":"new-rt","expiresAt":1900000000000}}`)
+	record, err := ImportNativeCredential("claude", data, NativeImportOptions{Email: "me@example.com"})
+	if err != nil {
+		t.Fatalf("ImportNativeCredential error = %v", err)
+	}
+	path, replaced, err := SaveImportedAuth(context.Background(), store, record)
+	if err != nil || !replaced {
+		t.Fatalf("SaveImportedAuth = %s, %t, %v", path, replaced, err)
+	}
+	if filepath.Base(path) != "claude-old.json" {
+		t.Fatalf("saved to %s, want the existing file", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read saved: %v", err)
+	}
+	saved := gjson.ParseBytes(raw)
+	if saved.Get("access_token").String() != "new" || saved.Get("refresh_token").String() != "new-rt" {
+		t.Fatalf("tokens not replaced: %s", raw)
+	}
+	if saved.Get("prefix").String() != "team" || saved.Get("label").String() != "work" ||
+		saved.Get("proxy_url").String() != "http://proxy:8080" || !saved.Get("disabled").Bool() || saved.Get("priority").Int() != 3 {
+		t.Fatalf("settings lost: %s", raw)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("import created a duplicate file: %d files", len(entries))
+	}
+}
+
+func TestImportNativeQwenCredentialRequiresEmail(t *testing.T) {
+	data := []byte(`{"access_token":"at","refresh_token":"rt","token_type":"Bearer","resource_url":"portal.qwen.ai","expiry_date":1900000000000}`)
+	if _, err := ImportNativeCredential("", data, NativeImportOptions{}); err == nil {
+		t.Fatal("expected email error")
+	}
+	record, err := ImportNativeCredential("", data, NativeImportOptions{Email: "alias"})
+	if err != nil || record.FileName != "qwen-alias.json" {
+		t.Fatalf("ImportNativeCredential = %v, %v", record, err)
+	}
+}
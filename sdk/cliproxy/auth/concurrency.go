@@ -0,0 +1,432 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	defaultConcurrencyQueue   = 100
+	defaultConcurrencyTimeout = 30 * time.Second
+	// concurrencyPollInterval re-checks saturated providers in case a released slot was
+	// signalled to a waiter that could not use it (e.g. the credential lacks its model).
+	concurrencyPollInterval = 500 * time.Millisecond
+
+	// ClientKeyMetadataKey carries the client API key in execution metadata so queued
+	// requests can be served fairly across clients.
+	ClientKeyMetadataKey = "client_key"
+)
+
+// errAuthsSaturated is returned by pickNext when eligible auths exist but all of them are
+// at their concurrency limit.
+var errAuthsSaturated = &Error{Code: "auth_saturated", Message: "all credentials are at their concurrency limit", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+
+// ConcurrencyStats is a snapshot of in-flight requests and queue state.
+type ConcurrencyStats struct {
+	Providers []ProviderQueueStat `json:"providers"`
+	Auths     []AuthInFlightStat  `json:"auths"`
+}
+
+// ProviderQueueStat describes the wait queue of one provider.
+type ProviderQueueStat struct {
+	Provider    string  `json:"provider"`
+	Queued      int     `json:"queued"`
+	Waited      int64   `json:"waited"`
+	TimedOut    int64   `json:"timed_out"`
+	Rejected    int64   `json:"rejected"`
+	AvgWaitMs   float64 `json:"avg_wait_ms"`
+	MaxWaitMs   int64   `json:"max_wait_ms"`
+	totalWaitMs int64
+}
+
+// AuthInFlightStat describes current usage of one credential.
+type AuthInFlightStat struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	InFlight int    `json:"in_flight"`
+	Limit    int    `json:"limit"`
+}
+
+// concurrencyWaiter is a request parked until a credential slot frees up.
+type concurrencyWaiter struct {
+	provider string
+	key      string
+	enqueued time.Time
+	deadline time.Time
+	ready    chan struct{}
+	signaled bool
+}
+
+// providerQueue keeps per-client FIFO lists and serves client keys round-robin.
+type providerQueue struct {
+	byKey  map[string][]*concurrencyWaiter
+	keys   []string
+	cursor int
+	size   int
+	stat   ProviderQueueStat
+}
+
+// concurrencyLimiter tracks in-flight requests per auth and the fair wait queues.
+type concurrencyLimiter struct {
+	mu        sync.Mutex
+	cfg       internalconfig.ConcurrencyConfig
+	inFlight  map[string]int
+	providers map[string]string
+	queues    map[string]*providerQueue
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{
+		inFlight:  make(map[string]int),
+		providers: make(map[string]string),
+		queues:    make(map[string]*providerQueue),
+	}
+}
+
+// SetConcurrency updates per-provider concurrency limits and queue settings.
+func (m *Manager) SetConcurrency(cfg internalconfig.ConcurrencyConfig) {
+	if m == nil || m.concurrency == nil {
+		return
+	}
+	m.concurrency.mu.Lock()
+	m.concurrency.cfg = cfg
+	m.concurrency.mu.Unlock()
+}
+
+// ConcurrencyStats returns in-flight counts per credential and queue statistics per provider.
+func (m *Manager) ConcurrencyStats() ConcurrencyStats {
+	out := ConcurrencyStats{Providers: []ProviderQueueStat{}, Auths: []AuthInFlightStat{}}
+	if m == nil || m.concurrency == nil {
+		return out
+	}
+	// Lock order matches pickNext: manager first, then limiter.
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c := m.concurrency
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, a := range m.auths {
+		limit := c.limitLocked(a)
+		if limit <= 0 && c.inFlight[id] == 0 {
+			continue
+		}
+		out.Auths = append(out.Auths, AuthInFlightStat{AuthID: id, Provider: a.Provider, InFlight: c.inFlight[id], Limit: limit})
+	}
+	for provider, q := range c.queues {
+		stat := q.stat
+		stat.Provider = provider
+		stat.Queued = q.size
+		if stat.Waited > 0 {
+			stat.AvgWaitMs = float64(stat.totalWaitMs) / float64(stat.Waited)
+		}
+		out.Providers = append(out.Providers, stat)
+	}
+	sort.Slice(out.Auths, func(i, j int) bool { return out.Auths[i].AuthID < out.Auths[j].AuthID })
+	sort.Slice(out.Providers, func(i, j int) bool { return out.Providers[i].Provider < out.Providers[j].Provider })
+	return out
+}
+
+// limitLocked resolves the concurrency limit for an auth: its own max_concurrency setting,
+// then the provider entry (compat name, then "openai-compatibility"). 0 means unlimited.
+func (c *concurrencyLimiter) limitLocked(a *Auth) int {
+	if a == nil {
+		return 0
+	}
+	if v, ok := a.Metadata["max_concurrency"]; ok {
+		switch n := v.(type) {
+		case float64:
+			return int(n)
+		case int:
+			return n
+		case string:
+			if parsed, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+				return parsed
+			}
+		}
+	}
+	if raw := strings.TrimSpace(a.Attributes["max_concurrency"]); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			return parsed
+		}
+	}
+	providers := c.cfg.Providers
+	if len(providers) == 0 {
+		return 0
+	}
+	lookup := func(key string) (int, bool) {
+		for k, v := range providers {
+			if strings.EqualFold(strings.TrimSpace(k), key) {
+				return v, true
+			}
+		}
+		return 0, false
+	}
+	if v, ok := lookup(a.Provider); ok {
+		return v
+	}
+	if compat := strings.TrimSpace(a.Attributes["compat_name"]); compat != "" {
+		if v, ok := lookup(compat); ok {
+			return v
+		}
+		if v, ok := lookup("openai-compatibility"); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// saturated reports whether an auth has no free slot. Called with the manager lock held;
+// the limiter never acquires the manager lock, so the ordering is safe.
+func (c *concurrencyLimiter) saturated(a *Auth) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit := c.limitLocked(a)
+	return limit > 0 && c.inFlight[a.ID] >= limit
+}
+
+// tryAcquire reserves a slot on the auth. The returned release function is idempotent.
+func (c *concurrencyLimiter) tryAcquire(a *Auth) (func(), bool) {
+	c.mu.Lock()
+	limit := c.limitLocked(a)
+	if limit > 0 && c.inFlight[a.ID] >= limit {
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.inFlight[a.ID]++
+	c.providers[a.ID] = a.Provider
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { c.release(a.ID) })
+	}, true
+}
+
+func (c *concurrencyLimiter) release(authID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[authID] > 0 {
+		c.inFlight[authID]--
+	}
+	if c.inFlight[authID] == 0 {
+		delete(c.inFlight, authID)
+	}
+	if provider := c.providers[authID]; provider != "" {
+		c.signalLocked(provider)
+	}
+}
+
+// queued reports whether requests are already waiting for provider.
+func (c *concurrencyLimiter) queued(provider string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q := c.queues[provider]
+	return q != nil && q.size > 0
+}
+
+// enqueue parks a request for provider. It fails when the queue is full.
+func (c *concurrencyLimiter) enqueue(provider, key string) (*concurrencyWaiter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	maxQueue := c.cfg.MaxQueue
+	if maxQueue <= 0 {
+		maxQueue = defaultConcurrencyQueue
+	}
+	timeout := time.Duration(c.cfg.QueueTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultConcurrencyTimeout
+	}
+	q := c.queues[provider]
+	if q == nil {
+		q = &providerQueue{byKey: make(map[string][]*concurrencyWaiter)}
+		c.queues[provider] = q
+	}
+	if q.size >= maxQueue {
+		q.stat.Rejected++
+		return nil, &Error{Code: "concurrency_queue_full", Message: "too many requests are waiting for " + provider + " credentials", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+	}
+	now := time.Now()
+	w := &concurrencyWaiter{provider: provider, key: key, enqueued: now, deadline: now.Add(timeout), ready: make(chan struct{}, 1)}
+	if _, ok := q.byKey[key]; !ok {
+		q.keys = append(q.keys, key)
+	}
+	q.byKey[key] = append(q.byKey[key], w)
+	q.size++
+	return w, nil
+}
+
+// signalLocked wakes the next waiter, taking client keys in turn so one busy client cannot
+// starve the others.
+func (c *concurrencyLimiter) signalLocked(provider string) {
+	q := c.queues[provider]
+	if q == nil || q.size == 0 {
+		return
+	}
+	for i := 0; i < len(q.keys); i++ {
+		idx := (q.cursor + i) % len(q.keys)
+		for _, w := range q.byKey[q.keys[idx]] {
+			if w.signaled {
+				continue
+			}
+			w.signaled = true
+			w.ready <- struct{}{}
+			q.cursor = idx + 1
+			return
+		}
+	}
+}
+
+// wait blocks until the waiter is signalled, the poll interval elapses, the deadline passes
+// or ctx is cancelled.
+func (c *concurrencyLimiter) wait(ctx context.Context, w *concurrencyWaiter) error {
+	remaining := time.Until(w.deadline)
+	if remaining <= 0 {
+		return c.timeout(w)
+	}
+	timer := time.NewTimer(min(remaining, concurrencyPollInterval))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.ready:
+	case <-timer.C:
+	}
+	c.mu.Lock()
+	// Drain a signal that raced with the timer so the buffered channel never blocks a sender.
+	select {
+	case <-w.ready:
+	default:
+	}
+	w.signaled = false
+	c.mu.Unlock()
+	if time.Now().After(w.deadline) {
+		return c.timeout(w)
+	}
+	return nil
+}
+
+func (c *concurrencyLimiter) timeout(w *concurrencyWaiter) error {
+	c.mu.Lock()
+	if q := c.queues[w.provider]; q != nil {
+		q.stat.TimedOut++
+	}
+	c.mu.Unlock()
+	return &Error{Code: "concurrency_timeout", Message: "timed out waiting for a free " + w.provider + " credential", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+}
+
+// leave removes a waiter from its queue and records how long it waited when served.
+func (c *concurrencyLimiter) leave(w *concurrencyWaiter, served bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q := c.queues[w.provider]
+	if q == nil {
+		return
+	}
+	list := q.byKey[w.key]
+	for i, candidate := range list {
+		if candidate == w {
+			list = append(list[:i], list[i+1:]...)
+			q.size--
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(q.byKey, w.key)
+		for i, key := range q.keys {
+			if key == w.key {
+				q.keys = append(q.keys[:i], q.keys[i+1:]...)
+				if q.cursor > i {
+					q.cursor--
+				}
+				break
+			}
+		}
+		if len(q.keys) > 0 {
+			q.cursor %= len(q.keys)
+		} else {
+			q.cursor = 0
+		}
+	} else {
+		q.byKey[w.key] = list
+	}
+	if served {
+		waited := time.Since(w.enqueued).Milliseconds()
+		q.stat.Waited++
+		q.stat.totalWaitMs += waited
+		if waited > q.stat.MaxWaitMs {
+			q.stat.MaxWaitMs = waited
+		}
+	}
+	// A signal consumed by a waiter that gave up is passed on.
+	if w.signaled || len(w.ready) > 0 {
+		c.signalLocked(w.provider)
+	}
+}
+
+// pickNextSlot selects an auth like pickNext and reserves one of its concurrency slots.
+// When every eligible auth is saturated the request waits in the provider's fair queue.
+// The returned release function must be called once the upstream call has finished.
+func (m *Manager) pickNextSlot(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, func(), error) {
+	c := m.concurrency
+	var w *concurrencyWaiter
+	served := false
+	defer func() {
+		if w != nil {
+			c.leave(w, served)
+		}
+	}()
+	key := clientKeyFromOptions(opts)
+
+	// Requests already waiting go first; newcomers join the back of the queue.
+	if c.queued(provider) {
+		var err error
+		if w, err = c.enqueue(provider, key); err != nil {
+			return nil, nil, nil, err
+		}
+		if err = c.wait(ctx, w); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for {
+		auth, executor, err := m.pickNext(ctx, provider, model, opts, tried)
+		if err == nil {
+			release, ok := c.tryAcquire(auth)
+			if ok {
+				served = true
+				return auth, executor, release, nil
+			}
+			// Lost a race for the last slot; pickNext now skips the saturated auth.
+			continue
+		}
+		if err != errAuthsSaturated {
+			return nil, nil, nil, err
+		}
+		if w == nil {
+			// Enqueue, then retry once so a slot released in the meantime is not missed.
+			if w, err = c.enqueue(provider, key); err != nil {
+				return nil, nil, nil, err
+			}
+			continue
+		}
+		if err = c.wait(ctx, w); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+func clientKeyFromOptions(opts cliproxyexecutor.Options) string {
+	if opts.Metadata != nil {
+		if key, ok := opts.Metadata[ClientKeyMetadataKey].(string); ok {
+			return key
+		}
+	}
+	return ""
+}
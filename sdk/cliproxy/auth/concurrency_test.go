@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestConcurrencyLimiter_AcquireRelease(t *testing.T) {
+	c := newConcurrencyLimiter()
+	c.cfg = internalconfig.ConcurrencyConfig{Providers: map[string]int{"claude": 1}}
+	auth := &Auth{ID: "a1", Provider: "claude"}
+
+	release, ok := c.tryAcquire(auth)
+	if !ok {
+		t.Fatalf("first acquire failed")
+	}
+	if !c.saturated(auth) {
+		t.Fatalf("auth should be saturated at its limit")
+	}
+	if _, ok = c.tryAcquire(auth); ok {
+		t.Fatalf("second acquire must fail while the slot is held")
+	}
+	release()
+	release()
+	if c.saturated(auth) || c.inFlight[auth.ID] != 0 {
+		t.Fatalf("release must be idempotent, in-flight = %d", c.inFlight[auth.ID])
+	}
+
+	own := &Auth{ID: "a2", Provider: "claude", Metadata: map[string]any{"max_concurrency": float64(2)}}
+	if c.limitLocked(own) != 2 {
+		t.Fatalf("per-auth max_concurrency must override the provider limit")
+	}
+	if c.limitLocked(&Auth{ID: "a3", Provider: "gemini"}) != 0 {
+		t.Fatalf("providers without a limit must be unlimited")
+	}
+}
+
+func TestConcurrencyLimiter_FairSignalling(t *testing.T) {
+	c := newConcurrencyLimiter()
+	c.cfg = internalconfig.ConcurrencyConfig{MaxQueue: 3}
+
+	busy1, _ := c.enqueue("claude", "busy")
+	busy2, _ := c.enqueue("claude", "busy")
+	quiet, _ := c.enqueue("claude", "quiet")
+	if _, err := c.enqueue("claude", "other"); err == nil {
+		t.Fatalf("enqueue beyond max-queue must be rejected")
+	}
+
+	c.mu.Lock()
+	c.signalLocked("claude")
+	c.signalLocked("claude")
+	c.mu.Unlock()
+	if len(busy1.ready) != 1 || len(quiet.ready) != 1 || len(busy2.ready) != 0 {
+		t.Fatalf("signals must alternate between client keys: busy1=%d busy2=%d quiet=%d", len(busy1.ready), len(busy2.ready), len(quiet.ready))
+	}
+
+	c.leave(busy1, true)
+	c.leave(quiet, true)
+	stats := c.queues["claude"]
+	if stats.size != 1 || stats.stat.Waited != 2 || stats.stat.Rejected != 1 {
+		t.Fatalf("unexpected queue state: size=%d stat=%+v", stats.size, stats.stat)
+	}
+}
+
+func TestConcurrencyLimiter_WaitTimeout(t *testing.T) {
+	c := newConcurrencyLimiter()
+	w, err := c.enqueue("codex", "")
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	w.deadline = time.Now().Add(20 * time.Millisecond)
+	for err == nil {
+		err = c.wait(context.Background(), w)
+	}
+	authErr, ok := err.(*Error)
+	if !ok || authErr.Code != "concurrency_timeout" || authErr.StatusCode() != 429 {
+		t.Fatalf("expected concurrency_timeout 429, got %v", err)
+	}
+	c.leave(w, false)
+	if q := c.queues["codex"]; q.size != 0 || q.stat.TimedOut != 1 {
+		t.Fatalf("unexpected queue state after timeout: %+v", q)
+	}
+}
+
+type badRequestError struct{ body string }
+
+func (e badRequestError) Error() string   { return e.body }
+func (e badRequestError) StatusCode() int { return http.StatusBadRequest }
+
+// imageRetryExecutor rejects the first request with an undownloadable image URL and records
+// the auth and its in-flight count seen by every call.
+type imageRetryExecutor struct {
+	manager  *Manager
+	authIDs  []string
+	inFlight []int
+}
+
+func (e *imageRetryExecutor) Identifier() string { return "claude" }
+
+func (e *imageRetryExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.manager.concurrency.mu.Lock()
+	e.inFlight = append(e.inFlight, e.manager.concurrency.inFlight[auth.ID])
+	e.manager.concurrency.mu.Unlock()
+	e.authIDs = append(e.authIDs, auth.ID)
+	if len(e.inFlight) == 1 {
+		return cliproxyexecutor.Response{}, badRequestError{body: `{"error":{"message":"Error while downloading https://img.invalid/concurrency-test.png.","param":"url","code":"invalid_value"}}`}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func (e *imageRetryExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *imageRetryExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *imageRetryExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *imageRetryExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestSanitizedRetryHoldsConcurrencySlot(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.SetConcurrency(internalconfig.ConcurrencyConfig{Providers: map[string]int{"claude": 1}})
+	executor := &imageRetryExecutor{manager: manager}
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"a", "b"} {
+		if _, err := manager.Register(ctx, &Auth{ID: id, Provider: "claude"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://img.invalid/concurrency-test.png"}}]}]}`)
+	if _, err := manager.executeWithProvider(ctx, "claude", cliproxyexecutor.Request{Payload: payload}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("executeWithProvider() error = %v", err)
+	}
+	if len(executor.authIDs) != 2 || executor.authIDs[0] != executor.authIDs[1] {
+		t.Fatalf("retry must reuse the held auth, calls went to %v", executor.authIDs)
+	}
+	if executor.inFlight[0] != 1 || executor.inFlight[1] != 1 {
+		t.Fatalf("in-flight per call = %v, want [1 1]", executor.inFlight)
+	}
+	if got := manager.concurrency.inFlight[executor.authIDs[0]]; got != 0 {
+		t.Fatalf("slot not released after the request, in-flight = %d", got)
+	}
+}
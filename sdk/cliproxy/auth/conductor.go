@@ -90,6 +90,41 @@ type Hook interface {
 	OnResult(ctx context.Context, result Result)
 }
 
+// RefreshFailureHook is an optional Hook extension notified when refreshing a credential fails.
+// auth reflects the state after the failure; a failed refresh after a 401 disables it.
+type RefreshFailureHook interface {
+	OnRefreshFailed(ctx context.Context, auth *Auth, err error)
+}
+
+// multiHook fans events out to several hooks.
+type multiHook []Hook
+
+func (h multiHook) OnAuthRegistered(ctx context.Context, auth *Auth) {
+	for _, hook := range h {
+		hook.OnAuthRegistered(ctx, auth)
+	}
+}
+
+func (h multiHook) OnAuthUpdated(ctx context.Context, auth *Auth) {
+	for _, hook := range h {
+		hook.OnAuthUpdated(ctx, auth)
+	}
+}
+
+func (h multiHook) OnResult(ctx context.Context, result Result) {
+	for _, hook := range h {
+		hook.OnResult(ctx, result)
+	}
+}
+
+func (h multiHook) OnRefreshFailed(ctx context.Context, auth *Auth, err error) {
+	for _, hook := range h {
+		if refreshHook, ok := hook.(RefreshFailureHook); ok {
+			refreshHook.OnRefreshFailed(ctx, auth, err)
+		}
+	}
+}
+
 // NoopHook provides optional hook defaults.
 type NoopHook struct{}
 
@@ -123,6 +158,12 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// concurrency caps in-flight requests per auth and queues the overflow.
+	concurrency *concurrencyLimiter
+
+	// quarantineThreshold is the unrecoverable failure streak that quarantines an auth; 0 disables.
+	quarantineThreshold atomic.Int32
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -136,14 +177,17 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	if hook == nil {
 		hook = NoopHook{}
 	}
-	return &Manager{
+	m := &Manager{
 		store:           store,
 		executors:       make(map[string]ProviderExecutor),
 		selector:        selector,
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		concurrency:     newConcurrencyLimiter(),
 	}
+	m.quarantineThreshold.Store(defaultQuarantineThreshold)
+	return m
 }
 
 func (m *Manager) SetSelector(selector Selector) {
@@ -158,6 +202,28 @@ func (m *Manager) SetSelector(selector Selector) {
 	m.mu.Unlock()
 }
 
+// AddHook registers an additional hook alongside the one passed to NewManager.
+// Call it before the manager starts serving requests.
+func (m *Manager) AddHook(hook Hook) {
+	if m == nil || hook == nil {
+		return
+	}
+	switch existing := m.hook.(type) {
+	case nil, NoopHook:
+		m.hook = hook
+	case multiHook:
+		m.hook = append(existing, hook)
+	default:
+		m.hook = multiHook{existing, hook}
+	}
+}
+
+func (m *Manager) notifyRefreshFailed(ctx context.Context, auth *Auth, err error) {
+	if refreshHook, ok := m.hook.(RefreshFailureHook); ok && auth != nil {
+		refreshHook.OnRefreshFailed(ctx, auth, err)
+	}
+}
+
 // SetStore swaps the underlying persistence store.
 func (m *Manager) SetStore(store Store) {
 	m.mu.Lock()
@@ -217,6 +283,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.ID = uuid.NewString()
 	}
 	auth.EnsureIndex()
+	attachSchedule(auth)
 	m.mu.Lock()
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
@@ -236,6 +303,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.indexAssigned = existing.indexAssigned
 	}
 	auth.EnsureIndex()
+	attachSchedule(auth)
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
 	_ = m.persist(ctx, auth)
@@ -264,6 +332,7 @@ func (m *Manager) Load(ctx context.Context) error {
 			continue
 		}
 		auth.EnsureIndex()
+		attachSchedule(auth)
 		m.auths[auth.ID] = auth.Clone()
 	}
 	return nil
@@ -388,7 +457,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
 	routeModel := req.Model
-	tried := make(map[string]struct{})
+	tried := excludedAuths(opts)
 	var lastErr error
 	attemptedSanitized := false
 	// Proactively sanitize known bad image URLs before execution.
@@ -398,9 +467,10 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 	if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
 		opts.OriginalRequest = sanitized
 	}
+	pickCtx, stickyPick := withStickyPick(ctx)
 
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(pickCtx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -412,7 +482,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		execCtx := stickyPick.attach(ctx)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
@@ -421,66 +491,20 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		// Retry once with a sanitized payload while still holding the concurrency slot.
+		if errExec != nil && !attemptedSanitized && sanitizeUndownloadableImages(errExec, &req, &opts) {
+			attemptedSanitized = true
+			execReq.Payload = req.Payload
+			resp, errExec = executor.Execute(execCtx, auth, execReq, opts)
+		}
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
-			// If upstream returned 400 with undownloadable URL, record and retry once with sanitized payload.
 			var se cliproxyexecutor.StatusError
 			var status = 0
 			if errors.As(errExec, &se) && se != nil {
 				status = se.StatusCode()
 			}
-			if status == http.StatusBadRequest {
-				var payload struct {
-					Error struct {
-						Message string `json:"message"`
-						Type    string `json:"type"`
-						Param   string `json:"param"`
-						Code    string `json:"code"`
-					} `json:"error"`
-				}
-				_ = json.Unmarshal([]byte(errExec.Error()), &payload)
-				msg := strings.TrimSpace(payload.Error.Message)
-				if strings.EqualFold(payload.Error.Param, "url") && strings.EqualFold(payload.Error.Code, "invalid_value") && strings.HasPrefix(strings.ToLower(msg), "error while downloading ") {
-					prefix := "Error while downloading "
-					start := strings.Index(msg, prefix)
-					if start >= 0 {
-						rest := msg[start+len(prefix):]
-						bad := strings.TrimSuffix(rest, ".")
-						util.AddBadImageURL(bad)
-					}
-					if !attemptedSanitized {
-						if sanitized, changed := util.SanitizeImageURLsJSON(req.Payload); changed {
-							req.Payload = sanitized
-						}
-						if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
-							opts.OriginalRequest = sanitized
-						}
-						// Allow retry on the same auth by removing it from tried set.
-						delete(tried, auth.ID)
-						attemptedSanitized = true
-						// Retry without marking failure result.
-						continue
-					}
-				}
-				// For other 400 invalid request errors (e.g., wrong params), fail fast without trying other auths.
-				result.Error = &Error{Message: errExec.Error()}
-				if status > 0 {
-					result.Error.HTTPStatus = status
-				}
-				if ra := retryAfterFromError(errExec); ra != nil {
-					result.RetryAfter = ra
-				}
-				m.MarkResult(execCtx, result)
-				lastErr = errExec
-				if status == http.StatusUnauthorized {
-					// Try to refresh credentials once; disable on failure, then switch auth.
-					m.refreshAfterUnauthorized(execCtx, auth.ID)
-					continue
-				}
-				return cliproxyexecutor.Response{}, errExec
-			}
-
-			// Normal failure path (or already retried once)
 			result.Error = &Error{Message: errExec.Error()}
 			if status > 0 {
 				result.Error.HTTPStatus = status
@@ -492,13 +516,16 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			lastErr = errExec
 			if status == http.StatusUnauthorized {
 				// Try to refresh credentials once; disable on failure, then switch auth.
-				m.refreshAfterUnauthorized(execCtx, auth.ID)
+				m.refreshAfterUnauthorized(execCtx, auth)
 				continue
 			}
+			if status == http.StatusForbidden {
+				m.forbiddenAfterRefresh(execCtx, auth)
+			}
 			return cliproxyexecutor.Response{}, errExec
 		}
 		m.MarkResult(execCtx, result)
-		m.recordStickyOnSuccess(provider, req.Model, opts, auth.ID)
+		m.recordStickyOnSuccess(provider, opts, auth)
 		return resp, nil
 	}
 }
@@ -508,7 +535,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
 	routeModel := req.Model
-	tried := make(map[string]struct{})
+	tried := excludedAuths(opts)
 	var lastErr error
 	attemptedSanitized := false
 
@@ -521,7 +548,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 	}
 
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -542,63 +569,20 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		// Retry once with a sanitized payload while still holding the concurrency slot.
+		if errExec != nil && !attemptedSanitized && sanitizeUndownloadableImages(errExec, &req, &opts) {
+			attemptedSanitized = true
+			execReq.Payload = req.Payload
+			resp, errExec = executor.CountTokens(execCtx, auth, execReq, opts)
+		}
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
-			// Handle 400 undownloadable URL similarly to executeWithProvider
 			var se cliproxyexecutor.StatusError
 			var status = 0
 			if errors.As(errExec, &se) && se != nil {
 				status = se.StatusCode()
 			}
-			if status == http.StatusBadRequest {
-				var payload struct {
-					Error struct {
-						Message string `json:"message"`
-						Type    string `json:"type"`
-						Param   string `json:"param"`
-						Code    string `json:"code"`
-					} `json:"error"`
-				}
-				_ = json.Unmarshal([]byte(errExec.Error()), &payload)
-				msg := strings.TrimSpace(payload.Error.Message)
-				if strings.EqualFold(payload.Error.Param, "url") && strings.EqualFold(payload.Error.Code, "invalid_value") && strings.HasPrefix(strings.ToLower(msg), "error while downloading ") {
-					prefix := "Error while downloading "
-					start := strings.Index(msg, prefix)
-					if start >= 0 {
-						rest := msg[start+len(prefix):]
-						bad := strings.TrimSuffix(rest, ".")
-						util.AddBadImageURL(bad)
-					}
-					if !attemptedSanitized {
-						if sanitized, changed := util.SanitizeImageURLsJSON(req.Payload); changed {
-							req.Payload = sanitized
-						}
-						if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
-							opts.OriginalRequest = sanitized
-						}
-						delete(tried, auth.ID)
-						attemptedSanitized = true
-						continue
-					}
-				}
-				// For other 400 invalid request errors, fail fast for CountTokens too.
-				result.Error = &Error{Message: errExec.Error()}
-				if status > 0 {
-					result.Error.HTTPStatus = status
-				}
-				if ra := retryAfterFromError(errExec); ra != nil {
-					result.RetryAfter = ra
-				}
-				m.MarkResult(execCtx, result)
-				lastErr = errExec
-				if status == http.StatusUnauthorized {
-					// Try to refresh credentials once; disable on failure, then switch auth.
-					m.refreshAfterUnauthorized(execCtx, auth.ID)
-					continue
-				}
-				return cliproxyexecutor.Response{}, errExec
-			}
-
 			result.Error = &Error{Message: errExec.Error()}
 			if status > 0 {
 				result.Error.HTTPStatus = status
@@ -610,13 +594,16 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			lastErr = errExec
 			if status == http.StatusUnauthorized {
 				// Try to refresh credentials once; disable on failure, then switch auth.
-				m.refreshAfterUnauthorized(execCtx, auth.ID)
+				m.refreshAfterUnauthorized(execCtx, auth)
 				continue
 			}
+			if status == http.StatusForbidden {
+				m.forbiddenAfterRefresh(execCtx, auth)
+			}
 			return cliproxyexecutor.Response{}, errExec
 		}
 		m.MarkResult(execCtx, result)
-		m.recordStickyOnSuccess(provider, req.Model, opts, auth.ID)
+		m.recordStickyOnSuccess(provider, opts, auth)
 		return resp, nil
 	}
 }
@@ -626,7 +613,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		return nil, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
 	}
 	routeModel := req.Model
-	tried := make(map[string]struct{})
+	tried := excludedAuths(opts)
 	var lastErr error
 	attemptedSanitized := false
 
@@ -637,9 +624,10 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
 		opts.OriginalRequest = sanitized
 	}
+	pickCtx, stickyPick := withStickyPick(ctx)
 
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(pickCtx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -651,7 +639,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		execCtx := stickyPick.attach(ctx)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
@@ -660,62 +648,19 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		// Retry once with a sanitized payload while still holding the concurrency slot.
+		if errStream != nil && !attemptedSanitized && sanitizeUndownloadableImages(errStream, &req, &opts) {
+			attemptedSanitized = true
+			execReq.Payload = req.Payload
+			chunks, errStream = executor.ExecuteStream(execCtx, auth, execReq, opts)
+		}
 		if errStream != nil {
-			// If the initial streaming request fails with 400 undownloadable URL, record and retry once sanitized.
+			release()
 			var se cliproxyexecutor.StatusError
 			var status = 0
 			if errors.As(errStream, &se) && se != nil {
 				status = se.StatusCode()
 			}
-			if status == http.StatusBadRequest {
-				var payload struct {
-					Error struct {
-						Message string `json:"message"`
-						Type    string `json:"type"`
-						Param   string `json:"param"`
-						Code    string `json:"code"`
-					} `json:"error"`
-				}
-				_ = json.Unmarshal([]byte(errStream.Error()), &payload)
-				msg := strings.TrimSpace(payload.Error.Message)
-				if strings.EqualFold(payload.Error.Param, "url") && strings.EqualFold(payload.Error.Code, "invalid_value") && strings.HasPrefix(strings.ToLower(msg), "error while downloading ") {
-					prefix := "Error while downloading "
-					start := strings.Index(msg, prefix)
-					if start >= 0 {
-						rest := msg[start+len(prefix):]
-						bad := strings.TrimSuffix(rest, ".")
-						util.AddBadImageURL(bad)
-					}
-					if !attemptedSanitized {
-						if sanitized, changed := util.SanitizeImageURLsJSON(req.Payload); changed {
-							req.Payload = sanitized
-						}
-						if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
-							opts.OriginalRequest = sanitized
-						}
-						delete(tried, auth.ID)
-						attemptedSanitized = true
-						continue
-					}
-
-				}
-				// For other 400 invalid request errors in streaming, fail fast.
-				rerr := &Error{Message: errStream.Error()}
-				if status > 0 {
-					rerr.HTTPStatus = status
-				}
-				result := Result{AuthID: auth.ID, Provider: provider, Model: req.Model, Success: false, Error: rerr}
-				result.RetryAfter = retryAfterFromError(errStream)
-				m.MarkResult(execCtx, result)
-				lastErr = errStream
-				if status == http.StatusUnauthorized {
-					// Try to refresh credentials once; disable on failure, then switch auth.
-					m.refreshAfterUnauthorized(execCtx, auth.ID)
-					continue
-				}
-				return nil, errStream
-			}
-
 			rerr := &Error{Message: errStream.Error()}
 			if status > 0 {
 				rerr.HTTPStatus = status
@@ -726,14 +671,19 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			lastErr = errStream
 			if status == http.StatusUnauthorized {
 				// Try to refresh credentials once; disable on failure, then switch auth.
-				m.refreshAfterUnauthorized(execCtx, auth.ID)
+				m.refreshAfterUnauthorized(execCtx, auth)
 				continue
 			}
+			if status == http.StatusForbidden {
+				m.forbiddenAfterRefresh(execCtx, auth)
+			}
 			return nil, lastErr
 		}
+		noteStreamAuth(ctx, auth.ID)
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer release()
 			var failed bool
 			for chunk := range streamChunks {
 				if chunk.Err != nil && !failed {
@@ -748,7 +698,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 				out <- chunk
 			}
 			if !failed {
-				m.recordStickyOnSuccess(streamProvider, routeModel, opts, streamAuth.ID)
+				m.recordStickyOnSuccess(streamProvider, opts, streamAuth)
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
@@ -756,6 +706,36 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	}
 }
 
+// sanitizeUndownloadableImages handles a 400 naming an image URL the upstream could not
+// download: it remembers the URL as bad and strips known bad URLs from req and opts. It
+// reports whether the caller should retry with the sanitized payload.
+func sanitizeUndownloadableImages(err error, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) bool {
+	var se cliproxyexecutor.StatusError
+	if !errors.As(err, &se) || se == nil || se.StatusCode() != http.StatusBadRequest {
+		return false
+	}
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+			Param   string `json:"param"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal([]byte(err.Error()), &payload)
+	msg := strings.TrimSpace(payload.Error.Message)
+	if !strings.EqualFold(payload.Error.Param, "url") || !strings.EqualFold(payload.Error.Code, "invalid_value") || !strings.HasPrefix(strings.ToLower(msg), "error while downloading ") {
+		return false
+	}
+	util.AddBadImageURL(strings.TrimSuffix(msg[len("Error while downloading "):], "."))
+	if sanitized, changed := util.SanitizeImageURLsJSON(req.Payload); changed {
+		req.Payload = sanitized
+	}
+	if sanitized, changed := util.SanitizeImageURLsJSON(opts.OriginalRequest); changed {
+		opts.OriginalRequest = sanitized
+	}
+	return true
+}
+
 func rewriteModelForAuth(model string, metadata map[string]any, auth *Auth) (string, map[string]any) {
 	if auth == nil || model == "" {
 		return model, metadata
@@ -889,7 +869,7 @@ func (m *Manager) closestCooldownWait(providers []string, model string) (time.Du
 			continue
 		}
 		blocked, reason, next := isAuthBlockedForModel(auth, model, now)
-		if !blocked || next.IsZero() || reason == blockReasonDisabled {
+		if !blocked || next.IsZero() || reason == blockReasonDisabled || reason == blockReasonSchedule {
 			continue
 		}
 		wait := next.Sub(now)
@@ -904,6 +884,46 @@ func (m *Manager) closestCooldownWait(providers []string, model string) (time.Du
 	return minWait, found
 }
 
+// ModelAvailability reports, across the credentials of provider that serve model, how many
+// exist, how many can be selected now and, when none can, the earliest cooldown expiry.
+// Disabled credentials are not counted.
+func (m *Manager) ModelAvailability(provider, model string) (total, available int, nextRecover time.Time) {
+	if m == nil || model == "" {
+		return 0, 0, time.Time{}
+	}
+	provider = strings.TrimSpace(strings.ToLower(provider))
+	now := time.Now()
+	reg := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, auth := range m.auths {
+		if auth == nil || auth.Disabled || !strings.EqualFold(strings.TrimSpace(auth.Provider), provider) {
+			continue
+		}
+		if !reg.ClientSupportsModel(auth.ID, model) {
+			if _, seen := auth.ModelStates[model]; !seen {
+				continue
+			}
+		}
+		blocked, reason, next := isAuthBlockedForModel(auth, model, now)
+		if reason == blockReasonDisabled {
+			continue
+		}
+		total++
+		if !blocked {
+			available++
+			continue
+		}
+		if !next.IsZero() && (nextRecover.IsZero() || next.Before(nextRecover)) {
+			nextRecover = next
+		}
+	}
+	if available > 0 {
+		nextRecover = time.Time{}
+	}
+	return total, available, nextRecover
+}
+
 func (m *Manager) shouldRetryAfterError(err error, attempt, maxAttempts int, providers []string, model string, maxWait time.Duration) (time.Duration, bool) {
 	if err == nil || attempt >= maxAttempts-1 {
 		return 0, false
@@ -990,6 +1010,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		now := time.Now()
 
 		if result.Success {
+			resetFailureStreak(auth)
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
 				resetModelState(state, now)
@@ -1475,6 +1496,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		return nil, nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	candidates := make([]*Auth, 0, len(m.auths))
+	saturated := 0
 	modelKey := strings.TrimSpace(model)
 	registryRef := registry.GetGlobalRegistry()
 	for _, candidate := range m.auths {
@@ -1487,10 +1509,17 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if m.concurrency != nil && m.concurrency.saturated(candidate) {
+			saturated++
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if saturated > 0 {
+			return nil, nil, errAuthsSaturated
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
@@ -1512,6 +1541,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		reasonStr = "cooldown"
 	case blockReasonDisabled:
 		reasonStr = "disabled"
+	case blockReasonSchedule:
+		reasonStr = "off_schedule"
 	case blockReasonOther:
 		reasonStr = "other"
 	default:
@@ -1870,13 +1901,24 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
 	if err != nil {
+		var failed *Auth
+		quarantined := false
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
 			current.NextRefreshAfter = now.Add(refreshFailureBackoff)
 			current.LastError = &Error{Message: err.Error()}
+			if isUnrecoverableError(err) && m.recordUnrecoverableFailure(current, "refresh rejected", now) {
+				quarantined = true
+			}
 			m.auths[id] = current
+			_ = m.persist(ctx, current)
+			failed = current.Clone()
 		}
 		m.mu.Unlock()
+		if quarantined {
+			registry.GetGlobalRegistry().UnregisterClient(id)
+		}
+		m.notifyRefreshFailed(ctx, failed, err)
 		return
 	}
 	if updated == nil {
@@ -1891,22 +1933,74 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated.NextRefreshAfter = time.Time{}
 	updated.LastError = nil
 	updated.UpdatedAt = now
+	updated.refreshedAfterUnauthorized = false
 	_, _ = m.Update(ctx, updated)
 }
 
-// refreshAfterUnauthorized attempts a one-off refresh after a 401 response.
-// If refresh fails, the auth is permanently disabled and unregistered.
-func (m *Manager) refreshAfterUnauthorized(ctx context.Context, id string) {
-	m.mu.RLock()
-	auth := m.auths[id]
+// rejectedAfterRefresh extends the failure streak of current when used, the auth a request was
+// made with, carried a token issued by a refresh forced by a 401 and that token is still the
+// current one. Rejections of older tokens, such as requests racing the refresh, are not
+// counted, and each forced refresh is counted at most once. The caller holds m.mu.
+func (m *Manager) rejectedAfterRefresh(current, used *Auth, reason string, now time.Time) bool {
+	if current == nil || used == nil || !used.refreshedAfterUnauthorized || !current.refreshedAfterUnauthorized {
+		return false
+	}
+	if !current.LastRefreshedAt.Equal(used.LastRefreshedAt) {
+		return false
+	}
+	current.refreshedAfterUnauthorized = false
+	return m.recordUnrecoverableFailure(current, reason, now)
+}
+
+// forbiddenAfterRefresh continues the failure streak when a token issued by a refresh forced
+// by a 401 is answered with a 403.
+func (m *Manager) forbiddenAfterRefresh(ctx context.Context, used *Auth) {
+	if used == nil || !used.refreshedAfterUnauthorized {
+		return
+	}
+	m.mu.Lock()
+	current := m.auths[used.ID]
+	quarantined := m.rejectedAfterRefresh(current, used, "forbidden after refresh", time.Now())
+	if quarantined {
+		_ = m.persist(ctx, current)
+	}
+	m.mu.Unlock()
+	if quarantined {
+		registry.GetGlobalRegistry().UnregisterClient(used.ID)
+	}
+}
+
+// refreshAfterUnauthorized attempts a one-off refresh after a 401 response to a request made
+// with used. If refresh fails, the auth is permanently disabled and unregistered.
+func (m *Manager) refreshAfterUnauthorized(ctx context.Context, used *Auth) {
+	if used == nil {
+		return
+	}
+	id := used.ID
+	now := time.Now()
+	m.mu.Lock()
+	current := m.auths[id]
 	var exec ProviderExecutor
-	if auth != nil {
-		exec = m.executors[auth.Provider]
+	if current != nil {
+		exec = m.executors[current.Provider]
 	}
-	m.mu.RUnlock()
-	if auth == nil || exec == nil {
+	if current == nil || exec == nil {
+		m.mu.Unlock()
 		return
 	}
+	if !current.LastRefreshedAt.Equal(used.LastRefreshedAt) {
+		// Another refresh already replaced the rejected token; retry with the new one.
+		m.mu.Unlock()
+		return
+	}
+	if m.rejectedAfterRefresh(current, used, "unauthorized after refresh", now) {
+		_ = m.persist(ctx, current)
+		m.mu.Unlock()
+		registry.GetGlobalRegistry().UnregisterClient(id)
+		return
+	}
+	auth := current.Clone()
+	m.mu.Unlock()
 	cloned := auth.Clone()
 	// For Codex, force a true refresh by removing any cached access_token on the cloned auth.
 	// This prevents refresh logic from short-circuiting based on a still-valid JWT and
@@ -1919,21 +2013,26 @@ func (m *Manager) refreshAfterUnauthorized(ctx context.Context, id string) {
 	}
 	updated, err := exec.Refresh(ctx, cloned)
 	log.Debugf("refresh after unauthorized %s, %s, %v", auth.Provider, auth.ID, err)
-	now := time.Now()
+	now = time.Now()
 	if err != nil {
+		var failed *Auth
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
-			current.Disabled = true
-			current.Status = StatusDisabled
-			if strings.TrimSpace(current.StatusMessage) == "" {
-				current.StatusMessage = "unauthorized_refresh_failed"
+			if !isUnrecoverableError(err) || !m.recordUnrecoverableFailure(current, "unauthorized and refresh rejected", now) {
+				current.Disabled = true
+				current.Status = StatusDisabled
+				if strings.TrimSpace(current.StatusMessage) == "" {
+					current.StatusMessage = "unauthorized_refresh_failed"
+				}
 			}
 			current.UpdatedAt = now
 			m.auths[id] = current
 			_ = m.persist(ctx, current)
+			failed = current.Clone()
 		}
 		m.mu.Unlock()
 		registry.GetGlobalRegistry().UnregisterClient(id)
+		m.notifyRefreshFailed(ctx, failed, err)
 		return
 	}
 	if updated == nil {
@@ -1948,6 +2047,8 @@ func (m *Manager) refreshAfterUnauthorized(ctx context.Context, id string) {
 	updated.NextRefreshAfter = time.Time{}
 	updated.LastError = nil
 	updated.UpdatedAt = now
+	// A rejection of the new token continues the streak; a success ends it.
+	updated.refreshedAfterUnauthorized = true
 	_, _ = m.Update(ctx, updated)
 }
 
@@ -1971,26 +2072,38 @@ func (m *Manager) roundTripperFor(auth *Auth) http.RoundTripper {
 	return p.RoundTripperFor(auth)
 }
 
-// recordStickyOnSuccess records message-hash -> auth binding only after a successful request.
-// It is a no-op for non-Codex providers or when no message hashes are present.
-func (m *Manager) recordStickyOnSuccess(provider, model string, opts cliproxyexecutor.Options, authID string) {
-	if m == nil || strings.TrimSpace(provider) == "" || strings.TrimSpace(authID) == "" {
-		return
-	}
-	// Only sticky-route Codex; others remain round-robin.
-	if !strings.EqualFold(strings.TrimSpace(provider), "codex") {
+// recordStickyOnSuccess records conversation fingerprint -> auth bindings only after a
+// successful request. It is a no-op for providers without sticky routing or when the
+// request carries no usable fingerprint.
+func (m *Manager) recordStickyOnSuccess(provider string, opts cliproxyexecutor.Options, auth *Auth) {
+	if m == nil || auth == nil || strings.TrimSpace(provider) == "" || strings.TrimSpace(auth.ID) == "" {
 		return
 	}
+	m.mu.RLock()
 	sel, ok := m.selector.(*SmartStickySelector)
+	m.mu.RUnlock()
 	if !ok || sel == nil || sel.idx == nil {
 		return
 	}
-	hashes := extractMessageHashes(opts.OriginalRequest)
+	scope := strings.ToLower(strings.TrimSpace(provider))
+	var hashes []uint64
+	switch sel.strategyFor(scope, auth) {
+	case StickyStrategyMessageHash:
+		hashes = extractMessageHashes(opts.OriginalRequest)
+	case StickyStrategyPrefix:
+		hashes = extractPrefixHashes(opts.OriginalRequest, sel.prefixMessages())
+	}
 	if len(hashes) == 0 {
 		return
 	}
-	scope := strings.ToLower(strings.TrimSpace(provider))
-	sel.idx.Record(scope, hashes, strings.TrimSpace(authID))
+	sel.idx.Record(scope, hashes, strings.TrimSpace(auth.ID))
+}
+
+// Selector returns the credential selector currently in use.
+func (m *Manager) Selector() Selector {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.selector
 }
 
 // RoundTripperProvider defines a minimal provider of per-auth HTTP transports.
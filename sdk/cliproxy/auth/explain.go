@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// RouteCandidate describes how a single auth would be treated when routing a model
+// through a provider. It is produced by ExplainRoute for dry-run diagnostics and
+// never mutates manager state.
+type RouteCandidate struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Label    string `json:"label,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Status   Status `json:"status"`
+	// Eligible reports whether the selector could pick this auth right now.
+	Eligible bool `json:"eligible"`
+	// Reason explains why the auth is not eligible ("disabled", "model_not_supported",
+	// "cooldown", "off_schedule", "unavailable"). Empty when eligible.
+	Reason string `json:"reason,omitempty"`
+	// StatusMessage carries the last recorded status message for the auth or model.
+	StatusMessage string `json:"status_message,omitempty"`
+	// NextRetryAfter is set when the auth is blocked until a known time.
+	NextRetryAfter *time.Time `json:"next_retry_after,omitempty"`
+	// UpstreamModel is the model name sent upstream after prefix stripping and OAuth model mappings.
+	UpstreamModel string `json:"upstream_model"`
+	// PrefixStripped is true when the auth prefix was removed from the requested model.
+	PrefixStripped bool `json:"prefix_stripped,omitempty"`
+	// OAuthMapping names the oauth-model-mappings channel that rewrote the model, if any.
+	OAuthMapping string `json:"oauth_mapping,omitempty"`
+}
+
+// RouteExplanation summarises how the manager would route a model for one provider.
+type RouteExplanation struct {
+	Provider           string           `json:"provider"`
+	ExecutorRegistered bool             `json:"executor_registered"`
+	Candidates         []RouteCandidate `json:"candidates"`
+	EligibleCount      int              `json:"eligible_count"`
+}
+
+// ExplainRoute reports, without executing anything, which auths the manager would
+// consider for the provider/model pair and why each one is eligible or blocked.
+// The evaluation mirrors pickNext and the built-in selectors' availability checks.
+func (m *Manager) ExplainRoute(provider, model string) RouteExplanation {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	out := RouteExplanation{Provider: provider}
+	if m == nil || provider == "" {
+		return out
+	}
+	modelKey := strings.TrimSpace(model)
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+
+	m.mu.RLock()
+	_, out.ExecutorRegistered = m.executors[provider]
+	auths := make([]*Auth, 0, len(m.auths))
+	for _, candidate := range m.auths {
+		if candidate == nil || candidate.Provider != provider {
+			continue
+		}
+		auths = append(auths, candidate.Clone())
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	for _, auth := range auths {
+		entry := RouteCandidate{
+			ID:            auth.ID,
+			Provider:      auth.Provider,
+			Label:         auth.Label,
+			Prefix:        auth.Prefix,
+			Status:        auth.Status,
+			StatusMessage: auth.StatusMessage,
+		}
+		rewritten, _ := rewriteModelForAuth(modelKey, nil, auth)
+		entry.PrefixStripped = rewritten != modelKey
+		entry.UpstreamModel = rewritten
+		if upstream := m.resolveOAuthUpstreamModel(auth, rewritten); upstream != "" {
+			entry.UpstreamModel = upstream
+			entry.OAuthMapping = modelMappingChannel(auth)
+		}
+
+		switch {
+		case auth.Disabled:
+			entry.Reason = "disabled"
+		case modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, modelKey):
+			entry.Reason = "model_not_supported"
+		default:
+			blocked, reason, next := isAuthBlockedForModel(auth, modelKey, now)
+			if blocked {
+				switch reason {
+				case blockReasonCooldown:
+					entry.Reason = "cooldown"
+				case blockReasonDisabled:
+					entry.Reason = "disabled"
+				case blockReasonSchedule:
+					entry.Reason = "off_schedule"
+				default:
+					entry.Reason = "unavailable"
+				}
+				if !next.IsZero() {
+					nextCopy := next
+					entry.NextRetryAfter = &nextCopy
+				}
+				if state, ok := auth.ModelStates[modelKey]; ok && state != nil && state.StatusMessage != "" {
+					entry.StatusMessage = state.StatusMessage
+				}
+			} else {
+				entry.Eligible = true
+				out.EligibleCount++
+			}
+		}
+		out.Candidates = append(out.Candidates, entry)
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestManagerExplainRoute_ReportsEligibilityAndMapping(t *testing.T) {
+	m := NewManager(nil, &RoundRobinSelector{}, nil)
+	m.SetOAuthModelMappings(map[string][]internalconfig.ModelNameMapping{
+		"claude": {{Name: "claude-sonnet-4-5-20250929", Alias: "sonnet"}},
+	})
+
+	ready := &Auth{ID: "explain-ready", Provider: "claude", Status: StatusActive}
+	cooling := &Auth{ID: "explain-cooling", Provider: "claude", Status: StatusError, ModelStates: map[string]*ModelState{
+		"sonnet": {
+			Unavailable:    true,
+			Status:         StatusError,
+			NextRetryAfter: time.Now().Add(time.Minute),
+			Quota:          QuotaState{Exceeded: true},
+		},
+	}}
+	disabled := &Auth{ID: "explain-disabled", Provider: "claude", Disabled: true, Status: StatusDisabled}
+	for _, a := range []*Auth{ready, cooling, disabled} {
+		if _, err := m.Register(context.Background(), a); err != nil {
+			t.Fatalf("Register(%s) error = %v", a.ID, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(a.ID, "claude", []*registry.ModelInfo{{ID: "sonnet"}})
+		t.Cleanup(func(id string) func() {
+			return func() { registry.GetGlobalRegistry().UnregisterClient(id) }
+		}(a.ID))
+	}
+
+	got := m.ExplainRoute("claude", "sonnet")
+	if got.EligibleCount != 1 {
+		t.Fatalf("EligibleCount = %d, want 1", got.EligibleCount)
+	}
+	reasons := make(map[string]RouteCandidate, len(got.Candidates))
+	for _, c := range got.Candidates {
+		reasons[c.ID] = c
+	}
+	if c := reasons["explain-ready"]; !c.Eligible || c.UpstreamModel != "claude-sonnet-4-5-20250929" || c.OAuthMapping != "claude" {
+		t.Fatalf("ready candidate = %+v", c)
+	}
+	if c := reasons["explain-cooling"]; c.Eligible || c.Reason != "cooldown" || c.NextRetryAfter == nil {
+		t.Fatalf("cooling candidate = %+v", c)
+	}
+	if c := reasons["explain-disabled"]; c.Eligible || c.Reason != "disabled" {
+		t.Fatalf("disabled candidate = %+v", c)
+	}
+}
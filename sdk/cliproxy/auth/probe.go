@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+// ProbeResult describes the outcome of a single ProbeAuth call.
+type ProbeResult struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ProbeAuth sends a minimal chat request through one specific auth, bypassing the selector,
+// and leaves the auth state untouched. When model is empty the first model
+// registered for the auth is used.
+func (m *Manager) ProbeAuth(ctx context.Context, authID, model string) (ProbeResult, error) {
+	auth, ok := m.GetByID(authID)
+	if !ok {
+		return ProbeResult{}, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	out := ProbeResult{AuthID: auth.ID, Provider: auth.Provider}
+	m.mu.RLock()
+	executor, okExecutor := m.executors[auth.Provider]
+	m.mu.RUnlock()
+	if !okExecutor {
+		return out, &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		models := registry.GetGlobalRegistry().GetModelsForClient(auth.ID)
+		ids := make([]string, 0, len(models))
+		for _, info := range models {
+			if info != nil && info.ID != "" {
+				ids = append(ids, info.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return out, &Error{Code: "model_not_found", Message: "no models registered for auth"}
+		}
+		sort.Strings(ids)
+		model = ids[0]
+	}
+	out.Model = model
+
+	payload := []byte(`{"messages":[{"role":"user","content":"ping"}],"max_tokens":1,"stream":false}`)
+	payload, _ = sjson.SetBytes(payload, "model", model)
+	req := cliproxyexecutor.Request{Model: model, Payload: payload, Format: sdktranslator.FormatOpenAI}
+	opts := cliproxyexecutor.Options{OriginalRequest: payload, SourceFormat: sdktranslator.FormatOpenAI}
+
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(model, req.Metadata, auth)
+	execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
+	// Probes are diagnostics: the outcome is reported to the caller but never fed back into
+	// credential state, so a failed test does not put a live credential into cooldown.
+	if _, errExec := executor.Execute(execCtx, auth, execReq, opts); errExec != nil {
+		out.Error = errExec.Error()
+		return out, nil
+	}
+	out.Success = true
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type probeFailingExecutor struct{}
+
+func (probeFailingExecutor) Identifier() string { return "claude" }
+
+func (probeFailingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, errors.New("upstream rejected the request")
+}
+
+func (probeFailingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (probeFailingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (probeFailingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (probeFailingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestProbeAuthFailureLeavesAuthState(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(probeFailingExecutor{})
+	if _, err := manager.Register(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	result, err := manager.ProbeAuth(ctx, "a", "claude-test")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if result.Success || result.Error == "" {
+		t.Fatalf("expected a failed probe, got %+v", result)
+	}
+	auth, _ := manager.GetByID("a")
+	if auth.Unavailable || auth.Status != StatusActive || len(auth.ModelStates) != 0 || auth.LastError != nil {
+		t.Fatalf("probe changed auth state: unavailable=%t status=%s states=%d", auth.Unavailable, auth.Status, len(auth.ModelStates))
+	}
+}
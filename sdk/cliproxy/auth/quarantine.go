@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// defaultQuarantineThreshold is the failure streak that quarantines an auth when the config
+// leaves it unset.
+const defaultQuarantineThreshold = 3
+
+// Auth-file metadata keys holding the quarantine state, so it survives reloads and restarts.
+const (
+	QuarantinedKey      = "quarantined"
+	QuarantineReasonKey = "quarantine_reason"
+	QuarantinedAtKey    = "quarantined_at"
+	FailureStreakKey    = "failure_streak"
+)
+
+// SetQuarantine updates the failure-streak policy.
+func (m *Manager) SetQuarantine(cfg internalconfig.QuarantineConfig) {
+	if m == nil {
+		return
+	}
+	threshold := cfg.FailureThreshold
+	switch {
+	case threshold == 0:
+		threshold = defaultQuarantineThreshold
+	case threshold < 0:
+		threshold = 0
+	}
+	m.quarantineThreshold.Store(int32(threshold))
+}
+
+// isUnrecoverableError reports whether a refresh error means the credential cannot recover
+// without a new login: a revoked or expired grant, or a 401/403 from the token endpoint.
+func isUnrecoverableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var withStatus interface{ StatusCode() int }
+	if errors.As(err, &withStatus) {
+		if code := withStatus.StatusCode(); code == 401 || code == 403 {
+			return true
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"invalid_grant", "status 401", "status 403"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// FailureStreak returns the number of consecutive unrecoverable failures recorded for auth.
+func FailureStreak(auth *Auth) int {
+	if auth == nil {
+		return 0
+	}
+	switch v := auth.Metadata[FailureStreakKey].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// recordUnrecoverableFailure extends the failure streak of auth and quarantines it once the
+// streak reaches the threshold, reporting whether it did. API keys have nothing to refresh and
+// are never quarantined.
+func (m *Manager) recordUnrecoverableFailure(auth *Auth, reason string, now time.Time) bool {
+	if auth == nil || auth.Metadata == nil || auth.Disabled {
+		return false
+	}
+	if typ, _ := auth.AccountInfo(); typ == "api_key" {
+		return false
+	}
+	threshold := int(m.quarantineThreshold.Load())
+	if threshold <= 0 {
+		return false
+	}
+	streak := FailureStreak(auth) + 1
+	auth.Metadata[FailureStreakKey] = streak
+	if streak < threshold {
+		return false
+	}
+	reason = fmt.Sprintf("%s (%d consecutive failures)", reason, streak)
+	auth.Disabled = true
+	auth.Status = StatusQuarantined
+	auth.StatusMessage = "quarantined: " + reason
+	auth.Unavailable = false
+	auth.NextRetryAfter = time.Time{}
+	auth.NextRefreshAfter = time.Time{}
+	auth.UpdatedAt = now
+	auth.Metadata[QuarantinedKey] = true
+	auth.Metadata[QuarantineReasonKey] = reason
+	auth.Metadata[QuarantinedAtKey] = now.UTC().Format(time.RFC3339)
+	return true
+}
+
+// resetFailureStreak ends the failure streak of auth after a success.
+func resetFailureStreak(auth *Auth) {
+	if auth == nil {
+		return
+	}
+	auth.refreshedAfterUnauthorized = false
+	if auth.Metadata != nil {
+		delete(auth.Metadata, FailureStreakKey)
+	}
+}
+
+// QuarantineInfo reports whether auth is quarantined, why and since when.
+func QuarantineInfo(auth *Auth) (quarantined bool, reason string, since time.Time) {
+	if auth == nil || auth.Metadata == nil {
+		return false, "", time.Time{}
+	}
+	if v, ok := auth.Metadata[QuarantinedKey].(bool); !ok || !v {
+		return false, "", time.Time{}
+	}
+	reason, _ = auth.Metadata[QuarantineReasonKey].(string)
+	if raw, ok := auth.Metadata[QuarantinedAtKey].(string); ok {
+		since, _ = time.Parse(time.RFC3339, raw)
+	}
+	return true, reason, since
+}
+
+// RestoreQuarantine disables auth when its metadata records a quarantine. Stores and
+// synthesizers call it when loading auth files.
+func RestoreQuarantine(auth *Auth) bool {
+	quarantined, reason, _ := QuarantineInfo(auth)
+	if !quarantined {
+		return false
+	}
+	auth.Disabled = true
+	auth.Status = StatusQuarantined
+	auth.StatusMessage = "quarantined: " + reason
+	return true
+}
+
+// ClearQuarantine removes the quarantine state and failure streak from auth. It leaves
+// Disabled and Status to the caller re-enabling the credential.
+func ClearQuarantine(auth *Auth) {
+	if auth == nil || auth.Metadata == nil {
+		return
+	}
+	delete(auth.Metadata, QuarantinedKey)
+	delete(auth.Metadata, QuarantineReasonKey)
+	delete(auth.Metadata, QuarantinedAtKey)
+	delete(auth.Metadata, FailureStreakKey)
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type refreshFailingExecutor struct {
+	err error
+}
+
+func (e refreshFailingExecutor) Identifier() string { return "claude" }
+
+func (e refreshFailingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e refreshFailingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e refreshFailingExecutor) Refresh(context.Context, *Auth) (*Auth, error) { return nil, e.err }
+
+func (e refreshFailingExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e refreshFailingExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestRefreshFailureStreakQuarantinesAuth(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.SetQuarantine(internalconfig.QuarantineConfig{FailureThreshold: 2})
+	manager.RegisterExecutor(refreshFailingExecutor{err: errors.New(`token refresh failed with status 400: {"error":"invalid_grant"}`)})
+	if _, err := manager.Register(ctx, &Auth{ID: "a", Provider: "claude", Metadata: map[string]any{"email": "a@example.com"}}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	manager.refreshAuth(ctx, "a")
+	auth, _ := manager.GetByID("a")
+	if auth.Disabled || FailureStreak(auth) != 1 {
+		t.Fatalf("after one failure: disabled=%t streak=%d", auth.Disabled, FailureStreak(auth))
+	}
+
+	// A successful request ends the streak.
+	manager.MarkResult(ctx, Result{AuthID: "a", Provider: "claude", Success: true})
+	if auth, _ = manager.GetByID("a"); FailureStreak(auth) != 0 {
+		t.Fatalf("streak after success = %d, want 0", FailureStreak(auth))
+	}
+
+	manager.refreshAuth(ctx, "a")
+	manager.refreshAuth(ctx, "a")
+	auth, _ = manager.GetByID("a")
+	quarantined, reason, since := QuarantineInfo(auth)
+	if !quarantined || !auth.Disabled || auth.Status != StatusQuarantined || reason == "" || since.IsZero() {
+		t.Fatalf("expected quarantine, got disabled=%t status=%s reason=%q", auth.Disabled, auth.Status, reason)
+	}
+	if manager.shouldRefresh(auth, since) {
+		t.Fatalf("quarantined auth should not be refreshed")
+	}
+
+	// Loading the persisted metadata restores the quarantine; clearing it releases the auth.
+	reloaded := &Auth{ID: "a", Metadata: auth.Metadata}
+	if !RestoreQuarantine(reloaded) || !reloaded.Disabled {
+		t.Fatalf("quarantine not restored from metadata")
+	}
+	ClearQuarantine(reloaded)
+	if quarantined, _, _ := QuarantineInfo(reloaded); quarantined || FailureStreak(reloaded) != 0 {
+		t.Fatalf("quarantine not cleared")
+	}
+}
+
+func TestTransientRefreshFailuresDoNotQuarantine(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(refreshFailingExecutor{err: errors.New("dial tcp: connection refused")})
+	if _, err := manager.Register(ctx, &Auth{ID: "a", Provider: "claude", Metadata: map[string]any{"email": "a@example.com"}}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	for i := 0; i < defaultQuarantineThreshold+1; i++ {
+		manager.refreshAuth(ctx, "a")
+	}
+	if auth, _ := manager.GetByID("a"); auth.Disabled || FailureStreak(auth) != 0 {
+		t.Fatalf("transient failures changed the auth: disabled=%t streak=%d", auth.Disabled, FailureStreak(auth))
+	}
+}
+
+// refreshingExecutor refreshes successfully and counts the refreshes.
+type refreshingExecutor struct {
+	refreshFailingExecutor
+	refreshes *atomic.Int32
+}
+
+func (e refreshingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.refreshes.Add(1)
+	return auth, nil
+}
+
+func newRefreshingManager(t *testing.T, threshold int) (*Manager, *atomic.Int32) {
+	t.Helper()
+	manager := NewManager(nil, nil, nil)
+	manager.SetQuarantine(internalconfig.QuarantineConfig{FailureThreshold: threshold})
+	refreshes := &atomic.Int32{}
+	manager.RegisterExecutor(refreshingExecutor{refreshes: refreshes})
+	if _, err := manager.Register(context.Background(), &Auth{ID: "a", Provider: "claude", Metadata: map[string]any{"email": "a@example.com"}}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return manager, refreshes
+}
+
+func TestSuccessfulRefreshAfterUnauthorizedDoesNotCount(t *testing.T) {
+	ctx := context.Background()
+	manager, refreshes := newRefreshingManager(t, 2)
+
+	used, _ := manager.GetByID("a")
+	manager.refreshAfterUnauthorized(ctx, used)
+	auth, _ := manager.GetByID("a")
+	if refreshes.Load() != 1 || FailureStreak(auth) != 0 || auth.Disabled {
+		t.Fatalf("after refresh: refreshes=%d streak=%d disabled=%t", refreshes.Load(), FailureStreak(auth), auth.Disabled)
+	}
+
+	// Requests that raced the refresh were rejected with the old token and are not counted.
+	manager.refreshAfterUnauthorized(ctx, used)
+	manager.forbiddenAfterRefresh(ctx, used)
+	if auth, _ = manager.GetByID("a"); refreshes.Load() != 1 || FailureStreak(auth) != 0 {
+		t.Fatalf("stale rejections: refreshes=%d streak=%d", refreshes.Load(), FailureStreak(auth))
+	}
+
+	// A success with the new token clears the marker, so a later 401 starts over.
+	manager.MarkResult(ctx, Result{AuthID: "a", Provider: "claude", Success: true})
+	used, _ = manager.GetByID("a")
+	manager.refreshAfterUnauthorized(ctx, used)
+	if auth, _ = manager.GetByID("a"); FailureStreak(auth) != 0 || auth.Disabled {
+		t.Fatalf("401 after success: streak=%d disabled=%t", FailureStreak(auth), auth.Disabled)
+	}
+}
+
+func TestRejectionsOfRefreshedTokenQuarantineAuth(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newRefreshingManager(t, 2)
+
+	used, _ := manager.GetByID("a")
+	manager.refreshAfterUnauthorized(ctx, used)
+
+	// Concurrent rejections of the refreshed token count once.
+	used, _ = manager.GetByID("a")
+	manager.forbiddenAfterRefresh(ctx, used)
+	manager.forbiddenAfterRefresh(ctx, used)
+	auth, _ := manager.GetByID("a")
+	if FailureStreak(auth) != 1 || auth.Disabled {
+		t.Fatalf("after 403: streak=%d disabled=%t", FailureStreak(auth), auth.Disabled)
+	}
+
+	manager.refreshAfterUnauthorized(ctx, used)
+	used, _ = manager.GetByID("a")
+	if FailureStreak(used) != 1 || used.Disabled {
+		t.Fatalf("after 401: streak=%d disabled=%t", FailureStreak(used), used.Disabled)
+	}
+	manager.refreshAfterUnauthorized(ctx, used)
+	auth, _ = manager.GetByID("a")
+	if quarantined, _, _ := QuarantineInfo(auth); !quarantined || !auth.Disabled {
+		t.Fatalf("expected quarantine, got streak=%d disabled=%t", FailureStreak(auth), auth.Disabled)
+	}
+}
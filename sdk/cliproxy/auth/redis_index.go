@@ -150,6 +150,41 @@ func (r *redisMessageIndex) SuggestAuth(scope string, msgHashes []uint64, auths
 	return nil
 }
 
+// SuggestPrefix returns the auth bound to the longest known conversation prefix,
+// fetching all prefix bindings with a single MGET.
+func (r *redisMessageIndex) SuggestPrefix(scope string, prefixHashes []uint64, auths []*Auth) *Auth {
+	if r == nil || scope == "" || len(prefixHashes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(prefixHashes))
+	for i := len(prefixHashes) - 1; i >= 0; i-- {
+		keys = append(keys, r.key(scope, prefixHashes[i]))
+	}
+	vals, err := r.client.MGet(context.Background(), keys...).Result()
+	if err != nil || len(vals) == 0 {
+		return nil
+	}
+	bound := make([]string, 0, len(vals))
+	for _, v := range vals {
+		var b msgBinding
+		switch vv := v.(type) {
+		case string:
+			_ = json.Unmarshal([]byte(vv), &b)
+		case []byte:
+			_ = json.Unmarshal(vv, &b)
+		default:
+			continue
+		}
+		if b.Stop {
+			continue
+		}
+		if id := strings.TrimSpace(b.AuthID); id != "" {
+			bound = append(bound, id)
+		}
+	}
+	return firstCandidate(bound, auths)
+}
+
 // Record stores per-hash bindings with TTL. It preserves the "majority binding"
 // behavior: increment when same auth, otherwise lightly decay and switch if count <= 0.
 func (r *redisMessageIndex) Record(scope string, msgHashes []uint64, authID string) {
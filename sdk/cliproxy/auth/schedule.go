@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+// ScheduleKey names the auth-file metadata entry and the attribute (set for config key entries)
+// holding a credential's availability schedule.
+const ScheduleKey = "schedule"
+
+type compiledSchedule struct {
+	raw   string
+	spec  config.CredentialSchedule
+	sched *schedule.Schedule
+	err   error
+}
+
+// ScheduleState describes where a credential stands in its availability schedule.
+type ScheduleState struct {
+	Schedule   config.CredentialSchedule `json:"schedule"`
+	Open       bool                      `json:"open"`
+	NextChange *time.Time                `json:"next_change,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+}
+
+// authSchedule returns the compiled schedule of auth, or nil when it has none. The Manager
+// compiles it when storing the auth, so selection does not re-parse it; auths changed since,
+// or never stored, are compiled on demand.
+func authSchedule(auth *Auth) *compiledSchedule {
+	raw := authScheduleRaw(auth)
+	if raw == "" {
+		return nil
+	}
+	if auth.schedule != nil && auth.schedule.raw == raw {
+		return auth.schedule
+	}
+	return compileSchedule(raw)
+}
+
+// attachSchedule compiles the schedule of an auth the Manager is about to store and keeps it
+// on the record, so cached schedules are dropped together with their auths.
+func attachSchedule(auth *Auth) {
+	raw := authScheduleRaw(auth)
+	if raw == "" {
+		auth.schedule = nil
+		return
+	}
+	if auth.schedule != nil && auth.schedule.raw == raw {
+		return
+	}
+	auth.schedule = compileSchedule(raw)
+	if auth.schedule.err != nil {
+		log.Warnf("auth %s: invalid schedule, credential stays unavailable: %v", auth.ID, auth.schedule.err)
+	}
+}
+
+// authScheduleRaw returns the JSON form of the schedule of auth, or "" when it has none.
+// Metadata takes precedence over attributes, matching max_concurrency.
+func authScheduleRaw(auth *Auth) string {
+	if auth == nil {
+		return ""
+	}
+	var raw string
+	if v, ok := auth.Metadata[ScheduleKey]; ok && v != nil {
+		switch typed := v.(type) {
+		case string:
+			raw = strings.TrimSpace(typed)
+		default:
+			if data, err := json.Marshal(typed); err == nil {
+				raw = string(data)
+			}
+		}
+	}
+	if raw == "" {
+		raw = strings.TrimSpace(auth.Attributes[ScheduleKey])
+	}
+	if raw == "null" {
+		return ""
+	}
+	return raw
+}
+
+func compileSchedule(raw string) *compiledSchedule {
+	compiled := &compiledSchedule{raw: raw}
+	if compiled.err = json.Unmarshal([]byte(raw), &compiled.spec); compiled.err == nil {
+		compiled.sched, compiled.err = schedule.Compile(compiled.spec.Timezone, compiled.spec.Mode, compiled.spec.Windows)
+	}
+	return compiled
+}
+
+// scheduleBlocked reports whether auth is outside its schedule at now and when that changes.
+// Invalid schedules block the credential without a known end.
+func scheduleBlocked(auth *Auth, now time.Time) (bool, time.Time) {
+	compiled := authSchedule(auth)
+	if compiled == nil {
+		return false, time.Time{}
+	}
+	if compiled.err != nil {
+		return true, time.Time{}
+	}
+	if compiled.sched.Open(now) {
+		return false, time.Time{}
+	}
+	return true, compiled.sched.NextChange(now)
+}
+
+// AuthScheduleState reports the schedule state of auth at now, or nil when it has no schedule.
+func AuthScheduleState(auth *Auth, now time.Time) *ScheduleState {
+	compiled := authSchedule(auth)
+	if compiled == nil {
+		return nil
+	}
+	state := &ScheduleState{Schedule: compiled.spec}
+	if compiled.err != nil {
+		state.Error = compiled.err.Error()
+		return state
+	}
+	state.Open = compiled.sched.Open(now)
+	if next := compiled.sched.NextChange(now); !next.IsZero() {
+		state.NextChange = &next
+	}
+	return state
+}
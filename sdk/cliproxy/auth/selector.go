@@ -31,6 +31,7 @@ const (
 	blockReasonNone blockReason = iota
 	blockReasonCooldown
 	blockReasonDisabled
+	blockReasonSchedule
 	blockReasonOther
 )
 
@@ -103,7 +104,7 @@ func (e *modelCooldownError) Headers() http.Header {
 	return headers
 }
 
-func collectAvailable(auths []*Auth, model string, now time.Time) (available []*Auth, cooldownCount int, earliest time.Time) {
+func collectAvailable(auths []*Auth, model string, now time.Time) (available []*Auth, waiting unavailableCounts) {
 	available = make([]*Auth, 0, len(auths))
 	for i := 0; i < len(auths); i++ {
 		candidate := auths[i]
@@ -112,17 +113,55 @@ func collectAvailable(auths []*Auth, model string, now time.Time) (available []*
 			available = append(available, candidate)
 			continue
 		}
-		if reason == blockReasonCooldown {
-			cooldownCount++
-			if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
-				earliest = next
-			}
-		}
+		waiting.add(reason, next)
 	}
 	if len(available) > 1 {
 		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
 	}
-	return available, cooldownCount, earliest
+	return available, waiting
+}
+
+// unavailableCounts tallies candidates that are only temporarily unavailable: cooling down
+// after a quota error or outside their schedule.
+type unavailableCounts struct {
+	cooldown  int
+	scheduled int
+	earliest  time.Time
+}
+
+func (u *unavailableCounts) add(reason blockReason, next time.Time) {
+	switch reason {
+	case blockReasonCooldown:
+		u.cooldown++
+	case blockReasonSchedule:
+		u.scheduled++
+	default:
+		return
+	}
+	if !next.IsZero() && (u.earliest.IsZero() || next.Before(u.earliest)) {
+		u.earliest = next
+	}
+}
+
+// errorFor returns the error reported when none of total candidates is available, or nil when
+// some of them are blocked for other reasons.
+func (u unavailableCounts) errorFor(total int, provider, model string, now time.Time) error {
+	if total == 0 || u.cooldown+u.scheduled != total || u.earliest.IsZero() {
+		return nil
+	}
+	resetIn := u.earliest.Sub(now)
+	if resetIn < 0 {
+		resetIn = 0
+	}
+	if u.cooldown > 0 {
+		return newModelCooldownError(model, provider, resetIn)
+	}
+	return &Error{
+		Code:       "auth_off_schedule",
+		Message:    fmt.Sprintf("all credentials are outside their schedule; the next window opens at %s", u.earliest.UTC().Format(time.RFC3339)),
+		Retryable:  true,
+		HTTPStatus: http.StatusTooManyRequests,
+	}
 }
 
 func getAvailableAuths(auths []*Auth, provider, model string, now time.Time) ([]*Auth, error) {
@@ -130,14 +169,10 @@ func getAvailableAuths(auths []*Auth, provider, model string, now time.Time) ([]
 		return nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
 	}
 
-	available, cooldownCount, earliest := collectAvailable(auths, model, now)
+	available, waiting := collectAvailable(auths, model, now)
 	if len(available) == 0 {
-		if cooldownCount == len(auths) && !earliest.IsZero() {
-			resetIn := earliest.Sub(now)
-			if resetIn < 0 {
-				resetIn = 0
-			}
-			return nil, newModelCooldownError(model, provider, resetIn)
+		if err := waiting.errorFor(len(auths), provider, model, now); err != nil {
+			return nil, err
 		}
 		return nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
 	}
@@ -190,6 +225,9 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	if off, next := scheduleBlocked(auth, now); off {
+		return true, blockReasonSchedule, next
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			if state, ok := auth.ModelStates[model]; ok && state != nil {
@@ -3,8 +3,11 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"sync"
 	"testing"
+	"time"
 
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 )
@@ -111,3 +114,73 @@ func TestRoundRobinSelectorPick_Concurrent(t *testing.T) {
 	default:
 	}
 }
+
+func TestIsAuthBlockedForModel_Schedule(t *testing.T) {
+	t.Parallel()
+
+	office := &Auth{ID: "office", Metadata: map[string]any{
+		"schedule": map[string]any{"windows": []any{"* 9-17 * * *"}},
+	}}
+	night := &Auth{ID: "night", Attributes: map[string]string{
+		ScheduleKey: `{"mode":"deny","windows":["* 9-17 * * *"]}`,
+	}}
+	broken := &Auth{ID: "broken", Attributes: map[string]string{ScheduleKey: `{"windows":["* 25 * * *"]}`}}
+
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+	if blocked, _, _ := isAuthBlockedForModel(office, "m", now); blocked {
+		t.Fatalf("office credential should be available at 10:00")
+	}
+	blocked, reason, next := isAuthBlockedForModel(night, "m", now)
+	if !blocked || reason != blockReasonSchedule || !next.Equal(time.Date(2026, time.March, 2, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("night credential = %t, %v, %v", blocked, reason, next)
+	}
+	if blocked, reason, _ := isAuthBlockedForModel(broken, "", now); !blocked || reason != blockReasonSchedule {
+		t.Fatalf("invalid schedule should block the credential")
+	}
+
+	_, err := getAvailableAuths([]*Auth{night}, "claude", "m", now)
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "auth_off_schedule" || authErr.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("getAvailableAuths() error = %v, want auth_off_schedule", err)
+	}
+	available, err := getAvailableAuths([]*Auth{night, office}, "claude", "m", now)
+	if err != nil || len(available) != 1 || available[0].ID != "office" {
+		t.Fatalf("getAvailableAuths() = %v, %v", available, err)
+	}
+}
+
+func TestManagerAttachesCompiledSchedules(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+	first, second := NewManager(nil, nil, nil), NewManager(nil, nil, nil)
+	for _, manager := range []*Manager{first, second} {
+		auth := &Auth{ID: "scheduled", Attributes: map[string]string{ScheduleKey: `{"windows":["* 10 * * *"]}`}}
+		if _, err := manager.Register(ctx, auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	a, _ := first.GetByID("scheduled")
+	b, _ := second.GetByID("scheduled")
+	if a.schedule == nil || authSchedule(a) != a.schedule {
+		t.Fatal("stored auth does not carry its compiled schedule")
+	}
+	if a.schedule == b.schedule {
+		t.Fatal("managers share compiled schedules")
+	}
+
+	for hour := 0; hour < 24; hour++ {
+		a.Attributes[ScheduleKey] = fmt.Sprintf(`{"windows":["* %d * * *"]}`, hour)
+		if _, err := first.Update(ctx, a); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		a, _ = first.GetByID("scheduled")
+		if blocked, _, _ := isAuthBlockedForModel(a, "m", now); blocked != (hour != 10) {
+			t.Fatalf("hour %d: blocked = %t, schedule change not picked up", hour, blocked)
+		}
+		if authSchedule(a) != a.schedule {
+			t.Fatalf("hour %d: updated schedule not attached", hour)
+		}
+	}
+}
@@ -16,4 +16,6 @@ const (
 	StatusError Status = "error"
 	// StatusDisabled marks the auth as intentionally disabled.
 	StatusDisabled Status = "disabled"
+	// StatusQuarantined marks an auth disabled after repeated unrecoverable failures.
+	StatusQuarantined Status = "quarantined"
 )
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// StickyStrategyMessageHash votes over hashes of individual user messages.
+	StickyStrategyMessageHash = "message-hash"
+	// StickyStrategyPrefix binds the hash chain of system prompt plus leading messages.
+	StickyStrategyPrefix = "prefix"
+
+	// defaultPrefixMessages bounds the leading messages hashed by the prefix strategy.
+	defaultPrefixMessages = 4
+)
+
+// stickyRouting is the resolved per-provider strategy table of a SmartStickySelector.
+// A nil providers map keeps the legacy behaviour (Codex only, message-hash).
+type stickyRouting struct {
+	providers      map[string]string
+	prefixMessages int
+}
+
+// SetRouting applies the sticky-routing configuration. Provider keys are matched
+// case-insensitively against the provider identifier, the openai-compatibility provider
+// name, and finally the generic "openai-compatibility" key.
+func (s *SmartStickySelector) SetRouting(cfg internalconfig.StickyRoutingConfig) {
+	if s == nil {
+		return
+	}
+	routing := stickyRouting{prefixMessages: cfg.PrefixMessages}
+	if len(cfg.Providers) > 0 {
+		routing.providers = make(map[string]string, len(cfg.Providers))
+		for provider, strategy := range cfg.Providers {
+			key := strings.ToLower(strings.TrimSpace(provider))
+			if key == "" {
+				continue
+			}
+			normalized := normalizeStickyStrategy(strategy)
+			if normalized == "" && !isStickyStrategyOff(strategy) {
+				log.Warnf("sticky-routing: unknown strategy %q for provider %s, disabling", strategy, provider)
+			}
+			routing.providers[key] = normalized
+		}
+	}
+	s.mu.Lock()
+	s.routing = routing
+	s.mu.Unlock()
+}
+
+// StickyRoutingEnabled reports whether cfg opts any provider into sticky routing.
+func StickyRoutingEnabled(cfg internalconfig.StickyRoutingConfig) bool {
+	for _, strategy := range cfg.Providers {
+		if normalizeStickyStrategy(strategy) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeStickyStrategy(strategy string) string {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "message-hash", "messagehash", "message", "messages":
+		return StickyStrategyMessageHash
+	case "prefix":
+		return StickyStrategyPrefix
+	default:
+		return ""
+	}
+}
+
+func isStickyStrategyOff(strategy string) bool {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "", "off", "none", "disabled", "round-robin":
+		return true
+	default:
+		return false
+	}
+}
+
+// strategyFor resolves the sticky strategy for a provider scope. sample is any candidate
+// auth of the provider and is used to recognise openai-compatibility providers.
+func (s *SmartStickySelector) strategyFor(scope string, sample *Auth) string {
+	s.mu.Lock()
+	providers := s.routing.providers
+	s.mu.Unlock()
+	if providers == nil {
+		if scope == "codex" {
+			return StickyStrategyMessageHash
+		}
+		return ""
+	}
+	if strategy, ok := providers[scope]; ok {
+		return strategy
+	}
+	compatName := ""
+	if sample != nil && sample.Attributes != nil {
+		compatName = strings.ToLower(strings.TrimSpace(sample.Attributes["compat_name"]))
+	}
+	if compatName != "" {
+		if strategy, ok := providers[compatName]; ok {
+			return strategy
+		}
+	}
+	if compatName != "" || scope == "openai-compatibility" {
+		return providers["openai-compatibility"]
+	}
+	return ""
+}
+
+func (s *SmartStickySelector) prefixMessages() int {
+	s.mu.Lock()
+	n := s.routing.prefixMessages
+	s.mu.Unlock()
+	if n <= 0 {
+		n = defaultPrefixMessages
+	}
+	return n
+}
+
+// prefixTurn is one conversation message reduced to its role and text parts.
+type prefixTurn struct {
+	role  string
+	texts []string
+}
+
+// extractPrefixHashes returns cumulative hashes over the system prompt and the leading
+// conversation messages (at most limit), ordered from the shortest to the longest prefix.
+// Hashes are only emitted once the prefix carries enough text to identify a conversation,
+// and never for the system prompt alone, so shared system prompts do not couple sessions.
+func extractPrefixHashes(raw []byte, limit int) []uint64 {
+	system, turns := conversationPrefix(raw)
+	if len(turns) == 0 {
+		return nil
+	}
+	if limit > 0 && len(turns) > limit {
+		turns = turns[:limit]
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("prefix\x00"))
+	textRunes := 0
+	for _, text := range system {
+		_, _ = h.Write([]byte("system\x00"))
+		_, _ = h.Write([]byte(text))
+		_, _ = h.Write([]byte{0})
+		textRunes += countTextRunes(text)
+	}
+	hashes := make([]uint64, 0, len(turns))
+	for _, turn := range turns {
+		_, _ = h.Write([]byte(turn.role))
+		_, _ = h.Write([]byte{0})
+		for _, text := range turn.texts {
+			_, _ = h.Write([]byte(text))
+			_, _ = h.Write([]byte{0})
+			textRunes += countTextRunes(text)
+		}
+		if textRunes >= minMessageRuneLen {
+			hashes = append(hashes, h.Sum64())
+		}
+	}
+	return hashes
+}
+
+// conversationPrefix extracts the system prompt and ordered messages from OpenAI chat,
+// OpenAI responses, Claude messages, Gemini and gemini-cli/antigravity request bodies.
+func conversationPrefix(raw []byte) ([]string, []prefixTurn) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var root map[string]any
+	if err := json.Unmarshal(raw, &root); err != nil || root == nil {
+		return nil, nil
+	}
+	if inner, ok := root["request"].(map[string]any); ok {
+		if _, hasContents := inner["contents"]; hasContents {
+			root = inner
+		}
+	}
+
+	var system []string
+	system = append(system, partsText(root["system"])...)
+	for _, key := range []string{"systemInstruction", "system_instruction"} {
+		if instr, ok := root[key].(map[string]any); ok {
+			system = append(system, partsText(instr["parts"])...)
+		}
+	}
+	if instructions, ok := root["instructions"].(string); ok {
+		system = append(system, instructions)
+	}
+
+	var turns []prefixTurn
+	if msgs, ok := root["messages"].([]any); ok {
+		for _, msgAny := range msgs {
+			msg, ok := msgAny.(map[string]any)
+			if !ok {
+				continue
+			}
+			role := strings.ToLower(strings.TrimSpace(lookupString(msg, "role")))
+			texts := partsText(msg["content"])
+			if role == "system" || role == "developer" {
+				system = append(system, texts...)
+				continue
+			}
+			turns = append(turns, prefixTurn{role: role, texts: texts})
+		}
+	} else if contents := geminiContents(root); contents != nil {
+		for _, itemAny := range contents {
+			item, ok := itemAny.(map[string]any)
+			if !ok {
+				continue
+			}
+			role := strings.ToLower(strings.TrimSpace(lookupString(item, "role")))
+			turns = append(turns, prefixTurn{role: role, texts: partsText(item["parts"])})
+		}
+	} else if input, ok := root["input"]; ok {
+		switch v := input.(type) {
+		case string:
+			turns = append(turns, prefixTurn{role: "user", texts: []string{v}})
+		case []any:
+			for _, itemAny := range v {
+				item, ok := itemAny.(map[string]any)
+				if !ok {
+					continue
+				}
+				role := strings.ToLower(strings.TrimSpace(lookupString(item, "role")))
+				if role == "" {
+					role = strings.ToLower(strings.TrimSpace(lookupString(item, "type")))
+				}
+				texts := partsText(item["content"])
+				if t, ok := item["text"].(string); ok {
+					texts = append(texts, t)
+				}
+				if role == "system" || role == "developer" {
+					system = append(system, texts...)
+					continue
+				}
+				turns = append(turns, prefixTurn{role: role, texts: texts})
+			}
+		}
+	}
+	return trimTexts(system), turns
+}
+
+// geminiContents returns the Gemini "contents" array, unwrapping the gemini-cli "request" envelope.
+func geminiContents(m map[string]any) []any {
+	if contents, ok := m["contents"].([]any); ok {
+		return contents
+	}
+	if inner, ok := m["request"].(map[string]any); ok {
+		if contents, ok := inner["contents"].([]any); ok {
+			return contents
+		}
+	}
+	return nil
+}
+
+// partsText collects text from a string, or from an array of content parts carrying
+// "text" (OpenAI, Claude, Gemini) or string "content" fields.
+func partsText(node any) []string {
+	switch v := node.(type) {
+	case string:
+		if t := strings.TrimSpace(v); t != "" {
+			return []string{t}
+		}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, partAny := range v {
+			switch part := partAny.(type) {
+			case string:
+				if t := strings.TrimSpace(part); t != "" {
+					out = append(out, t)
+				}
+			case map[string]any:
+				if t := lookupString(part, "text"); t != "" {
+					out = append(out, t)
+				} else if t := lookupString(part, "content"); t != "" {
+					out = append(out, t)
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func trimTexts(texts []string) []string {
+	out := texts[:0]
+	for _, t := range texts {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func countTextRunes(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
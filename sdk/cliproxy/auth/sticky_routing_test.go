@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestExtractPrefixHashes_StableAcrossTurnsAndFormats(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][2]string{
+		"claude": {
+			`{"system":[{"type":"text","text":"You are a coding agent."}],"messages":[{"role":"user","content":"Refactor the billing module please"}]}`,
+			`{"system":[{"type":"text","text":"You are a coding agent."}],"messages":[{"role":"user","content":"Refactor the billing module please"},{"role":"assistant","content":[{"type":"text","text":"Done."}]},{"role":"user","content":"Now add tests"}]}`,
+		},
+		"gemini-cli": {
+			`{"model":"gemini-2.5-pro","request":{"systemInstruction":{"parts":[{"text":"Be terse."}]},"contents":[{"role":"user","parts":[{"text":"Explain the scheduler in detail"}]}]}}`,
+			`{"model":"gemini-2.5-pro","request":{"systemInstruction":{"parts":[{"text":"Be terse."}]},"contents":[{"role":"user","parts":[{"text":"Explain the scheduler in detail"}]},{"role":"model","parts":[{"text":"It runs jobs."}]},{"role":"user","parts":[{"text":"More"}]}]}}`,
+		},
+		"openai": {
+			`{"messages":[{"role":"system","content":"You are helpful."},{"role":"user","content":"Summarise this repository layout"}]}`,
+			`{"messages":[{"role":"system","content":"You are helpful."},{"role":"user","content":"Summarise this repository layout"},{"role":"assistant","content":"Sure."},{"role":"user","content":"Go on"}]}`,
+		},
+	}
+	for name, tc := range cases {
+		first := extractPrefixHashes([]byte(tc[0]), 4)
+		second := extractPrefixHashes([]byte(tc[1]), 4)
+		if len(first) != 1 {
+			t.Fatalf("%s: first turn hashes = %d, want 1", name, len(first))
+		}
+		if len(second) != 3 {
+			t.Fatalf("%s: second turn hashes = %d, want 3", name, len(second))
+		}
+		if first[0] != second[0] {
+			t.Fatalf("%s: leading prefix hash changed between turns", name)
+		}
+	}
+
+	systemOnly := extractPrefixHashes([]byte(`{"system":"A very long shared system prompt for everyone","messages":[{"role":"user","content":"hi"}]}`), 4)
+	other := extractPrefixHashes([]byte(`{"system":"A very long shared system prompt for everyone","messages":[{"role":"user","content":"yo"}]}`), 4)
+	if len(systemOnly) != 1 || len(other) != 1 || systemOnly[0] == other[0] {
+		t.Fatalf("different first messages must not share a prefix hash")
+	}
+}
+
+func TestSmartStickySelector_PrefixStrategyKeepsConversation(t *testing.T) {
+	selector := NewSmartStickySelector()
+	selector.SetRouting(internalconfig.StickyRoutingConfig{Providers: map[string]string{"claude": "prefix"}})
+	auths := []*Auth{{ID: "a", Provider: "claude"}, {ID: "b", Provider: "claude"}, {ID: "c", Provider: "claude"}}
+
+	turn1 := []byte(`{"system":"You are a coding agent.","messages":[{"role":"user","content":"Refactor the billing module please"}]}`)
+	turn2 := []byte(`{"system":"You are a coding agent.","messages":[{"role":"user","content":"Refactor the billing module please"},{"role":"assistant","content":"Done."},{"role":"user","content":"Now add tests"}]}`)
+
+	ctx, pick := withStickyPick(context.Background())
+	first, err := selector.Pick(ctx, "claude", "claude-sonnet", cliproxyexecutor.Options{OriginalRequest: turn1}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if decision := pick.decision; decision.strategy != StickyStrategyPrefix || decision.outcome != stickyOutcomeMiss {
+		t.Fatalf("first decision = %+v, want prefix/miss", decision)
+	}
+	selector.idx.Record("claude", extractPrefixHashes(turn1, selector.prefixMessages()), first.ID)
+
+	for i := 0; i < 5; i++ {
+		got, errPick := selector.Pick(ctx, "claude", "claude-sonnet", cliproxyexecutor.Options{OriginalRequest: turn2}, auths)
+		if errPick != nil {
+			t.Fatalf("Pick() #%d error = %v", i, errPick)
+		}
+		if got.ID != first.ID {
+			t.Fatalf("Pick() #%d = %q, want sticky %q", i, got.ID, first.ID)
+		}
+		if pick.decision.outcome != stickyOutcomeHit {
+			t.Fatalf("Pick() #%d outcome = %q, want hit", i, pick.decision.outcome)
+		}
+	}
+
+	// Providers without sticky routing use the fallback selector.
+	selector.SetFallback(&FillFirstSelector{})
+	got, err := selector.Pick(ctx, "gemini", "", cliproxyexecutor.Options{OriginalRequest: turn2}, auths)
+	if err != nil || got.ID != "a" {
+		t.Fatalf("fallback Pick() = %v, %v; want a", got, err)
+	}
+	if pick.decision.strategy != stickyStrategyOff {
+		t.Fatalf("fallback strategy = %q, want off", pick.decision.strategy)
+	}
+}
+
+func TestSmartStickySelector_CompatStrategyResolution(t *testing.T) {
+	t.Parallel()
+
+	selector := NewSmartStickySelector()
+	if got := selector.strategyFor("codex", nil); got != StickyStrategyMessageHash {
+		t.Fatalf("default codex strategy = %q", got)
+	}
+	if got := selector.strategyFor("claude", nil); got != "" {
+		t.Fatalf("default claude strategy = %q, want off", got)
+	}
+	selector.SetRouting(internalconfig.StickyRoutingConfig{Providers: map[string]string{"openai-compatibility": "prefix", "Kimi": "off"}})
+	compat := &Auth{ID: "x", Provider: "openrouter", Attributes: map[string]string{"compat_name": "openrouter"}}
+	if got := selector.strategyFor("openrouter", compat); got != StickyStrategyPrefix {
+		t.Fatalf("compat strategy = %q, want prefix", got)
+	}
+	kimi := &Auth{ID: "y", Provider: "kimi", Attributes: map[string]string{"compat_name": "kimi"}}
+	if got := selector.strategyFor("kimi", kimi); got != "" {
+		t.Fatalf("explicit off strategy = %q", got)
+	}
+	if got := selector.strategyFor("codex", nil); got != "" {
+		t.Fatalf("codex without entry = %q, want off", got)
+	}
+}
+
+func TestStickyUsagePlugin_AggregatesCachedTokens(t *testing.T) {
+	store := &stickyStatsStore{stats: make(map[stickyDecision]*StickyRoutingStat)}
+	decision := stickyDecision{provider: "claude", strategy: StickyStrategyPrefix, outcome: stickyOutcomeHit}
+	store.record(decision, coreusage.Record{Detail: coreusage.Detail{InputTokens: 100, CachedTokens: 80}})
+	store.record(decision, coreusage.Record{Failed: true})
+	stat := store.stats[decision]
+	if stat.Requests != 2 || stat.Failed != 1 || stat.InputTokens != 100 || stat.CachedTokens != 80 {
+		t.Fatalf("unexpected stat %+v", *stat)
+	}
+
+	ctx, pick := withStickyPick(context.Background())
+	noteStickyDecision(ctx, "claude", StickyStrategyPrefix, stickyOutcomeHit)
+	execCtx := pick.attach(context.Background())
+	if got, ok := execCtx.Value(stickyRouteKey{}).(stickyDecision); !ok || got != decision {
+		t.Fatalf("attached decision = %+v, %v", got, ok)
+	}
+}
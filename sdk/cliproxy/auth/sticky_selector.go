@@ -14,14 +14,18 @@ import (
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 )
 
-// SmartStickySelector chooses credentials deterministically for Codex based on a
-// context fingerprint to preserve upstream per-account caches, and falls back
-// to round-robin for other providers or when no context is available.
-// Rendezvous (highest-random-weight) hashing provides good balance and stability.
+// SmartStickySelector chooses credentials deterministically based on a conversation
+// fingerprint to preserve upstream per-account prompt caches, and falls back to the
+// configured fallback selector (round-robin by default) for providers without sticky
+// routing or when no usable context is available.
+// Providers opt in through SetRouting; by default only Codex is sticky-routed using
+// per-message hashes.
 type SmartStickySelector struct {
-	mu      sync.Mutex
-	offsets map[string]int    // fallback round-robin cursor per (provider|model)
-	idx     messageIndexStore // per-message hash -> auth binding index (memory or redis)
+	mu       sync.Mutex
+	offsets  map[string]int    // fallback round-robin cursor per (provider|model)
+	idx      messageIndexStore // per-message hash -> auth binding index (memory or redis)
+	routing  stickyRouting     // per-provider strategy table
+	fallback Selector          // optional selector used when sticky routing does not apply
 }
 
 // NewSmartStickySelector constructs a new sticky selector.
@@ -32,20 +36,33 @@ func NewSmartStickySelector() *SmartStickySelector {
 	}
 }
 
+// SetFallback sets the selector used for providers that are not sticky-routed and for
+// requests without a usable fingerprint. Nil restores the built-in round-robin.
+func (s *SmartStickySelector) SetFallback(fallback Selector) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.fallback = fallback
+	s.mu.Unlock()
+}
+
 // Pick implements Selector.
-// - For provider "codex":
+// - For providers routed with "message-hash" (Codex by default):
 //  1. Try per-message-hash suggestion;
 //  2. If any messages present but no reliable suggestion: RANDOM among available (fairness);
-//  3. If no messages: try strong context key (conversation/thread) rendezvous;
 //
-// - Otherwise: round-robin.
-func (s *SmartStickySelector) Pick(_ context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+// - For providers routed with "prefix":
+//  1. Reuse the auth bound to the longest known conversation prefix;
+//  2. Otherwise RANDOM among available;
+//
+// - Otherwise: fallback selector (round-robin by default).
+func (s *SmartStickySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
 	now := time.Now()
 
-	// Filter out disabled or cooling-down auths.
+	// Filter out disabled, cooling-down and off-schedule auths.
 	filtered := make([]*Auth, 0, len(auths))
-	cooldownCount := 0
-	var earliest time.Time
+	var waiting unavailableCounts
 	total := 0
 	for _, a := range auths {
 		if a == nil {
@@ -57,23 +74,14 @@ func (s *SmartStickySelector) Pick(_ context.Context, provider, model string, op
 		}
 		blocked, reason, next := isAuthBlockedForModel(a, model, now)
 		if blocked {
-			if reason == blockReasonCooldown {
-				cooldownCount++
-				if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
-					earliest = next
-				}
-			}
+			waiting.add(reason, next)
 			continue
 		}
 		filtered = append(filtered, a)
 	}
 	if len(filtered) == 0 {
-		if cooldownCount == total && !earliest.IsZero() {
-			resetIn := earliest.Sub(now)
-			if resetIn < 0 {
-				resetIn = 0
-			}
-			return nil, newModelCooldownError(model, provider, resetIn)
+		if err := waiting.errorFor(total, provider, model, now); err != nil {
+			return nil, err
 		}
 		return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
@@ -84,32 +92,52 @@ func (s *SmartStickySelector) Pick(_ context.Context, provider, model string, op
 	}
 
 	scope := strings.ToLower(strings.TrimSpace(provider))
+	strategy := s.strategyFor(scope, filtered[0])
+	outcome := stickyOutcomeSkip
 	var chosen *Auth
 
-	// Sticky selection only for Codex
-	if strings.EqualFold(strings.TrimSpace(provider), "codex") {
+	switch strategy {
+	case StickyStrategyMessageHash:
 		// 1) Try per-message hash index suggestion
 		msgHashes := extractMessageHashes(opts.OriginalRequest)
 		if len(msgHashes) > 0 && s.idx != nil {
 			if a := s.idx.SuggestAuth(scope, msgHashes, filtered); a != nil {
 				chosen = a
+				outcome = stickyOutcomeHit
 			}
 		}
 
 		// 2) If messages exist but no reliable suggestion: RANDOM among available (not "second best")
-		if chosen == nil && len(msgHashes) > 0 && len(filtered) > 0 {
-			idx := randIntn(len(filtered))
-			if idx < 0 || idx >= len(filtered) {
-				idx = 0
+		if chosen == nil && len(msgHashes) > 0 {
+			chosen = filtered[randomIndex(len(filtered))]
+			outcome = stickyOutcomeMiss
+		}
+	case StickyStrategyPrefix:
+		prefixHashes := extractPrefixHashes(opts.OriginalRequest, s.prefixMessages())
+		if len(prefixHashes) > 0 && s.idx != nil {
+			if a := s.idx.SuggestPrefix(scope, prefixHashes, filtered); a != nil {
+				chosen = a
+				outcome = stickyOutcomeHit
 			}
-			chosen = filtered[idx]
 		}
-
-		// 4) If chosen, return (recording occurs on successful execution)
-		if chosen != nil {
-			return chosen.Clone(), nil
+		if chosen == nil && len(prefixHashes) > 0 {
+			chosen = filtered[randomIndex(len(filtered))]
+			outcome = stickyOutcomeMiss
 		}
 	}
+	noteStickyDecision(ctx, scope, strategy, outcome)
+
+	// If chosen, return (recording occurs on successful execution)
+	if chosen != nil {
+		return chosen.Clone(), nil
+	}
+
+	s.mu.Lock()
+	fallback := s.fallback
+	s.mu.Unlock()
+	if fallback != nil {
+		return fallback.Pick(ctx, provider, model, opts, auths)
+	}
 
 	// Fallback: round-robin by (provider|model)
 	k := scope
@@ -124,6 +152,14 @@ func (s *SmartStickySelector) Pick(_ context.Context, provider, model string, op
 	return filtered[cur].Clone(), nil
 }
 
+func randomIndex(n int) int {
+	idx := randIntn(n)
+	if idx < 0 || idx >= n {
+		idx = 0
+	}
+	return idx
+}
+
 func hash64(s string) uint64 {
 	h := fnv.New64a()
 	_, _ = h.Write([]byte(s))
@@ -170,6 +206,7 @@ func lookupNestedString(node any, k1, k2 string) string {
 // Implementations: in-memory (messageIndex) and Redis-backed (redisMessageIndex).
 type messageIndexStore interface {
 	SuggestAuth(scope string, msgHashes []uint64, auths []*Auth) *Auth
+	SuggestPrefix(scope string, prefixHashes []uint64, auths []*Auth) *Auth
 	Record(scope string, msgHashes []uint64, authID string)
 	InvalidateAuth(scope, authID string) int
 }
@@ -279,6 +316,41 @@ func (idx *messageIndex) SuggestAuth(scope string, msgHashes []uint64, auths []*
 	return nil
 }
 
+// SuggestPrefix returns the auth bound to the longest known conversation prefix.
+// prefixHashes are ordered from the shortest to the longest prefix.
+func (idx *messageIndex) SuggestPrefix(scope string, prefixHashes []uint64, auths []*Auth) *Auth {
+	if idx == nil || scope == "" || len(prefixHashes) == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	table := idx.byKey[scope]
+	if table == nil {
+		idx.mu.RUnlock()
+		return nil
+	}
+	bound := make([]string, 0, len(prefixHashes))
+	for i := len(prefixHashes) - 1; i >= 0; i-- {
+		if b, ok := table[prefixHashes[i]]; ok && b != nil && b.AuthID != "" && !b.Stop {
+			bound = append(bound, b.AuthID)
+		}
+	}
+	idx.mu.RUnlock()
+	return firstCandidate(bound, auths)
+}
+
+// firstCandidate returns the first auth from ids that is present and enabled in auths.
+func firstCandidate(ids []string, auths []*Auth) *Auth {
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		for _, a := range auths {
+			if a != nil && !a.Disabled && strings.TrimSpace(a.ID) == id {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
 // Record stores bindings from message hashes to the chosen auth id with TTL and periodic GC.
 func (idx *messageIndex) Record(scope string, msgHashes []uint64, authID string) {
 	if idx == nil || scope == "" || authID == "" || len(msgHashes) == 0 {
@@ -405,7 +477,7 @@ func (s *SmartStickySelector) InvalidateAuth(scope, authID string) int {
 const minMessageRuneLen = 16
 
 // extractMessageHashes parses request JSON and returns unique 64-bit hashes
-// for per-message user/system text content or responses-style input.
+// for per-message user text content, responses-style input or Gemini contents.
 func extractMessageHashes(raw []byte) []uint64 {
 	if len(raw) == 0 {
 		return nil
@@ -480,6 +552,23 @@ func extractMessageHashes(raw []byte) []uint64 {
 				}
 			}
 		}
+	} else if contents := geminiContents(m); contents != nil {
+		// Gemini-style contents (also wrapped under "request" by gemini-cli/antigravity clients)
+		for _, itemAny := range contents {
+			item, ok := itemAny.(map[string]any)
+			if !ok {
+				continue
+			}
+			role, _ := item["role"].(string)
+			if !strings.EqualFold(strings.TrimSpace(role), "user") {
+				continue
+			}
+			for _, t := range partsText(item["parts"]) {
+				if s := normalizeText(t); s != "" {
+					hashes = append(hashes, hash64(s))
+				}
+			}
+		}
 	}
 
 	// Deduplicate
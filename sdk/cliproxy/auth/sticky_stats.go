@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	// stickyOutcomeHit marks requests routed to the auth bound to a known conversation.
+	stickyOutcomeHit = "hit"
+	// stickyOutcomeMiss marks fingerprinted requests without a usable binding (new conversation).
+	stickyOutcomeMiss = "miss"
+	// stickyOutcomeSkip marks requests handled by the fallback selector.
+	stickyOutcomeSkip = "skip"
+
+	stickyStrategyOff = "off"
+)
+
+// StickyRoutingStat aggregates usage for one (provider, strategy, outcome) combination so
+// cache-hit tokens can be compared between sticky strategies and plain rotation.
+type StickyRoutingStat struct {
+	Provider     string `json:"provider"`
+	Strategy     string `json:"strategy"`
+	Outcome      string `json:"outcome"`
+	Requests     int64  `json:"requests"`
+	Failed       int64  `json:"failed"`
+	InputTokens  int64  `json:"input_tokens"`
+	CachedTokens int64  `json:"cached_tokens"`
+}
+
+type stickyDecision struct {
+	provider string
+	strategy string
+	outcome  string
+}
+
+type stickyPickKey struct{}
+
+type stickyRouteKey struct{}
+
+// stickyPick receives the decision made by SmartStickySelector during pickNext.
+type stickyPick struct {
+	mu       sync.Mutex
+	decision stickyDecision
+	set      bool
+}
+
+// withStickyPick returns a context the selector can report its routing decision into.
+func withStickyPick(ctx context.Context) (context.Context, *stickyPick) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pick := &stickyPick{}
+	return context.WithValue(ctx, stickyPickKey{}, pick), pick
+}
+
+func noteStickyDecision(ctx context.Context, provider, strategy, outcome string) {
+	if ctx == nil {
+		return
+	}
+	pick, ok := ctx.Value(stickyPickKey{}).(*stickyPick)
+	if !ok || pick == nil {
+		return
+	}
+	if strategy == "" {
+		strategy = stickyStrategyOff
+	}
+	pick.mu.Lock()
+	pick.decision = stickyDecision{provider: provider, strategy: strategy, outcome: outcome}
+	pick.set = true
+	pick.mu.Unlock()
+}
+
+// attach moves the latest recorded decision onto ctx for the execution attempt so usage
+// records emitted by the executor can be attributed to it.
+func (p *stickyPick) attach(ctx context.Context) context.Context {
+	if p == nil {
+		return ctx
+	}
+	p.mu.Lock()
+	decision, set := p.decision, p.set
+	p.set = false
+	p.mu.Unlock()
+	if !set {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyRouteKey{}, decision)
+}
+
+type stickyStatsStore struct {
+	mu    sync.Mutex
+	stats map[stickyDecision]*StickyRoutingStat
+}
+
+var defaultStickyStats = &stickyStatsStore{stats: make(map[stickyDecision]*StickyRoutingStat)}
+
+// StickyUsagePlugin returns the usage plugin that attributes usage records to sticky routing
+// decisions for StickyRoutingStats. The service registers it once on the usage manager.
+func StickyUsagePlugin() coreusage.Plugin {
+	return stickyUsagePlugin{}
+}
+
+// stickyUsagePlugin attributes usage records to the sticky routing decision that produced them.
+type stickyUsagePlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (stickyUsagePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if ctx == nil {
+		return
+	}
+	decision, ok := ctx.Value(stickyRouteKey{}).(stickyDecision)
+	if !ok {
+		return
+	}
+	defaultStickyStats.record(decision, record)
+}
+
+func (s *stickyStatsStore) record(decision stickyDecision, record coreusage.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat := s.stats[decision]
+	if stat == nil {
+		stat = &StickyRoutingStat{Provider: decision.provider, Strategy: decision.strategy, Outcome: decision.outcome}
+		s.stats[decision] = stat
+	}
+	stat.Requests++
+	if record.Failed {
+		stat.Failed++
+	}
+	stat.InputTokens += record.Detail.InputTokens
+	stat.CachedTokens += record.Detail.CachedTokens
+}
+
+// StickyRoutingStats returns per provider, strategy and outcome usage totals, including
+// cached (prompt-cache hit) tokens, collected since process start.
+func StickyRoutingStats() []StickyRoutingStat {
+	defaultStickyStats.mu.Lock()
+	out := make([]StickyRoutingStat, 0, len(defaultStickyStats.stats))
+	for _, stat := range defaultStickyStats.stats {
+		out = append(out, *stat)
+	}
+	defaultStickyStats.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		if out[i].Strategy != out[j].Strategy {
+			return out[i].Strategy < out[j].Strategy
+		}
+		return out[i].Outcome < out[j].Outcome
+	})
+	return out
+}
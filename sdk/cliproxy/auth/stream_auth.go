@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// ExcludedAuthsMetadataKey lists credential IDs ([]string) that must not serve a request, such
+// as the credential whose stream failed before a mid-stream continuation is issued.
+const ExcludedAuthsMetadataKey = "excluded_auths"
+
+type streamAuthKey struct{}
+
+// StreamAuthRecorder receives the ID of the credential serving a streaming request.
+type StreamAuthRecorder struct {
+	mu     sync.Mutex
+	authID string
+}
+
+// WithStreamAuthRecorder returns a context the manager reports the selected stream credential into.
+func WithStreamAuthRecorder(ctx context.Context) (context.Context, *StreamAuthRecorder) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recorder := &StreamAuthRecorder{}
+	return context.WithValue(ctx, streamAuthKey{}, recorder), recorder
+}
+
+// AuthID returns the credential that served the most recent stream.
+func (r *StreamAuthRecorder) AuthID() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.authID
+}
+
+func noteStreamAuth(ctx context.Context, authID string) {
+	if ctx == nil {
+		return
+	}
+	recorder, ok := ctx.Value(streamAuthKey{}).(*StreamAuthRecorder)
+	if !ok || recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	recorder.authID = authID
+	recorder.mu.Unlock()
+}
+
+// excludedAuths seeds the tried set with the credentials the caller asked to skip.
+func excludedAuths(opts cliproxyexecutor.Options) map[string]struct{} {
+	tried := make(map[string]struct{})
+	if opts.Metadata == nil {
+		return tried
+	}
+	switch ids := opts.Metadata[ExcludedAuthsMetadataKey].(type) {
+	case []string:
+		for _, id := range ids {
+			if id = strings.TrimSpace(id); id != "" {
+				tried[id] = struct{}{}
+			}
+		}
+	case string:
+		if id := strings.TrimSpace(ids); id != "" {
+			tried[id] = struct{}{}
+		}
+	}
+	return tried
+}
@@ -63,6 +63,11 @@ type Auth struct {
 	Runtime any `json:"-"`
 
 	indexAssigned bool `json:"-"`
+	// refreshedAfterUnauthorized marks a token issued by a refresh forced by a 401, so a
+	// further rejection of that token extends the failure streak.
+	refreshedAfterUnauthorized bool `json:"-"`
+	// schedule is the compiled availability schedule attached by the Manager.
+	schedule *compiledSchedule `json:"-"`
 }
 
 // QuotaState contains limiter tracking data for a credential.
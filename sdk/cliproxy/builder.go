@@ -11,9 +11,12 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/module"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -50,6 +53,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// modules are the route modules registered on the server.
+	modules []module.Module
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -167,6 +173,12 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithModules registers route modules. Modules are registered, started and stopped in order.
+func (b *Builder) WithModules(mods ...module.Module) *Builder {
+	b.modules = append(b.modules, mods...)
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -184,6 +196,9 @@ func (b *Builder) Build() (*Service, error) {
 	if b.configPath == "" {
 		return nil, fmt.Errorf("cliproxy: configuration path is required")
 	}
+	if err := validateModules(b.modules); err != nil {
+		return nil, err
+	}
 
 	tokenProvider := b.tokenProvider
 	if tokenProvider == nil {
@@ -261,18 +276,28 @@ func (b *Builder) Build() (*Service, error) {
 				}
 			}
 
+			var sticky *coreauth.SmartStickySelector
 			if sti.RedisEnabled {
 				var ttl time.Duration
 				if sti.TTLSeconds > 0 {
 					ttl = time.Duration(sti.TTLSeconds) * time.Second
 				}
-				selector = coreauth.NewSmartStickySelectorWithRedis(coreauth.RedisOptions{
+				sticky = coreauth.NewSmartStickySelectorWithRedis(coreauth.RedisOptions{
 					Addr:     sti.RedisAddr,
 					Password: sti.RedisPassword,
 					DB:       sti.RedisDB,
 					Prefix:   sti.RedisPrefix,
 					TTL:      ttl,
 				})
+			} else if coreauth.StickyRoutingEnabled(b.cfg.StickyRouting) {
+				sticky = coreauth.NewSmartStickySelector()
+			}
+			// Sticky routing wraps the configured strategy, which still serves
+			// providers and requests that are not sticky-routed.
+			if sticky != nil {
+				sticky.SetFallback(selector)
+				sticky.SetRouting(b.cfg.StickyRouting)
+				selector = sticky
 			}
 		}
 		if selector == nil {
@@ -283,6 +308,18 @@ func (b *Builder) Build() (*Service, error) {
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetOAuthModelMappings(b.cfg.OAuthModelMappings)
+	coreManager.SetConcurrency(b.cfg.Concurrency)
+	coreManager.SetQuarantine(b.cfg.Quarantine)
+	coreManager.AddHook(notify.NewAuthHook(notify.Default(), coreManager))
+
+	serverOptions := append([]api.ServerOption(nil), b.serverOptions...)
+	if len(b.modules) > 0 {
+		routeModules := make([]modules.RouteModuleV2, 0, len(b.modules))
+		for _, mod := range b.modules {
+			routeModules = append(routeModules, routeModuleAdapter{mod: mod})
+		}
+		serverOptions = append(serverOptions, api.WithRouteModules(routeModules...))
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -294,7 +331,9 @@ func (b *Builder) Build() (*Service, error) {
 		authManager:    authManager,
 		accessManager:  accessManager,
 		coreManager:    coreManager,
-		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
+		serverOptions:  serverOptions,
+		modules:        append([]module.Module(nil), b.modules...),
+		modelDiscovery: newCompatModelDiscovery(),
 	}
 	return service, nil
 }
@@ -0,0 +1,243 @@
+package cliproxy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultModelDiscoveryInterval is used when discovery.interval-seconds is unset.
+	defaultModelDiscoveryInterval = time.Hour
+	// minModelDiscoveryInterval bounds how often a single provider is polled.
+	minModelDiscoveryInterval = time.Minute
+	// modelDiscoveryTick controls how often due providers are checked.
+	modelDiscoveryTick = 30 * time.Second
+)
+
+// compatModelDiscovery keeps the last successfully discovered model list per
+// OpenAI-compatible provider so transient upstream failures never drop models.
+type compatModelDiscovery struct {
+	mu sync.Mutex
+	// models holds the raw model IDs from the last successful fetch, keyed by lowercase compat name.
+	models map[string][]string
+	// lastAttempt records when each provider was last polled.
+	lastAttempt map[string]time.Time
+	// applied is the signature of the filtered list currently registered for each provider.
+	applied map[string]string
+	cancel  context.CancelFunc
+}
+
+func newCompatModelDiscovery() *compatModelDiscovery {
+	return &compatModelDiscovery{
+		models:      make(map[string][]string),
+		lastAttempt: make(map[string]time.Time),
+		applied:     make(map[string]string),
+	}
+}
+
+// startModelDiscovery launches the background loop that polls OpenAI-compatible providers
+// with discovery enabled. It is safe to call when no provider enables discovery.
+func (s *Service) startModelDiscovery(parent context.Context) {
+	if s == nil || s.modelDiscovery == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	s.modelDiscovery.mu.Lock()
+	s.modelDiscovery.cancel = cancel
+	s.modelDiscovery.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(modelDiscoveryTick)
+		defer ticker.Stop()
+		s.runModelDiscovery(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runModelDiscovery(ctx)
+			}
+		}
+	}()
+}
+
+// stopModelDiscovery cancels the background discovery loop.
+func (s *Service) stopModelDiscovery() {
+	if s == nil || s.modelDiscovery == nil {
+		return
+	}
+	s.modelDiscovery.mu.Lock()
+	cancel := s.modelDiscovery.cancel
+	s.modelDiscovery.cancel = nil
+	s.modelDiscovery.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// runModelDiscovery polls every due provider and re-registers models for providers whose
+// effective discovered list changed (including filter or enable/disable changes on reload).
+func (s *Service) runModelDiscovery(ctx context.Context) {
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if cfg == nil || s.modelDiscovery == nil {
+		return
+	}
+	d := s.modelDiscovery
+	now := time.Now()
+	active := make(map[string]struct{}, len(cfg.OpenAICompatibility))
+	for i := range cfg.OpenAICompatibility {
+		compat := &cfg.OpenAICompatibility[i]
+		key := strings.ToLower(strings.TrimSpace(compat.Name))
+		if key == "" {
+			continue
+		}
+		active[key] = struct{}{}
+		if compat.Discovery.Enabled {
+			d.mu.Lock()
+			last := d.lastAttempt[key]
+			d.mu.Unlock()
+			if last.IsZero() || now.Sub(last) >= modelDiscoveryInterval(compat) {
+				s.fetchDiscoveredModels(ctx, cfg, key, compat)
+			}
+		}
+		signature := strings.Join(s.discoveredCompatModels(compat), "\n")
+		d.mu.Lock()
+		changed := d.applied[key] != signature
+		d.applied[key] = signature
+		d.mu.Unlock()
+		if changed {
+			s.reregisterCompatAuths(compat.Name)
+		}
+	}
+	d.mu.Lock()
+	for key := range d.applied {
+		if _, ok := active[key]; !ok {
+			delete(d.applied, key)
+			delete(d.models, key)
+			delete(d.lastAttempt, key)
+		}
+	}
+	d.mu.Unlock()
+}
+
+func (s *Service) fetchDiscoveredModels(ctx context.Context, cfg *config.Config, key string, compat *config.OpenAICompatibility) {
+	d := s.modelDiscovery
+	auth := s.discoveryAuthForCompat(compat.Name)
+	if auth == nil {
+		// Leave lastAttempt unset so discovery runs as soon as a credential appears.
+		log.Debugf("model discovery: no active credential for openai-compatibility provider %s", compat.Name)
+		return
+	}
+	d.mu.Lock()
+	d.lastAttempt[key] = time.Now()
+	d.mu.Unlock()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	models, err := executor.FetchOpenAICompatModels(fetchCtx, auth, cfg)
+	if err != nil {
+		d.mu.Lock()
+		cached := len(d.models[key])
+		d.mu.Unlock()
+		log.Warnf("model discovery: failed to list models for %s (keeping %d cached): %v", compat.Name, cached, err)
+		return
+	}
+	d.mu.Lock()
+	d.models[key] = models
+	d.mu.Unlock()
+	log.Debugf("model discovery: %s reported %d models", compat.Name, len(models))
+}
+
+// discoveryAuthForCompat returns the first enabled auth belonging to the compat provider.
+func (s *Service) discoveryAuthForCompat(compatName string) *coreauth.Auth {
+	if s.coreManager == nil {
+		return nil
+	}
+	var picked *coreauth.Auth
+	for _, auth := range s.coreManager.List() {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if _, name, ok := openAICompatInfoFromAuth(auth); !ok || !strings.EqualFold(name, compatName) {
+			continue
+		}
+		if picked == nil || auth.ID < picked.ID {
+			picked = auth
+		}
+	}
+	return picked
+}
+
+func (s *Service) reregisterCompatAuths(compatName string) {
+	if s.coreManager == nil {
+		return
+	}
+	for _, auth := range s.coreManager.List() {
+		if auth == nil {
+			continue
+		}
+		if _, name, ok := openAICompatInfoFromAuth(auth); ok && strings.EqualFold(name, compatName) {
+			s.registerModelsForAuth(auth)
+		}
+	}
+}
+
+// discoveredCompatModels returns the filtered discovered model IDs for a compat provider,
+// or nil when discovery is disabled or nothing has been discovered yet.
+func (s *Service) discoveredCompatModels(compat *config.OpenAICompatibility) []string {
+	if s == nil || s.modelDiscovery == nil || compat == nil || !compat.Discovery.Enabled {
+		return nil
+	}
+	key := strings.ToLower(strings.TrimSpace(compat.Name))
+	s.modelDiscovery.mu.Lock()
+	raw := s.modelDiscovery.models[key]
+	s.modelDiscovery.mu.Unlock()
+	return filterDiscoveredModels(raw, compat.Discovery.Include, compat.Discovery.Exclude)
+}
+
+func modelDiscoveryInterval(compat *config.OpenAICompatibility) time.Duration {
+	interval := defaultModelDiscoveryInterval
+	if compat != nil && compat.Discovery.IntervalSeconds > 0 {
+		interval = time.Duration(compat.Discovery.IntervalSeconds) * time.Second
+	}
+	if interval < minModelDiscoveryInterval {
+		interval = minModelDiscoveryInterval
+	}
+	return interval
+}
+
+// filterDiscoveredModels applies include/exclude wildcard patterns (case-insensitive).
+func filterDiscoveredModels(models, include, exclude []string) []string {
+	if len(models) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(models))
+	for _, id := range models {
+		lower := strings.ToLower(id)
+		if len(include) > 0 && !matchAnyWildcard(include, lower) {
+			continue
+		}
+		if matchAnyWildcard(exclude, lower) {
+			continue
+		}
+		out = append(out, id)
+	}
+	return out
+}
+
+func matchAnyWildcard(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchWildcard(strings.ToLower(strings.TrimSpace(pattern)), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package cliproxy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestFilterDiscoveredModels(t *testing.T) {
+	models := []string{"openai/gpt-4o", "openai/gpt-4o-mini", "Moonshot/Kimi-K2", "meta/llama-3:free"}
+
+	out := filterDiscoveredModels(models, []string{"openai/*", "moonshot/*"}, []string{"*-mini"})
+	want := []string{"openai/gpt-4o", "Moonshot/Kimi-K2"}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("expected %v, got %v", want, out)
+	}
+
+	out = filterDiscoveredModels(models, nil, []string{"*:free"})
+	if len(out) != 3 {
+		t.Fatalf("expected 3 models without include filter, got %v", out)
+	}
+}
+
+func TestDiscoveredCompatModels_RequiresEnabled(t *testing.T) {
+	s := &Service{modelDiscovery: newCompatModelDiscovery()}
+	s.modelDiscovery.models["router"] = []string{"a", "b"}
+	compat := &config.OpenAICompatibility{Name: "Router"}
+
+	if got := s.discoveredCompatModels(compat); got != nil {
+		t.Fatalf("expected no models while discovery is disabled, got %v", got)
+	}
+	compat.Discovery.Enabled = true
+	compat.Discovery.Exclude = []string{"b"}
+	if got := s.discoveredCompatModels(compat); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected [a], got %v", got)
+	}
+}
+
+func TestFetchDiscoveredModels_NoAuthLeavesProviderDue(t *testing.T) {
+	s := &Service{modelDiscovery: newCompatModelDiscovery(), coreManager: coreauth.NewManager(nil, nil, nil)}
+	compat := &config.OpenAICompatibility{Name: "Router"}
+
+	s.fetchDiscoveredModels(context.Background(), &config.Config{}, "router", compat)
+	if last, ok := s.modelDiscovery.lastAttempt["router"]; ok {
+		t.Fatalf("expected no recorded attempt without a credential, got %v", last)
+	}
+}
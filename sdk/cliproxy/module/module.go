@@ -0,0 +1,110 @@
+// Package module defines the public route-module API for embedding CLIProxyAPI.
+//
+// A module attaches its own routes to the HTTP server, may own a typed configuration
+// section under "modules.<name>" in config.yaml, may expose management endpoints under
+// /v0/management/modules/<name>, and may take part in the service lifecycle. Modules are
+// registered through cliproxy.Builder.WithModules.
+package module
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// Module is a pluggable bundle of routes. Optional behaviour is added by also
+// implementing ConfigUpdater, ManagementRoutes, Starter or Stopper.
+type Module interface {
+	// Name returns the unique module identifier. It names the config section and the
+	// management sub-route, so it may only contain letters, digits, '-' and '_'.
+	Name() string
+
+	// Register attaches the module's routes. It is called once while the server is built.
+	Register(ctx Context) error
+}
+
+// ConfigUpdater is implemented by modules that react to configuration hot reloads.
+type ConfigUpdater interface {
+	OnConfigUpdated(cfg *config.Config) error
+}
+
+// ManagementRoutes is implemented by modules that expose management endpoints. The group
+// is rooted at /v0/management/modules/<name> and already requires the management key.
+type ManagementRoutes interface {
+	RegisterManagementRoutes(group *gin.RouterGroup)
+}
+
+// Starter is implemented by modules that run background work. Start is called after
+// routes are registered and before the server accepts connections; an error aborts startup.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by modules that need cleanup. Stop is called after in-flight
+// requests have drained, in reverse registration order.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Context carries the dependencies available to a module during registration.
+type Context struct {
+	// Name is the module's name, used to locate its config section.
+	Name string
+	// Engine is the Gin engine serving the proxy API.
+	Engine *gin.Engine
+	// BaseHandler is shared by the built-in API handlers and executes requests through the auth manager.
+	BaseHandler *handlers.BaseAPIHandler
+	// Config is the configuration at registration time.
+	Config *config.Config
+	// AuthMiddleware enforces the proxy's API-key authentication.
+	AuthMiddleware gin.HandlerFunc
+	// AuthManager schedules credentials and executes provider requests.
+	AuthManager *coreauth.Manager
+	// Usage is the usage bus; modules may register plugins on it or publish records.
+	Usage *usage.Manager
+}
+
+// DecodeConfig decodes the module's config section into out.
+func (c Context) DecodeConfig(out any) (bool, error) {
+	return DecodeConfig(c.Config, c.Name, out)
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateName reports whether name is usable as a module name.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("module: invalid name %q (allowed: letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// DecodeConfig decodes the "modules.<name>" section of cfg into out, which must be a
+// pointer. It reports whether the section was present; out is left untouched otherwise.
+func DecodeConfig(cfg *config.Config, name string, out any) (bool, error) {
+	if cfg == nil || out == nil {
+		return false, nil
+	}
+	node, ok := cfg.Modules[name]
+	if !ok {
+		return false, nil
+	}
+	if err := node.Decode(out); err != nil {
+		return true, fmt.Errorf("module %s: invalid config: %w", name, err)
+	}
+	return true, nil
+}
+
+// Section decodes the "modules.<name>" section of cfg into a value of type T. A missing
+// section yields the zero value.
+func Section[T any](cfg *config.Config, name string) (T, error) {
+	var out T
+	_, err := DecodeConfig(cfg, name, &out)
+	return out, err
+}
@@ -0,0 +1,58 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"gopkg.in/yaml.v3"
+)
+
+type quotaConfig struct {
+	Limit  int      `yaml:"limit"`
+	Models []string `yaml:"models"`
+}
+
+func TestSectionDecodesModuleConfig(t *testing.T) {
+	var cfg config.Config
+	raw := []byte("modules:\n  quota:\n    limit: 7\n    models: [a, b]\n")
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		t.Fatalf("unmarshal config: %v", err)
+	}
+
+	got, err := Section[quotaConfig](&cfg, "quota")
+	if err != nil {
+		t.Fatalf("Section returned error: %v", err)
+	}
+	if got.Limit != 7 || len(got.Models) != 2 {
+		t.Fatalf("unexpected section: %+v", got)
+	}
+
+	var missing quotaConfig
+	found, err := DecodeConfig(&cfg, "other", &missing)
+	if err != nil || found {
+		t.Fatalf("DecodeConfig for missing section = (%t, %v), want (false, nil)", found, err)
+	}
+}
+
+func TestSectionReportsTypeErrors(t *testing.T) {
+	var cfg config.Config
+	if err := yaml.Unmarshal([]byte("modules:\n  quota:\n    limit: many\n"), &cfg); err != nil {
+		t.Fatalf("unmarshal config: %v", err)
+	}
+	if _, err := Section[quotaConfig](&cfg, "quota"); err == nil {
+		t.Fatal("expected decode error for non-integer limit")
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"quota", "my-module_2"} {
+		if err := ValidateName(name); err != nil {
+			t.Fatalf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "a/b", "with space"} {
+		if err := ValidateName(name); err == nil {
+			t.Fatalf("ValidateName(%q) succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/module"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// routeModuleAdapter exposes a public module through the server's internal module interface.
+type routeModuleAdapter struct {
+	mod module.Module
+}
+
+func (a routeModuleAdapter) Name() string { return a.mod.Name() }
+
+func (a routeModuleAdapter) Register(ctx modules.Context) error {
+	return a.mod.Register(module.Context{
+		Name:           a.mod.Name(),
+		Engine:         ctx.Engine,
+		BaseHandler:    ctx.BaseHandler,
+		Config:         ctx.Config,
+		AuthMiddleware: ctx.AuthMiddleware,
+		AuthManager:    ctx.AuthManager,
+		Usage:          usage.DefaultManager(),
+	})
+}
+
+func (a routeModuleAdapter) OnConfigUpdated(cfg *config.Config) error {
+	if updater, ok := a.mod.(module.ConfigUpdater); ok {
+		return updater.OnConfigUpdated(cfg)
+	}
+	return nil
+}
+
+func (a routeModuleAdapter) RegisterManagementRoutes(group *gin.RouterGroup) {
+	if routes, ok := a.mod.(module.ManagementRoutes); ok {
+		routes.RegisterManagementRoutes(group)
+	}
+}
+
+// validateModules rejects nil modules, invalid names and duplicates.
+func validateModules(mods []module.Module) error {
+	seen := make(map[string]struct{}, len(mods))
+	for _, mod := range mods {
+		if mod == nil {
+			return fmt.Errorf("cliproxy: nil module")
+		}
+		name := mod.Name()
+		if err := module.ValidateName(name); err != nil {
+			return err
+		}
+		if _, dup := seen[name]; dup {
+			return fmt.Errorf("cliproxy: duplicate module %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
+
+// startModules starts every module implementing module.Starter. Modules started before a
+// failure are stopped again during shutdown.
+func (s *Service) startModules(ctx context.Context) error {
+	for _, mod := range s.modules {
+		if starter, ok := mod.(module.Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				return fmt.Errorf("cliproxy: failed to start module %s: %w", mod.Name(), err)
+			}
+			log.Debugf("module %s started", mod.Name())
+		}
+		s.startedModules = append(s.startedModules, mod)
+	}
+	return nil
+}
+
+// stopModules stops started modules in reverse registration order, logging failures.
+func (s *Service) stopModules(ctx context.Context) {
+	for i := len(s.startedModules) - 1; i >= 0; i-- {
+		mod := s.startedModules[i]
+		if stopper, ok := mod.(module.Stopper); ok {
+			if err := stopper.Stop(ctx); err != nil {
+				log.Errorf("failed to stop module %s: %v", mod.Name(), err)
+			}
+		}
+	}
+	s.startedModules = nil
+}
@@ -8,19 +8,24 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
-	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/module"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -86,8 +91,20 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// modelDiscovery caches models discovered from OpenAI-compatible providers.
+	modelDiscovery *compatModelDiscovery
+
+	// modules are the route modules supplied through the builder.
+	modules []module.Module
+
+	// startedModules are the modules whose Start hook has run.
+	startedModules []module.Module
 }
 
+// registerStickyUsage guards the process-wide registration of the sticky routing usage plugin.
+var registerStickyUsage sync.Once
+
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
 // This allows external code to monitor API usage and token consumption.
 //
@@ -219,6 +236,11 @@ func (s *Service) wsOnConnected(channelID string) {
 	if s == nil || channelID == "" {
 		return
 	}
+	notify.Notify(notify.Event{
+		Type:    internalconfig.NotifyRelayConnected,
+		Subject: channelID,
+		Message: "websocket relay connected: " + channelID,
+	})
 	if !strings.HasPrefix(strings.ToLower(channelID), "aistudio-") {
 		return
 	}
@@ -261,6 +283,15 @@ func (s *Service) wsOnDisconnected(channelID string, reason error) {
 	} else {
 		log.Infof("websocket provider disconnected: %s", channelID)
 	}
+	event := notify.Event{
+		Type:    internalconfig.NotifyRelayDisconnected,
+		Subject: channelID,
+		Message: "websocket relay disconnected: " + channelID,
+	}
+	if reason != nil {
+		event.Message += " (" + reason.Error() + ")"
+	}
+	notify.Notify(event)
 	ctx := context.Background()
 	s.emitAuthUpdate(ctx, watcher.AuthUpdate{
 		Action: watcher.AuthUpdateActionDelete,
@@ -417,11 +448,10 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	usage.StartDefault(ctx)
+	registerStickyUsage.Do(func() { usage.RegisterPlugin(coreauth.StickyUsagePlugin()) })
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
-		if err := s.Shutdown(shutdownCtx); err != nil {
+		if err := s.Shutdown(context.Background()); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
 	}()
@@ -431,6 +461,7 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	notify.Default().SetConfig(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -457,7 +488,12 @@ func (s *Service) Run(ctx context.Context) error {
 	// legacy clients removed; no caches to refresh
 
 	// handlers no longer depend on legacy clients; pass nil slice initially
-	s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	s.server, err = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
+	if err != nil {
+		return err
+	}
+	// Budgets are rebuilt from the usage statistics the server has just loaded from disk.
+	internalusage.SetPricing(s.cfg.Pricing)
 
 	if s.authManager == nil {
 		s.authManager = newDefaultAuthManager()
@@ -484,6 +520,10 @@ func (s *Service) Run(ctx context.Context) error {
 		})
 	}
 
+	if err := s.startModules(ctx); err != nil {
+		return err
+	}
+
 	if s.hooks.OnBeforeStart != nil {
 		s.hooks.OnBeforeStart(s.cfg)
 	}
@@ -533,16 +573,32 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 		previousStrategy = normalizeStrategy(previousStrategy)
 		nextStrategy = normalizeStrategy(nextStrategy)
-		if s.coreManager != nil && previousStrategy != nextStrategy {
-			var selector coreauth.Selector
-			switch nextStrategy {
-			case "fill-first":
-				selector = &coreauth.FillFirstSelector{}
-			default:
-				selector = &coreauth.RoundRobinSelector{}
+		if s.coreManager != nil {
+			sticky, _ := s.coreManager.Selector().(*coreauth.SmartStickySelector)
+			if sticky == nil && coreauth.StickyRoutingEnabled(newCfg.StickyRouting) {
+				sticky = coreauth.NewSmartStickySelector()
+				sticky.SetFallback(s.coreManager.Selector())
+				s.coreManager.SetSelector(sticky)
+				log.Info("sticky routing enabled")
+			}
+			if previousStrategy != nextStrategy {
+				var selector coreauth.Selector
+				switch nextStrategy {
+				case "fill-first":
+					selector = &coreauth.FillFirstSelector{}
+				default:
+					selector = &coreauth.RoundRobinSelector{}
+				}
+				if sticky != nil {
+					sticky.SetFallback(selector)
+				} else {
+					s.coreManager.SetSelector(selector)
+				}
+				log.Infof("routing strategy updated to %s", nextStrategy)
+			}
+			if sticky != nil {
+				sticky.SetRouting(newCfg.StickyRouting)
 			}
-			s.coreManager.SetSelector(selector)
-			log.Infof("routing strategy updated to %s", nextStrategy)
 		}
 
 		s.applyRetryConfig(newCfg)
@@ -550,10 +606,26 @@ func (s *Service) Run(ctx context.Context) error {
 			s.server.UpdateClients(newCfg)
 		}
 		s.cfgMu.Lock()
+		oldCfg := s.cfg
 		s.cfg = newCfg
 		s.cfgMu.Unlock()
+		notify.Default().SetConfig(newCfg)
+		if oldCfg == nil || !reflect.DeepEqual(oldCfg.Pricing, newCfg.Pricing) {
+			internalusage.SetPricing(newCfg.Pricing)
+		}
+		if oldCfg != newCfg {
+			changes := diff.BuildConfigChangeDetails(oldCfg, newCfg)
+			notify.Notify(notify.Event{
+				Type:    internalconfig.NotifyConfigReloaded,
+				Subject: "config",
+				Message: fmt.Sprintf("configuration reloaded (%d change(s))", len(changes)),
+				Details: map[string]any{"changes": changes},
+			})
+		}
 		if s.coreManager != nil {
 			s.coreManager.SetOAuthModelMappings(newCfg.OAuthModelMappings)
+			s.coreManager.SetConcurrency(newCfg.Concurrency)
+			s.coreManager.SetQuarantine(newCfg.Quarantine)
 		}
 		s.rebindExecutors()
 	}
@@ -583,6 +655,8 @@ func (s *Service) Run(ctx context.Context) error {
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
 	}
 
+	s.startModelDiscovery(ctx)
+
 	select {
 	case <-ctx.Done():
 		log.Debug("service context cancelled, shutting down...")
@@ -611,6 +685,23 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			ctx = context.Background()
 		}
 
+		// Drain the HTTP server first so in-flight streams keep their providers, websocket
+		// relays and credentials until they finish.
+		if s.server != nil {
+			s.cfgMu.RLock()
+			drainTimeout := api.DrainTimeout(s.cfg)
+			s.cfgMu.RUnlock()
+			drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+			defer cancel()
+			log.Infof("draining in-flight requests (up to %s)", drainTimeout)
+			if err := s.server.Stop(drainCtx); err != nil {
+				log.Errorf("error stopping API server: %v", err)
+				shutdownErr = err
+			}
+		}
+
+		s.stopModules(ctx)
+
 		// legacy refresh loop removed; only stopping core auth manager below
 
 		if s.watcherCancel != nil {
@@ -619,10 +710,13 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
+		s.stopModelDiscovery()
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
 				log.Errorf("failed to stop file watcher: %v", err)
-				shutdownErr = err
+				if shutdownErr == nil {
+					shutdownErr = err
+				}
 			}
 		}
 		if s.wsGateway != nil {
@@ -640,22 +734,20 @@ func (s *Service) Shutdown(ctx context.Context) error {
 
 		// no legacy clients to persist
 
-		if s.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			defer cancel()
-			if err := s.server.Stop(shutdownCtx); err != nil {
-				log.Errorf("error stopping API server: %v", err)
-				if shutdownErr == nil {
-					shutdownErr = err
-				}
-			}
-		}
-
 		usage.StopDefault()
 	})
 	return shutdownErr
 }
 
+// Handoff re-executes the binary with the listening socket inherited and returns once the new
+// process is serving. The caller then stops this service, which drains in-flight requests.
+func (s *Service) Handoff(ctx context.Context) error {
+	if s == nil || s.server == nil {
+		return fmt.Errorf("cliproxy: server not running")
+	}
+	return s.server.Handoff(ctx, time.Minute)
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -691,6 +783,11 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			return
 		}
 	}
+	if a.Disabled {
+		// Disabled credentials must not advertise models.
+		GlobalModelRegistry().UnregisterClient(a.ID)
+		return
+	}
 	// Unregister legacy client ID (if present) to avoid double counting
 	if a.Runtime != nil {
 		if idGetter, ok := a.Runtime.(interface{ GetClientID() string }); ok {
@@ -810,7 +907,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 				if strings.EqualFold(compat.Name, compatName) {
 					isCompatAuth = true
 					// Convert compatibility models to registry models
-					ms := make([]*ModelInfo, 0, len(compat.Models))
+					discovered := s.discoveredCompatModels(compat)
+					ms := make([]*ModelInfo, 0, len(compat.Models)+len(discovered))
+					manual := make(map[string]struct{}, len(compat.Models)*2)
 					for j := range compat.Models {
 						m := compat.Models[j]
 						// Use alias as model ID, fallback to name if alias is empty
@@ -826,6 +925,25 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 							Type:        "openai-compatibility",
 							DisplayName: modelID,
 						})
+						manual[strings.ToLower(modelID)] = struct{}{}
+						if m.Name != "" {
+							manual[strings.ToLower(m.Name)] = struct{}{}
+						}
+					}
+					// Discovered models never override manually configured names or aliases.
+					for _, modelID := range discovered {
+						if _, exists := manual[strings.ToLower(modelID)]; exists {
+							continue
+						}
+						manual[strings.ToLower(modelID)] = struct{}{}
+						ms = append(ms, &ModelInfo{
+							ID:          modelID,
+							Object:      "model",
+							Created:     time.Now().Unix(),
+							OwnedBy:     compat.Name,
+							Type:        "openai-compatibility",
+							DisplayName: modelID,
+						})
 					}
 					// Register and return
 					if len(ms) > 0 {
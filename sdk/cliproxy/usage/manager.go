@@ -19,6 +19,10 @@ type Record struct {
 	RequestedAt time.Time
 	Failed      bool
 	Detail      Detail
+	// Cost is the price of the request in Currency. It is filled from the manager's pricer
+	// before plugins run unless the publisher already set it; zero when no price is known.
+	Cost     float64
+	Currency string
 }
 
 // Detail holds the token usage breakdown.
@@ -35,6 +39,12 @@ type Plugin interface {
 	HandleUsage(ctx context.Context, record Record)
 }
 
+// Pricer computes the cost of usage records. It must be safe for concurrent use.
+type Pricer interface {
+	// Price returns the cost of record and its currency; ok is false when no price is known.
+	Price(record Record) (cost float64, currency string, ok bool)
+}
+
 type queueItem struct {
 	ctx    context.Context
 	record Record
@@ -46,13 +56,15 @@ type Manager struct {
 	stopOnce sync.Once
 	cancel   context.CancelFunc
 
-	mu     sync.Mutex
-	cond   *sync.Cond
-	queue  []queueItem
-	closed bool
+	mu          sync.Mutex
+	cond        *sync.Cond
+	queue       []queueItem
+	closed      bool
+	dispatching bool
 
 	pluginsMu sync.RWMutex
 	plugins   []Plugin
+	pricer    Pricer
 }
 
 // NewManager constructs a manager with a buffered queue.
@@ -103,6 +115,16 @@ func (m *Manager) Register(plugin Plugin) {
 	m.pluginsMu.Unlock()
 }
 
+// SetPricer installs the pricer used to fill Record.Cost; nil disables pricing.
+func (m *Manager) SetPricer(pricer Pricer) {
+	if m == nil {
+		return
+	}
+	m.pluginsMu.Lock()
+	m.pricer = pricer
+	m.pluginsMu.Unlock()
+}
+
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
@@ -118,7 +140,34 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	}
 	m.queue = append(m.queue, queueItem{ctx: ctx, record: record})
 	m.mu.Unlock()
-	m.cond.Signal()
+	// Broadcast rather than Signal: Flush callers wait on the same condition.
+	m.cond.Broadcast()
+}
+
+// Flush blocks until every queued record has been delivered to the plugins or ctx is done.
+func (m *Manager) Flush(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Wake the wait below when ctx expires so Flush never outlives its caller.
+	stop := context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	})
+	defer stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.queue) > 0 || m.dispatching {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m.cond.Wait()
+	}
+	return nil
 }
 
 func (m *Manager) run(ctx context.Context) {
@@ -133,8 +182,13 @@ func (m *Manager) run(ctx context.Context) {
 		}
 		item := m.queue[0]
 		m.queue = m.queue[1:]
+		m.dispatching = true
 		m.mu.Unlock()
 		m.dispatch(item)
+		m.mu.Lock()
+		m.dispatching = false
+		m.mu.Unlock()
+		m.cond.Broadcast()
 	}
 }
 
@@ -142,10 +196,14 @@ func (m *Manager) dispatch(item queueItem) {
 	m.pluginsMu.RLock()
 	plugins := make([]Plugin, len(m.plugins))
 	copy(plugins, m.plugins)
+	pricer := m.pricer
 	m.pluginsMu.RUnlock()
 	if len(plugins) == 0 {
 		return
 	}
+	if pricer != nil && item.record.Cost == 0 {
+		item.record = priceRecord(pricer, item.record)
+	}
 	for _, plugin := range plugins {
 		if plugin == nil {
 			continue
@@ -154,6 +212,21 @@ func (m *Manager) dispatch(item queueItem) {
 	}
 }
 
+func priceRecord(pricer Pricer, record Record) (priced Record) {
+	priced = record
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("usage: pricer panic recovered: %v", r)
+			priced = record
+		}
+	}()
+	if cost, currency, ok := pricer.Price(record); ok {
+		priced.Cost = cost
+		priced.Currency = currency
+	}
+	return priced
+}
+
 func safeInvoke(plugin Plugin, ctx context.Context, record Record) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -171,11 +244,17 @@ func DefaultManager() *Manager { return defaultManager }
 // RegisterPlugin registers a plugin on the default manager.
 func RegisterPlugin(plugin Plugin) { DefaultManager().Register(plugin) }
 
+// SetDefaultPricer installs the pricer on the default manager.
+func SetDefaultPricer(pricer Pricer) { DefaultManager().SetPricer(pricer) }
+
 // PublishRecord publishes a record using the default manager.
 func PublishRecord(ctx context.Context, record Record) { DefaultManager().Publish(ctx, record) }
 
 // StartDefault starts the default manager's dispatcher.
 func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
+// FlushDefault waits for the default manager to deliver queued records.
+func FlushDefault(ctx context.Context) error { return DefaultManager().Flush(ctx) }
+
 // StopDefault stops the default manager's dispatcher.
 func StopDefault() { DefaultManager().Stop() }
@@ -0,0 +1,147 @@
+package usage
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type slowPlugin struct {
+	mu      sync.Mutex
+	handled int
+}
+
+func (p *slowPlugin) HandleUsage(_ context.Context, _ Record) {
+	time.Sleep(10 * time.Millisecond)
+	p.mu.Lock()
+	p.handled++
+	p.mu.Unlock()
+}
+
+func TestManagerFlushWaitsForQueuedRecords(t *testing.T) {
+	m := NewManager(0)
+	plugin := &slowPlugin{}
+	m.Register(plugin)
+	m.Start(context.Background())
+	defer m.Stop()
+
+	for i := 0; i < 5; i++ {
+		m.Publish(context.Background(), Record{Model: "test"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := m.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	plugin.mu.Lock()
+	defer plugin.mu.Unlock()
+	if plugin.handled != 5 {
+		t.Fatalf("handled = %d, want 5", plugin.handled)
+	}
+}
+
+func TestManagerFlushHonoursContext(t *testing.T) {
+	m := NewManager(0)
+	m.Register(&slowPlugin{})
+	m.Start(context.Background())
+	defer m.Stop()
+
+	for i := 0; i < 50; i++ {
+		m.Publish(context.Background(), Record{Model: "test"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Flush(ctx); err == nil {
+		t.Fatal("expected Flush to return the context error")
+	}
+}
+
+type blockingPlugin struct {
+	release chan struct{}
+}
+
+func (p *blockingPlugin) HandleUsage(context.Context, Record) {
+	<-p.release
+}
+
+func TestManagerFlushDoesNotLeakWaiters(t *testing.T) {
+	m := NewManager(0)
+	plugin := &blockingPlugin{release: make(chan struct{})}
+	m.Register(plugin)
+	m.Start(context.Background())
+	defer m.Stop()
+	defer close(plugin.release)
+
+	m.Publish(context.Background(), Record{Model: "test"})
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		if err := m.Flush(ctx); err == nil {
+			t.Fatal("expected Flush to return the context error")
+		}
+		cancel()
+	}
+	// Allow the context wake-ups that already fired to finish exiting.
+	deadline := time.Now().Add(time.Second)
+	after := runtime.NumGoroutine()
+	for after > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after > before {
+		t.Fatalf("goroutines grew from %d to %d after timed-out flushes", before, after)
+	}
+}
+
+type fixedPricer struct{}
+
+func (fixedPricer) Price(record Record) (float64, string, bool) {
+	return float64(record.Detail.TotalTokens) / 1000, "USD", record.Model != "free"
+}
+
+type capturePlugin struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (p *capturePlugin) HandleUsage(_ context.Context, record Record) {
+	p.mu.Lock()
+	p.records = append(p.records, record)
+	p.mu.Unlock()
+}
+
+func TestManagerPricesRecordsBeforePlugins(t *testing.T) {
+	m := NewManager(0)
+	plugin := &capturePlugin{}
+	m.Register(plugin)
+	m.SetPricer(fixedPricer{})
+	m.Start(context.Background())
+	defer m.Stop()
+
+	m.Publish(context.Background(), Record{Model: "paid", Detail: Detail{TotalTokens: 500}})
+	m.Publish(context.Background(), Record{Model: "free", Detail: Detail{TotalTokens: 500}})
+	m.Publish(context.Background(), Record{Model: "preset", Cost: 2, Currency: "EUR", Detail: Detail{TotalTokens: 500}})
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	plugin.mu.Lock()
+	defer plugin.mu.Unlock()
+	if len(plugin.records) != 3 {
+		t.Fatalf("records = %d, want 3", len(plugin.records))
+	}
+	if got := plugin.records[0]; got.Cost != 0.5 || got.Currency != "USD" {
+		t.Fatalf("priced record = %+v", got)
+	}
+	if got := plugin.records[1]; got.Cost != 0 || got.Currency != "" {
+		t.Fatalf("unpriced record = %+v", got)
+	}
+	if got := plugin.records[2]; got.Cost != 2 || got.Currency != "EUR" {
+		t.Fatalf("preset cost should be kept, got %+v", got)
+	}
+}
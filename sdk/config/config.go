@@ -15,23 +15,46 @@ type Config = internalconfig.Config
 type StreamingConfig = internalconfig.StreamingConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
+type ManagementKey = internalconfig.ManagementKey
+type NotificationsConfig = internalconfig.NotificationsConfig
+type NotificationWebhook = internalconfig.NotificationWebhook
+type PricingConfig = internalconfig.PricingConfig
+type ModelPrice = internalconfig.ModelPrice
+type SpendBudget = internalconfig.SpendBudget
 type AmpCode = internalconfig.AmpCode
 type ModelNameMapping = internalconfig.ModelNameMapping
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type ResponseRule = internalconfig.ResponseRule
+type StickyRoutingConfig = internalconfig.StickyRoutingConfig
+type ConcurrencyConfig = internalconfig.ConcurrencyConfig
+type MediaFetchConfig = internalconfig.MediaFetchConfig
+type ContextLimitsConfig = internalconfig.ContextLimitsConfig
+type ContextLimitModel = internalconfig.ContextLimitModel
+type AudioConfig = internalconfig.AudioConfig
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
 type VertexCompatKey = internalconfig.VertexCompatKey
+type CredentialSchedule = internalconfig.CredentialSchedule
+type QuarantineConfig = internalconfig.QuarantineConfig
 type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type OpenAICompatibilityDiscovery = internalconfig.OpenAICompatibilityDiscovery
 
 type TLS = internalconfig.TLSConfig
 
+type SecretResolver = internalconfig.SecretResolver
+type SecretResolverFunc = internalconfig.SecretResolverFunc
+type LocalSecretStore = internalconfig.LocalSecretStore
+type SecretRefError = internalconfig.SecretRefError
+
+var ErrUnknownSecretScheme = internalconfig.ErrUnknownSecretScheme
+
 const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
@@ -42,6 +65,15 @@ func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
 	return internalconfig.MakeInlineAPIKeyProvider(keys)
 }
 
+// RegisterSecretResolver makes resolver available for ${scheme:...} references in config files.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	internalconfig.RegisterSecretResolver(scheme, resolver)
+}
+
+func NewLocalSecretStore(path string) *LocalSecretStore {
+	return internalconfig.NewLocalSecretStore(path)
+}
+
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
 
 func LoadConfigOptional(configFile string, optional bool) (*Config, error) {